@@ -0,0 +1,142 @@
+// Package arrowgrate writes grate collections as Apache Arrow record
+// batches. It is a separate module from the grate package, so that
+// programs which do not use Arrow do not depend on it.
+package arrowgrate
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/apache/arrow/go/v14/arrow/memory"
+	"github.com/wubin1989/grate"
+)
+
+// number of rows written per Arrow record batch
+const arrowBatchRows = 1024
+
+// ToArrow writes the remaining records of the collection to w as an Arrow
+// IPC stream of record batches. The column types are inferred from Types()
+// of the first record: "integer" columns are int64, "float" columns are
+// float64, "boolean" columns are bool, "date" columns are timestamp[ms]
+// and all other columns are utf8. Columns are named "col1", "col2", etc.
+//
+// Values of later records which do not match the column type are written
+// as nulls, as are blank values.
+func ToArrow(c grate.Collection, w io.Writer) error {
+	if !c.Next() {
+		if err := c.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("arrowgrate: ToArrow requires at least one record")
+	}
+
+	types := c.Types()
+	fields := make([]arrow.Field, len(types))
+	for i, typ := range types {
+		fields[i] = arrow.Field{Name: fmt.Sprintf("col%d", i+1), Type: arrowType(typ), Nullable: true}
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	mem := memory.NewGoAllocator()
+	b := array.NewRecordBuilder(mem, schema)
+	defer b.Release()
+	aw := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+
+	flush := func() error {
+		rec := b.NewRecord()
+		defer rec.Release()
+		return aw.Write(rec)
+	}
+
+	n := 0
+	for ok := true; ok; ok = c.Next() {
+		vals := c.Values()
+		strs := c.Strings()
+		for i, fb := range b.Fields() {
+			if i >= len(vals) || strs[i] == "" {
+				fb.AppendNull()
+				continue
+			}
+			appendArrowValue(fb, vals[i], strs[i])
+		}
+		n++
+		if n == arrowBatchRows {
+			if err := flush(); err != nil {
+				aw.Close()
+				return err
+			}
+			n = 0
+		}
+	}
+	if err := c.Err(); err != nil {
+		aw.Close()
+		return err
+	}
+	if n > 0 {
+		if err := flush(); err != nil {
+			aw.Close()
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// arrowType returns the Arrow column type for a Types() value.
+func arrowType(typ string) arrow.DataType {
+	switch typ {
+	case "integer":
+		return arrow.PrimitiveTypes.Int64
+	case "float":
+		return arrow.PrimitiveTypes.Float64
+	case "boolean":
+		return arrow.FixedWidthTypes.Boolean
+	case "date":
+		return arrow.FixedWidthTypes.Timestamp_ms
+	}
+	return arrow.BinaryTypes.String
+}
+
+// appendArrowValue appends v to the column builder, or a null if it does
+// not match the column type.
+func appendArrowValue(fb array.Builder, v interface{}, s string) {
+	switch b := fb.(type) {
+	case *array.Int64Builder:
+		switch x := v.(type) {
+		case int64:
+			b.Append(x)
+			return
+		case float64:
+			if x == float64(int64(x)) {
+				b.Append(int64(x))
+				return
+			}
+		}
+	case *array.Float64Builder:
+		switch x := v.(type) {
+		case float64:
+			b.Append(x)
+			return
+		case int64:
+			b.Append(float64(x))
+			return
+		}
+	case *array.BooleanBuilder:
+		if x, ok := v.(bool); ok {
+			b.Append(x)
+			return
+		}
+	case *array.TimestampBuilder:
+		if x, ok := v.(time.Time); ok {
+			b.Append(arrow.Timestamp(x.UnixMilli()))
+			return
+		}
+	case *array.StringBuilder:
+		b.Append(s)
+		return
+	}
+	fb.AppendNull()
+}
@@ -0,0 +1,93 @@
+package arrowgrate
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v14/arrow"
+	"github.com/apache/arrow/go/v14/arrow/array"
+	"github.com/apache/arrow/go/v14/arrow/ipc"
+	"github.com/wubin1989/grate"
+)
+
+// typedCollection is a grate.Collection of records with fixed types and
+// native values.
+type typedCollection struct {
+	strs   [][]string
+	types  [][]string
+	values [][]interface{}
+	row    int
+}
+
+func (c *typedCollection) Next() bool {
+	c.row++
+	return c.row < len(c.strs)
+}
+
+func (c *typedCollection) Row() int                       { return c.row }
+func (c *typedCollection) Strings() []string              { return c.strs[c.row] }
+func (c *typedCollection) Types() []string                { return c.types[c.row] }
+func (c *typedCollection) Values() []interface{}          { return c.values[c.row] }
+func (c *typedCollection) Formats() []string              { return make([]string, len(c.strs[c.row])) }
+func (c *typedCollection) Scan(args ...interface{}) error { return grate.ErrInvalidScanType }
+func (c *typedCollection) IsEmpty() bool                  { return len(c.strs) == 0 }
+func (c *typedCollection) Width() int                     { return len(c.strs[0]) }
+func (c *typedCollection) Err() error                     { return nil }
+
+func TestToArrow(t *testing.T) {
+	tc := &typedCollection{
+		strs: [][]string{
+			{"1", "1.5", "true", "2021-03-04", "x"},
+			{"2", "", "false", "bad", "y"},
+		},
+		types: [][]string{
+			{"integer", "float", "boolean", "date", "string"},
+			{"integer", "blank", "boolean", "date", "string"},
+		},
+		values: [][]interface{}{
+			{int64(1), 1.5, true, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), "x"},
+			{int64(2), "", false, "bad", "y"},
+		},
+		row: -1,
+	}
+	var buf bytes.Buffer
+	if err := ToArrow(tc, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	want := []arrow.DataType{
+		arrow.PrimitiveTypes.Int64, arrow.PrimitiveTypes.Float64, arrow.FixedWidthTypes.Boolean,
+		arrow.FixedWidthTypes.Timestamp_ms, arrow.BinaryTypes.String,
+	}
+	for i, f := range r.Schema().Fields() {
+		if !arrow.TypeEqual(f.Type, want[i]) {
+			t.Errorf("column %d: got type %v, expected %v", i, f.Type, want[i])
+		}
+	}
+	if !r.Next() {
+		t.Fatal("expected a record batch")
+	}
+	rec := r.Record()
+	if rec.NumRows() != 2 {
+		t.Fatalf("expected 2 rows, got %d", rec.NumRows())
+	}
+	if v := rec.Column(0).(*array.Int64).Value(1); v != 2 {
+		t.Errorf("got integer %d, expected 2", v)
+	}
+	if !rec.Column(1).IsNull(1) || !rec.Column(3).IsNull(1) {
+		t.Error("expected blank and unparsable values to be null")
+	}
+	ts := rec.Column(3).(*array.Timestamp).Value(0)
+	if got := time.UnixMilli(int64(ts)).UTC(); !got.Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got date %v", got)
+	}
+	if v := rec.Column(4).(*array.String).Value(1); v != "y" {
+		t.Errorf("got string %q, expected y", v)
+	}
+}
@@ -0,0 +1,267 @@
+package grate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedCollection is a Collection held entirely in memory, which allows
+// rewinding and random access to the rows.
+type CachedCollection interface {
+	Collection
+
+	// Len returns the number of rows in the collection.
+	Len() int
+
+	// RowAt returns the string values of the i-th (0-based) row. It was
+	// named Row until Collection.Row was added, which returns the index of
+	// the current row instead; callers of Row(i) should use RowAt(i).
+	RowAt(i int) []string
+
+	// Rewind resets iteration to before the first row.
+	Rewind()
+}
+
+// Cache drains the collection into memory and returns a CachedCollection
+// over the same records. If the collection reports its number of rows
+// through a Len() method, the backing slices are allocated up front.
+//
+// Memory usage is proportional to the size of the collection, so it is up
+// to the caller to only cache reasonably sized sheets.
+func Cache(c Collection) (CachedCollection, error) {
+	n := 0
+	if lc, ok := c.(interface{ Len() int }); ok {
+		n = lc.Len()
+	}
+	cc := &cachedCollection{
+		rows:    make([][]string, 0, n),
+		types:   make([][]string, 0, n),
+		values:  make([][]interface{}, 0, n),
+		formats: make([][]string, 0, n),
+		iterRow: -1,
+	}
+	for c.Next() {
+		cc.rows = append(cc.rows, c.Strings())
+		cc.types = append(cc.types, c.Types())
+		cc.values = append(cc.values, append([]interface{}(nil), c.Values()...))
+		cc.formats = append(cc.formats, c.Formats())
+	}
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+	return cc, nil
+}
+
+type cachedCollection struct {
+	rows    [][]string
+	types   [][]string
+	values  [][]interface{}
+	formats [][]string
+	iterRow int
+}
+
+// Len returns the number of rows in the collection.
+func (c *cachedCollection) Len() int {
+	return len(c.rows)
+}
+
+// RowAt returns the string values of the i-th (0-based) row.
+func (c *cachedCollection) RowAt(i int) []string {
+	return c.rows[i]
+}
+
+// Row returns the zero-based index of the current row.
+func (c *cachedCollection) Row() int {
+	if c.iterRow >= len(c.rows) {
+		return len(c.rows) - 1
+	}
+	return c.iterRow
+}
+
+// Rewind resets iteration to before the first row.
+func (c *cachedCollection) Rewind() {
+	c.iterRow = -1
+}
+
+// Next advances to the next record of content.
+func (c *cachedCollection) Next() bool {
+	if c.iterRow >= len(c.rows) {
+		return false
+	}
+	c.iterRow++
+	return c.iterRow < len(c.rows)
+}
+
+// Strings extracts values from the current record into a list of strings.
+func (c *cachedCollection) Strings() []string {
+	return c.rows[c.iterRow]
+}
+
+// Types extracts the data types from the current record into a list.
+func (c *cachedCollection) Types() []string {
+	return c.types[c.iterRow]
+}
+
+// Values extracts the native values of the current record into a list, as
+// returned by the cached collection.
+func (c *cachedCollection) Values() []interface{} {
+	return c.values[c.iterRow]
+}
+
+// Formats extracts the format codes for the current record into a list.
+func (c *cachedCollection) Formats() []string {
+	return c.formats[c.iterRow]
+}
+
+// Scan extracts values from the current record into the provided arguments.
+// Native values are stored directly, as for the cached collection.
+func (c *cachedCollection) Scan(args ...interface{}) error {
+	return scanValues(c.values[c.iterRow], c.rows[c.iterRow], args)
+}
+
+// scanValues stores the native values vals into args, converting integers
+// to floats if needed. Other values which do not match their destination
+// are parsed from their string representation strs, as for scanStrings.
+func scanValues(vals []interface{}, strs []string, args []interface{}) error {
+	if len(vals) < len(args) {
+		return fmt.Errorf("grate: expected at most %d Scan destinations, got %d", len(vals), len(args))
+	}
+
+	for i, a := range args {
+		switch v := a.(type) {
+		case *bool:
+			if x, ok := vals[i].(bool); ok {
+				*v = x
+				continue
+			}
+		case *int64:
+			if x, ok := vals[i].(int64); ok {
+				*v = x
+				continue
+			}
+		case *float64:
+			switch x := vals[i].(type) {
+			case float64:
+				*v = x
+				continue
+			case int64:
+				*v = float64(x)
+				continue
+			}
+		case *time.Time:
+			if x, ok := vals[i].(time.Time); ok {
+				*v = x
+				continue
+			}
+		}
+		s := fmt.Sprint(vals[i])
+		if i < len(strs) {
+			s = strs[i]
+		}
+		if err := scanStrings([]string{s}, args[i:i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanStrings parses the string values of row into args.
+func scanStrings(row []string, args []interface{}) error {
+	if len(row) < len(args) {
+		return fmt.Errorf("grate: expected at most %d Scan destinations, got %d", len(row), len(args))
+	}
+
+	var err error
+	for i, a := range args {
+		switch v := a.(type) {
+		case *bool:
+			switch strings.ToLower(row[i]) {
+			case "1", "t", "true", "y", "yes":
+				*v = true
+			default:
+				*v = false
+			}
+		case *int64:
+			*v, err = strconv.ParseInt(row[i], 10, 64)
+		case *float64:
+			*v, err = strconv.ParseFloat(row[i], 64)
+		case *string:
+			*v = row[i]
+		case *time.Time:
+			*v, err = time.Parse(time.RFC3339, row[i])
+		default:
+			return ErrInvalidScanType
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if there are no data values.
+func (c *cachedCollection) IsEmpty() bool {
+	return len(c.rows) == 0
+}
+
+// Width returns the maximum number of values of the rows.
+func (c *cachedCollection) Width() int {
+	w := 0
+	for _, row := range c.rows {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w
+}
+
+// Err returns the last error that occured.
+func (c *cachedCollection) Err() error {
+	return nil
+}
+
+// CachedSource wraps a Source so that List is only called once on the
+// underlying source, and the result returned on subsequent calls. Get and
+// Close are passed through unchanged.
+//
+// Sources should always return the same list, so this is only a safety
+// net for implementations where List is expensive.
+func CachedSource(s Source) Source {
+	return &cachedSource{Source: s}
+}
+
+type cachedSource struct {
+	Source
+
+	mu     sync.Mutex
+	loaded bool
+	names  []string
+}
+
+// List the individual data tables within this source. Each call returns a
+// new slice, so callers may modify it.
+func (s *cachedSource) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		names, err := s.Source.List()
+		if err != nil {
+			return nil, err
+		}
+		s.names, s.loaded = names, true
+	}
+	return append([]string(nil), s.names...), nil
+}
+
+// SheetCount returns the number of data tables within this source.
+func (s *cachedSource) SheetCount() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return len(s.names), nil
+	}
+	return s.Source.SheetCount()
+}
@@ -0,0 +1,159 @@
+package grate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c, err := Cache(newTestCollection(
+		[]string{"a", "b"},
+		[]string{"1", "2"},
+		[]string{"3", ""},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 3 {
+		t.Fatalf("expected 3 rows, got %d", c.Len())
+	}
+	if !reflect.DeepEqual(c.RowAt(1), []string{"1", "2"}) {
+		t.Fatalf("unexpected row 1: %v", c.RowAt(1))
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		n := 0
+		if c.Row() != -1 {
+			t.Fatalf("pass %d: expected row -1 before Next, got %d", pass, c.Row())
+		}
+		for c.Next() {
+			if c.Row() != n {
+				t.Fatalf("pass %d: expected row %d, got %d", pass, n, c.Row())
+			}
+			n++
+		}
+		if n != 3 {
+			t.Fatalf("pass %d: expected 3 rows, got %d", pass, n)
+		}
+		c.Rewind()
+	}
+
+	c.Next()
+	c.Next()
+	var a, b int64
+	if err := c.Scan(&a, &b); err != nil {
+		t.Fatal(err)
+	}
+	if a != 1 || b != 2 {
+		t.Fatalf("unexpected scan results %d %d", a, b)
+	}
+}
+
+type countingSource struct {
+	testSource
+	calls int
+}
+
+func (c *countingSource) List() ([]string, error) {
+	c.calls++
+	return c.testSource.List()
+}
+
+func TestCachedSource(t *testing.T) {
+	src := &countingSource{testSource: testSource{names: []string{"a", "b"}}}
+	cs := CachedSource(src)
+	for i := 0; i < 3; i++ {
+		names, err := cs.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, []string{"a", "b"}) {
+			t.Fatalf("unexpected names %v", names)
+		}
+	}
+	if src.calls != 1 {
+		t.Fatalf("expected 1 call to List, got %d", src.calls)
+	}
+	if n, err := cs.SheetCount(); err != nil || n != 2 {
+		t.Fatalf("unexpected SheetCount %d, %v", n, err)
+	}
+
+	// the cached list can't be modified through a returned slice
+	names, _ := cs.List()
+	names[0] = "changed"
+	if names, _ = cs.List(); names[0] != "a" {
+		t.Errorf("expected the cached names to be unchanged, got %v", names)
+	}
+
+	// an empty (nil) list is cached too
+	src = &countingSource{}
+	cs = CachedSource(src)
+	for i := 0; i < 2; i++ {
+		if names, err := cs.List(); err != nil || len(names) != 0 {
+			t.Fatalf("unexpected names %v, %v", names, err)
+		}
+	}
+	if src.calls != 1 {
+		t.Errorf("expected 1 call to List for an empty source, got %d", src.calls)
+	}
+}
+
+func TestValues(t *testing.T) {
+	tc := newTestCollection([]string{"12", "1.5", "yes", "2021-03-04", "x", "", "1,234"})
+	tc.types = [][]string{{"integer", "float", "boolean", "date", "string", "blank", "integer"}}
+	c, err := Cache(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() {
+		t.Fatal("expected a row")
+	}
+	expect := []interface{}{int64(12), 1.5, true, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), "x", "", "1,234"}
+	if got := c.Values(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("got %#v, expected %#v", got, expect)
+	}
+	if c.Width() != 7 {
+		t.Errorf("expected width 7, got %d", c.Width())
+	}
+}
+
+func TestCacheNativeValues(t *testing.T) {
+	// values are kept as returned, not parsed from their formatted strings
+	date := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tc := newTestCollection([]string{"3/4/21 5:06", "1,234", "12.50%"})
+	tc.types = [][]string{{"date", "integer", "float"}}
+	tc.values = [][]interface{}{{date, int64(1234), 0.125}}
+	c, err := Cache(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Next()
+	if got := c.Values(); !reflect.DeepEqual(got, tc.values[0]) {
+		t.Errorf("got %#v, expected %#v", got, tc.values[0])
+	}
+}
+
+func TestCacheScan(t *testing.T) {
+	date := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tc := newTestCollection([]string{"3/4/21 5:06", "1,234", "1,234", "x"})
+	tc.types = [][]string{{"date", "integer", "integer", "string"}}
+	tc.values = [][]interface{}{{date, int64(1234), int64(1234), "x"}}
+	c, err := Cache(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Next()
+	var (
+		d time.Time
+		n int64
+		f float64
+		s string
+	)
+	if err := c.Scan(&d, &n, &f, &s); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Equal(date) || n != 1234 || f != 1234 || s != "x" {
+		t.Errorf("got %v, %d, %v, %q", d, n, f, s)
+	}
+}
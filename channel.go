@@ -0,0 +1,31 @@
+package grate
+
+// ChannelSource iterates over c in a new goroutine, sending a copy of the
+// string values of each record to the returned rows channel, which has a
+// buffer of bufSize records. The rows channel is closed when iteration
+// finishes. If iteration stops with an error, a nil row is sent before the
+// rows channel is closed, and the error is sent on the errs channel. The
+// errs channel is closed after the rows channel, so receiving from it
+// after draining the rows returns the error, or nil on success.
+//
+// The consumer must drain the rows channel, otherwise the goroutine blocks.
+func ChannelSource(c Collection, bufSize int) (rows <-chan []string, errs <-chan error) {
+	rc := make(chan []string, bufSize)
+	ec := make(chan error, 1)
+	go func() {
+		defer close(ec)
+		defer close(rc)
+		for c.Next() {
+			// non-nil even for empty records, as nil marks an error
+			strs := c.Strings()
+			row := make([]string, len(strs))
+			copy(row, strs)
+			rc <- row
+		}
+		if err := c.Err(); err != nil {
+			rc <- nil
+			ec <- err
+		}
+	}()
+	return rc, ec
+}
@@ -0,0 +1,47 @@
+package grate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestChannelSource(t *testing.T) {
+	rows, errs := ChannelSource(newTestCollection(
+		[]string{"a", "1"},
+		[]string{"b", "2"},
+	), 1)
+	var got [][]string
+	for row := range rows {
+		got = append(got, row)
+	}
+	if want := [][]string{{"a", "1"}, {"b", "2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	failing := newTestCollection([]string{"a"})
+	failing.err = errors.New("test: parse error")
+	rows, errs = ChannelSource(failing, 0)
+	got = nil
+	for row := range rows {
+		got = append(got, row)
+	}
+	if len(got) != 2 || got[1] != nil {
+		t.Errorf("expected a row followed by nil, got %q", got)
+	}
+	if err := <-errs; err != failing.err {
+		t.Errorf("expected the collection error, got %v", err)
+	}
+
+	// empty records are not mistaken for errors
+	rows, errs = ChannelSource(newTestCollection([]string{}), 1)
+	if row := <-rows; row == nil {
+		t.Error("expected an empty row, got nil")
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
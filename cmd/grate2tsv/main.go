@@ -5,14 +5,27 @@
 // Files on the command line will be parsed and extracted to the "results"
 // subdirectory under a heirarchical arrangement (to make our filesystems
 // more responsive), and a "results.txt" file will be created logging basic
-// information and errors for each file.
+// information and errors for each file. With -manifest, the same
+// information (and the path of each output file) is also written as JSON
+// for downstream tools.
+//
+// A filename of "-" reads more filenames from stdin, one per line, so that
+// large sets of files can be piped in:
+//
+//	find . -name '*.xlsx' | grate2tsv -
 package main
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"log"
@@ -21,6 +34,7 @@ import (
 	"regexp"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -38,12 +52,40 @@ var (
 	removeNewlines = flag.Bool("r", true, "remove embedded tabs, newlines, and condense spaces in cell contents")
 	trimSpaces     = flag.Bool("w", true, "trim whitespace from cell contents")
 	skipBlanks     = flag.Bool("b", true, "discard blank rows from the output")
+	maxRows        = flag.Int("max-rows", 0, "stop writing each sheet after `N` data rows (0 for no limit)")
+	zipFile        = flag.String("zip", "", "write all output .tsv files (and the stats file) into a single `archive.zip`, created once all files are processed (with -resume, the outputs of skipped files are copied from the existing archive)")
+	workers        = flag.Int("workers", 0, "number of files to process in parallel (0 for half the number of CPUs)")
+	resume         = flag.Bool("resume", false, "skip files already completed by a previous run whose outputs still exist (tracked in the stats `filename` + \".state\")")
+	writeMeta      = flag.Bool("meta", false, "write a .meta.json file describing each sheet alongside its .tsv")
+	deduplicate    = flag.Bool("deduplicate", false, "skip files with the same content as a file already processed in this run")
+	manifestFile   = flag.String("manifest", "", "write a JSON list of the output files and their sheets to `manifest.json`")
 	cpuprofile     = flag.String("cpuprofile", "", "write cpu profile to file")
 	memprofile     = flag.String("memprofile", "", "write memory profile to file")
 
 	timeFormat = "2006-01-02 15:04:05"
 	fstats     *os.File
 
+	// output archive (with -zip), only written by the cleanup goroutine
+	zout *zip.Writer
+
+	// the archive of a previous run (with -zip and -resume), whose members
+	// are copied to the new archive for the files which are not processed
+	// again, and the state file lines of newly completed files, which are
+	// recorded once the new archive replaces it
+	prevZip      *zip.ReadCloser
+	prevMembers  = make(map[string]*zip.File)
+	pendingState []string
+
+	// files completed in previous runs by content hash (with -resume)
+	fstate    *os.File
+	completed = make(map[string]*completedFile)
+
+	// SHA-256 hashes of the files seen in this run (with -deduplicate)
+	seen = make(map[string]bool)
+
+	// entries of the -manifest file, guarded by the stats mutex
+	manifest = []manifestEntry{}
+
 	procWG  sync.WaitGroup
 	cleanup = make(chan *output, 100)
 	outpool = sync.Pool{New: func() interface{} {
@@ -54,10 +96,102 @@ var (
 type output struct {
 	f *os.File
 	b *bufio.Writer
+
+	// with -zip, the archive member name and buffered contents
+	name string
+	buf  bytes.Buffer
+
+	// with -resume, the state file line of a completed file, recorded
+	// once the outputs queued before it are written
+	state string
+
+	// with -zip and -resume, the members of the previous archive to copy
+	copies []string
+}
+
+// completedFile is a line of the -resume state file, recording an input
+// completed by a previous run and the outputs written for its sheets.
+type completedFile struct {
+	Hash   string           `json:"hash"`
+	Sheets []completedSheet `json:"sheets"`
+}
+
+// completedSheet records the stats and output paths of a sheet of a
+// completed file.
+type completedSheet struct {
+	Sheet      string `json:"sheet"`
+	Rows       int    `json:"rows"`
+	Cols       int    `json:"cols"`
+	Hash       string `json:"hash"`
+	OutputPath string `json:"output_path,omitempty"`
+	MetaPath   string `json:"meta_path,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// newCompletedFile returns the state of a file with content hash h, from
+// the stats of its sheets.
+func newCompletedFile(h string, results []stats) *completedFile {
+	cf := &completedFile{Hash: h, Sheets: make([]completedSheet, 0, len(results))}
+	for _, res := range results {
+		cs := completedSheet{
+			Sheet:      res.SheetName,
+			Rows:       res.NumRows,
+			Cols:       res.NumCols,
+			Hash:       res.Hash,
+			OutputPath: res.OutputPath,
+			MetaPath:   res.MetaPath,
+		}
+		if res.Err != nil {
+			cs.Error = res.Err.Error()
+		}
+		cf.Sheets = append(cf.Sheets, cs)
+	}
+	return cf
+}
+
+// stats returns the stats of a completed sheet, as read from the file fn.
+func (cs completedSheet) stats(fn string) stats {
+	st := stats{
+		Filename:   fn,
+		SheetName:  cs.Sheet,
+		Hash:       cs.Hash,
+		NumRows:    cs.Rows,
+		NumCols:    cs.Cols,
+		OutputPath: cs.OutputPath,
+		MetaPath:   cs.MetaPath,
+	}
+	if cs.Error != "" {
+		st.Err = errors.New(cs.Error)
+	}
+	return st
+}
+
+// outputsExist returns true if the outputs of a completed file are still
+// available, so that it does not need to be processed again.
+func (cf *completedFile) outputsExist() bool {
+	for _, cs := range cf.Sheets {
+		for _, fn := range []string{cs.OutputPath, cs.MetaPath} {
+			if fn == "" {
+				continue
+			}
+			if zout != nil {
+				if prevMembers[fn] == nil {
+					return false
+				}
+			} else if _, err := os.Stat(fn); err != nil {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 func main() {
 	flag.Parse()
+	if *workers < 0 {
+		fmt.Fprintf(os.Stderr, "invalid -workers %d: must be 0 (automatic) or a positive number of workers\n", *workers)
+		os.Exit(2)
+	}
 
 	if *memprofile != "" {
 		f, err := os.Create(*memprofile)
@@ -89,11 +223,66 @@ func main() {
 		log.SetOutput(fo)
 	}
 
+	// the archive is written to a temporary file, and only renamed to the
+	// -zip filename once it is complete, so that an interrupted run does
+	// not leave an invalid archive behind
+	var fz *os.File
+	if *zipFile != "" {
+		var err error
+		fz, err = os.CreateTemp(filepath.Dir(*zipFile), filepath.Base(*zipFile)+".*.tmp")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fz.Close()
+		zout = zip.NewWriter(fz)
+
+		if *resume {
+			// files completed by the previous run are only skipped if their
+			// outputs are in its archive, and are then copied over
+			prevZip, err = zip.OpenReader(*zipFile)
+			if err == nil {
+				defer prevZip.Close()
+				for _, zf := range prevZip.File {
+					prevMembers[zf.Name] = zf
+				}
+			} else if !os.IsNotExist(err) {
+				log.Printf("Not resuming from archive '%s': %v", *zipFile, err)
+			}
+		}
+	}
+
 	done := make(chan int)
 	go func() {
+		copied := make(map[string]bool)
 		for x := range cleanup {
-			x.b.Flush()
-			x.f.Close()
+			if x.state != "" {
+				if zout != nil {
+					// the outputs are only durable once the archive is renamed
+					pendingState = append(pendingState, x.state)
+				} else {
+					fmt.Fprintln(fstate, x.state)
+				}
+				x.state = ""
+			} else if x.copies != nil {
+				for _, name := range x.copies {
+					if copied[name] {
+						continue
+					}
+					copied[name] = true
+					if err := zout.Copy(prevMembers[name]); err != nil {
+						log.Println(err)
+					}
+				}
+				x.copies = nil
+			} else if zout != nil {
+				if err := writeZipMember(x.name, &x.buf); err != nil {
+					log.Println(err)
+				}
+				x.buf.Reset()
+			} else {
+				x.b.Flush()
+				x.f.Close()
+			}
 			outpool.Put(x)
 		}
 		done <- 1
@@ -113,17 +302,37 @@ func main() {
 		fmt.Fprintf(fstats, "time\tfilename\tsheet\trows\tcolumns\terrors\n")
 	}
 
+	if *resume {
+		fstate, err = openState(*infoFile + ".state")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer fstate.Close()
+	}
+
 	filenameChan := make(chan string)
 
-	// fan out to 1/2 of CPU cores
+	// fan out to 1/2 of CPU cores by default
 	// (e.g. each file-processor can use 2 cpus)
 	outMu := &sync.Mutex{}
-	nparallel := runtime.NumCPU() / 2
+	nparallel := *workers
+	if nparallel == 0 {
+		nparallel = runtime.NumCPU() / 2
+	}
+	if nparallel < 1 {
+		nparallel = 1
+	}
 	procWG.Add(nparallel)
 	for i := 0; i < nparallel; i++ {
 		go runProcessor(filenameChan, outMu)
 	}
 	for _, fn := range flag.Args() {
+		if fn == "-" {
+			if err := readFilenames(os.Stdin, filenameChan); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
 		filenameChan <- fn
 	}
 
@@ -131,16 +340,198 @@ func main() {
 	procWG.Wait()
 	close(cleanup)
 	<-done
+
+	if *manifestFile != "" {
+		if err := saveManifest(*manifestFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if zout != nil {
+		// include the stats file in the archive
+		if _, err := fstats.Seek(0, io.SeekStart); err != nil {
+			log.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, fstats); err != nil {
+			log.Fatal(err)
+		}
+		if err := writeZipMember(filepath.Base(*infoFile), &buf); err != nil {
+			log.Fatal(err)
+		}
+		if err := zout.Close(); err != nil {
+			log.Fatal(err)
+		}
+		if err := fz.Close(); err != nil {
+			log.Fatal(err)
+		}
+		if prevZip != nil {
+			prevZip.Close()
+		}
+		if err := os.Rename(fz.Name(), *zipFile); err != nil {
+			log.Fatal(err)
+		}
+		for _, line := range pendingState {
+			fmt.Fprintln(fstate, line)
+		}
+	}
+}
+
+// writeZipMember adds a file to the output archive, flushing it so that
+// the archive contents up to this point are on disk. The archive is only
+// valid (and renamed to the -zip filename) once it is closed.
+func writeZipMember(name string, r io.Reader) error {
+	w, err := zout.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		return err
+	}
+	return zout.Flush()
+}
+
+// readFilenames sends the filenames read from r, one per line, to ch as
+// they arrive. Whitespace is trimmed and empty lines are skipped.
+func readFilenames(r io.Reader, ch chan<- string) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if fn := strings.TrimSpace(s.Text()); fn != "" {
+			ch <- fn
+		}
+	}
+	return s.Err()
+}
+
+// openState loads the files completed by previous runs, and opens the
+// state file to record newly completed ones. Lines which cannot be decoded
+// (e.g. written by an interrupted run) are ignored, so those files are
+// processed again.
+func openState(fn string) (*os.File, error) {
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 16<<20)
+	for s.Scan() {
+		cf := &completedFile{}
+		if err := json.Unmarshal(s.Bytes(), cf); err != nil || cf.Hash == "" {
+			log.Printf("Ignoring invalid state '%s'", s.Text())
+			continue
+		}
+		completed[cf.Hash] = cf
+	}
+	if err = s.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// hashFile returns the hex-encoded hashes of the file contents, in a
+// single pass over the file.
+func hashFile(fn string, hs ...hash.Hash) ([]string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ws := make([]io.Writer, len(hs))
+	for i, h := range hs {
+		ws[i] = h
+	}
+	if _, err = io.Copy(io.MultiWriter(ws...), f); err != nil {
+		return nil, err
+	}
+	res := make([]string, len(hs))
+	for i, h := range hs {
+		res[i] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return res, nil
 }
 
 func runProcessor(from chan string, mu *sync.Mutex) {
 	for fn := range from {
 		nowFmt := time.Now().Format(timeFormat)
-		results, err := processFile(fn)
+
+		// the resume state records MD5 hashes, duplicates are found by SHA-256
+		var contentHash, dedupHash string
+		if fstate != nil || *deduplicate {
+			hashes, err := hashFile(fn, md5.New(), sha256.New())
+			if err != nil {
+				mu.Lock()
+				fmt.Fprintf(fstats, "%s\t%s\t-\t-\t-\t%s\n", nowFmt, fn, err.Error())
+				addManifest(stats{Filename: fn, Err: err})
+				mu.Unlock()
+				continue
+			}
+			contentHash, dedupHash = hashes[0], hashes[1]
+
+			mu.Lock()
+			skip := ""
+			cf := completed[contentHash]
+			if fstate != nil && cf != nil && cf.outputsExist() {
+				skip = "resumed"
+			} else if *deduplicate {
+				if seen[dedupHash] {
+					skip = "duplicate"
+				}
+				seen[dedupHash] = true
+			}
+			if skip != "" {
+				log.Printf("Skipping file '%s' (%s)", fn, skip)
+				fmt.Fprintf(fstats, "%s\t%s\t-\t-\t-\t%s\n", nowFmt, fn, skip)
+				if skip == "resumed" {
+					// the outputs of the previous run are still valid
+					var copies []string
+					for _, cs := range cf.Sheets {
+						addManifest(cs.stats(fn))
+						for _, name := range []string{cs.OutputPath, cs.MetaPath} {
+							if name != "" {
+								copies = append(copies, name)
+							}
+						}
+					}
+					if zout != nil && len(copies) > 0 {
+						ox := outpool.Get().(*output)
+						ox.copies = copies
+						cleanup <- ox
+					}
+				} else {
+					addManifest(stats{Filename: fn, Err: errors.New(skip)})
+				}
+			}
+			mu.Unlock()
+			if skip != "" {
+				continue
+			}
+		}
+
+		results, err := processFile(fn, *maxRows)
+		var cf *completedFile
+		if fstate != nil && err == nil {
+			// record completion after the outputs of the file are flushed
+			// and closed (by the cleanup goroutine, in order), so a crash
+			// loses at most the files in progress. Failed files are retried.
+			cf = newCompletedFile(contentHash, results)
+			line, _ := json.Marshal(cf)
+			ox := outpool.Get().(*output)
+			ox.state = string(line)
+			cleanup <- ox
+		}
 		mu.Lock()
+		if cf != nil {
+			completed[contentHash] = cf
+		}
 		if err != nil {
 			// returned errors are fatal
 			fmt.Fprintf(fstats, "%s\t%s\t-\t-\t-\t%s\n", nowFmt, fn, err.Error())
+			addManifest(stats{Filename: fn, Err: err})
 			mu.Unlock()
 			continue
 		}
@@ -152,6 +543,7 @@ func runProcessor(from chan string, mu *sync.Mutex) {
 			}
 			fmt.Fprintf(fstats, "%s\t%s\t%s\t%d\t%d\t%s\n", nowFmt, res.Filename, res.SheetName,
 				res.NumRows, res.NumCols, e)
+			addManifest(res)
 		}
 		mu.Unlock()
 	}
@@ -163,16 +555,34 @@ var (
 	newlines = regexp.MustCompile("[ \n\r\t]+")
 )
 
+// cleanValue applies the -r and -w flags to a cell value.
+func cleanValue(row, col int, x string) string {
+	if *removeNewlines {
+		x = newlines.ReplaceAllString(x, " ")
+	}
+	if *trimSpaces {
+		x = strings.TrimSpace(x)
+	}
+	return x
+}
+
+// errTruncated is recorded for sheets with more rows than the -max-rows limit.
+var errTruncated = errors.New("truncated")
+
 type stats struct {
-	Filename  string
-	Hash      string
-	SheetName string
-	NumRows   int
-	NumCols   int
-	Err       error
+	Filename   string
+	Hash       string
+	SheetName  string
+	NumRows    int
+	NumCols    int
+	OutputPath string
+	MetaPath   string
+	Err        error
 }
 
-func processFile(fn string) ([]stats, error) {
+// processFile extracts every sheet of fn into tsv files. If limit > 0,
+// at most limit rows are written per sheet.
+func processFile(fn string, limit int) ([]stats, error) {
 	//log.Printf("Opening file '%s' ...", fn)
 	wb, err := grate.Open(fn)
 	if err != nil {
@@ -186,7 +596,9 @@ func processFile(fn string) ([]stats, error) {
 	fn2 := filepath.Base(strings.TrimSuffix(fn, ext))
 	subparts := fmt.Sprintf("%x", md5.Sum([]byte(fn2)))
 	subdir := filepath.Join("results", subparts[:2], subparts[2:4])
-	os.MkdirAll(subdir, 0755)
+	if zout == nil {
+		os.MkdirAll(subdir, 0755)
+	}
 	log.Printf(subparts[:8]+"  Processing file '%s'", fn2)
 
 	sheets, err := wb.List()
@@ -217,8 +629,14 @@ func processFile(fn string) ([]stats, error) {
 		}
 		var ox *output
 		var w io.Writer = ioutil.Discard
-		if !*pretend {
-			f, err := os.Create(subdir + "/" + fn2 + "." + s2 + ".tsv")
+		if !*pretend && zout != nil {
+			ox = outpool.Get().(*output)
+			ox.name = filepath.ToSlash(subdir) + "/" + fn2 + "." + s2 + ".tsv"
+			w = &ox.buf
+			ps.OutputPath = ox.name
+		} else if !*pretend {
+			ps.OutputPath = subdir + "/" + fn2 + "." + s2 + ".tsv"
+			f, err := os.Create(ps.OutputPath)
 			if err != nil {
 				return nil, err
 			}
@@ -228,25 +646,31 @@ func processFile(fn string) ([]stats, error) {
 			w = ox.b
 		}
 
+		var meta *sheetMeta
+		if *writeMeta && !*pretend {
+			meta = &sheetMeta{Filename: fn, Sheet: s}
+		}
+
+		sheet = grate.Map(sheet, cleanValue)
 		for sheet.Next() {
 			row := sheet.Strings()
+			if meta != nil {
+				meta.add(sheet.Row(), row, sheet.Types())
+			}
 			nonblank := false
 			for i, x := range row {
-				if *removeNewlines {
-					x = newlines.ReplaceAllString(x, " ")
-				}
-				if *trimSpaces {
-					x = strings.TrimSpace(x)
-					row[i] = x
-				}
 				if x != "" {
 					nonblank = true
-					if ps.NumCols < i {
-						ps.NumCols = i
+					if ps.NumCols < i+1 {
+						ps.NumCols = i + 1
 					}
 				}
 			}
 			if nonblank || !*skipBlanks {
+				if limit > 0 && ps.NumRows >= limit {
+					ps.Err = errTruncated
+					break
+				}
 				for i, v := range row {
 					if i != 0 {
 						w.Write([]byte{'\t'})
@@ -257,10 +681,106 @@ func processFile(fn string) ([]stats, error) {
 				ps.NumRows++
 			}
 		}
-		results = append(results, ps)
 		if ox != nil {
 			cleanup <- ox
 		}
+		if meta != nil {
+			// the same counts as the stats file
+			meta.Rows, meta.Cols = ps.NumRows, ps.NumCols
+			ps.MetaPath = subdir + "/" + fn2 + "." + s2 + ".meta.json"
+			if zout != nil {
+				ps.MetaPath = filepath.ToSlash(ps.MetaPath)
+			}
+			if err = saveMeta(meta, ps.MetaPath); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, ps)
 	}
 	return results, nil
 }
+
+// sheetMeta is the content of the .meta.json files written with -meta.
+type sheetMeta struct {
+	Filename string   `json:"filename"`
+	Sheet    string   `json:"sheet"`
+	Rows     int      `json:"rows"`
+	Cols     int      `json:"cols"`
+	Headers  []string `json:"headers"`
+	Types    []string `json:"types"`
+}
+
+// add records the headers from the first row of the sheet, and the types
+// from the second (or the first, if there is only one).
+func (m *sheetMeta) add(rowIndex int, row, types []string) {
+	switch rowIndex {
+	case 0:
+		m.Headers = row
+		m.Types = types
+	case 1:
+		m.Types = types
+	}
+}
+
+// saveMeta writes the sheet metadata to the named file, or into the output
+// archive with -zip.
+func saveMeta(m *sheetMeta, name string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if zout != nil {
+		ox := outpool.Get().(*output)
+		ox.name = filepath.ToSlash(name)
+		ox.buf.Write(data)
+		cleanup <- ox
+		return nil
+	}
+	return os.WriteFile(name, data, 0644)
+}
+
+// manifestEntry is an element of the -manifest file. Files which could not
+// be processed (or were skipped) have an entry without a sheet.
+type manifestEntry struct {
+	File       string `json:"file"`
+	Sheet      string `json:"sheet"`
+	Rows       int    `json:"rows"`
+	Cols       int    `json:"cols"`
+	Hash       string `json:"hash"`
+	OutputPath string `json:"output_path"`
+	Error      string `json:"error"`
+}
+
+// addManifest records the stats of a sheet for the -manifest file. The
+// caller must hold the stats mutex.
+func addManifest(st stats) {
+	if *manifestFile == "" {
+		return
+	}
+	e := manifestEntry{
+		File:       st.Filename,
+		Sheet:      st.SheetName,
+		Rows:       st.NumRows,
+		Cols:       st.NumCols,
+		Hash:       st.Hash,
+		OutputPath: st.OutputPath,
+	}
+	if st.Err != nil {
+		e.Error = st.Err.Error()
+	}
+	manifest = append(manifest, e)
+}
+
+// saveManifest writes the manifest entries, ordered by input file, to the
+// named file.
+func saveManifest(name string) error {
+	sort.SliceStable(manifest, func(i, j int) bool {
+		return manifest[i].File < manifest[j].File
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0644)
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/wubin1989/grate"
@@ -15,6 +16,10 @@ import (
 
 func main() {
 	flagDebug := flag.Bool("v", false, "debug log")
+	flagColumns := flag.String("columns", "", "output only the `columns` given, in order, as zero-based indexes (0,2,5) or header names (Name,Age)")
+	flagHeader := flag.Bool("header", false, "the first row of each sheet is a header row")
+	flagOffset := flag.Int("offset", 0, "skip the first `N` rows of each sheet (not counting the -header row)")
+	flagLimit := flag.Int("limit", -1, "output at most `M` rows of each sheet (not counting the -header row), -1 for all")
 	flag.Parse()
 	if flag.NArg() < 1 {
 		fmt.Fprintf(os.Stderr, "USAGE: %s [file1.xls file2.xlsx file3.tsv ...]\n", os.Args[0])
@@ -22,6 +27,29 @@ func main() {
 		os.Exit(1)
 	}
 	grate.Debug = *flagDebug
+	if *flagOffset < 0 {
+		fmt.Fprintln(os.Stderr, "-offset must not be negative")
+		os.Exit(1)
+	}
+
+	var colNames []string
+	var colIndexes []int
+	if *flagColumns != "" {
+		colNames = strings.Split(*flagColumns, ",")
+		colIndexes = make([]int, len(colNames))
+		for i, c := range colNames {
+			n, err := strconv.Atoi(strings.TrimSpace(c))
+			if err != nil || n < 0 {
+				colIndexes = nil
+				break
+			}
+			colIndexes[i] = n
+		}
+		if colIndexes == nil && !*flagHeader {
+			fmt.Fprintln(os.Stderr, "selecting -columns by name requires -header")
+			os.Exit(1)
+		}
+	}
 	for _, fn := range flag.Args() {
 		wb, err := grate.Open(fn)
 		if err != nil {
@@ -43,15 +71,81 @@ func main() {
 				continue
 			}
 
+			sel := colIndexes
+			warned := false
+			header := *flagHeader
+			n := 0
 			for sheet.Next() {
+				if header {
+					header = false
+				} else {
+					n++
+					if n <= *flagOffset {
+						continue
+					}
+					if *flagLimit >= 0 && n > *flagOffset+*flagLimit {
+						break
+					}
+				}
+				row := sheet.Strings()
+				if colNames != nil && sel == nil {
+					// first row is the header, find the named columns
+					sel = headerIndexes(s, row, colNames)
+				}
 				if *flagDebug {
 					dtypes := sheet.Types()
+					if sel != nil {
+						dtypes, _ = selectColumns(dtypes, sel)
+					}
 					fmt.Println(strings.Join(dtypes, "\t"))
 				}
-				row := sheet.Strings()
+				if sel != nil {
+					var ok bool
+					row, ok = selectColumns(row, sel)
+					if !ok && !warned {
+						fmt.Fprintf(os.Stderr, "sheet '%s': some selected columns are out of range\n", s)
+						warned = true
+					}
+				}
 				fmt.Println(strings.Join(row, "\t"))
 			}
 		}
 		wb.Close()
 	}
 }
+
+// headerIndexes returns the indexes of the named columns in the header row.
+// Names which are not found are reported on stderr, and skipped.
+func headerIndexes(sheetName string, header, names []string) []int {
+	res := make([]int, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for i, h := range header {
+			if strings.TrimSpace(h) == name {
+				res = append(res, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "sheet '%s': column '%s' not found in header\n", sheetName, name)
+		}
+	}
+	return res
+}
+
+// selectColumns returns the values at the given indexes, in order. Indexes
+// out of range are returned as empty values, and reported by returning false.
+func selectColumns(row []string, indexes []int) ([]string, bool) {
+	ok := true
+	res := make([]string, len(indexes))
+	for i, c := range indexes {
+		if c >= len(row) {
+			ok = false
+			continue
+		}
+		res[i] = row[c]
+	}
+	return res, ok
+}
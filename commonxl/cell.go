@@ -23,6 +23,8 @@ const (
 
 	HyperlinkStringCell // internal type to separate URLs
 	StaticCell          // placeholder, internal use only
+
+	ErrorCell // error values such as "#REF!"
 )
 
 // String returns a string description of the cell data type.
@@ -42,6 +44,8 @@ func (c CellType) String() string {
 		return "hyperlink"
 	case StaticCell:
 		return "static"
+	case ErrorCell:
+		return "error"
 	default: // StringCell, StaticCell
 		return "string"
 	}
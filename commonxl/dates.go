@@ -33,7 +33,7 @@ func (x *Formatter) ConvertToDate(val float64) time.Time {
 		year := 100*(N-49) + I + L
 
 		t := time.Duration(float64(time.Hour*24) * frac)
-		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Add(t)
+		return x.inLocation(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Add(t))
 	}
 	frac := val - float64(v)
 	date := time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -42,7 +42,15 @@ func (x *Formatter) ConvertToDate(val float64) time.Time {
 	}
 
 	t := time.Duration(float64(time.Hour*24) * frac)
-	return date.AddDate(0, 0, v).Add(t)
+	return x.inLocation(date.AddDate(0, 0, v).Add(t))
+}
+
+// inLocation re-interprets the (UTC) wall clock time in the formatter's location.
+func (x *Formatter) inLocation(t time.Time) time.Time {
+	if x.loc == nil || x.loc == time.UTC {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), x.loc)
 }
 
 func timeFmtFunc(f string) FmtFunc {
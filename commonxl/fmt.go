@@ -39,13 +39,17 @@ func addCommas(ff FmtFunc) FmtFunc {
 			isNeg = true
 			s1 = s1[1:]
 		}
-		endIndex := strings.IndexAny(s1, ".eE")
+		loc := x.getLocale()
+		endIndex := strings.IndexAny(s1, "eE")
 		if endIndex < 0 {
 			endIndex = len(s1)
 		}
+		if i := strings.Index(s1, loc.DecimalSeparator); i >= 0 && i < endIndex {
+			endIndex = i
+		}
 		for endIndex > 3 {
 			endIndex -= 3
-			s1 = s1[:endIndex] + "," + s1[endIndex:]
+			s1 = s1[:endIndex] + loc.ThousandsSeparator + s1[endIndex:]
 		}
 		if isNeg {
 			return "-" + s1
@@ -54,7 +58,7 @@ func addCommas(ff FmtFunc) FmtFunc {
 	}
 }
 
-func identFunc(x *Formatter, v interface{}) string {
+func identFunc(fx *Formatter, v interface{}) string {
 	switch x := v.(type) {
 	case bool:
 		if x {
@@ -67,7 +71,7 @@ func identFunc(x *Formatter, v interface{}) string {
 			return s
 		}
 	case float64:
-		return strconv.FormatFloat(x, 'g', -1, 64)
+		return fx.localizeDecimal(strconv.FormatFloat(x, 'g', -1, 64))
 	case string:
 		return x
 	case fmt.Stringer:
@@ -89,7 +93,7 @@ func sprintfFunc(fs string, mul int) FmtFunc {
 				v2 := int64(val)
 				return fmt.Sprintf(fs, v2)
 			}
-			return fmt.Sprintf(fs, val)
+			return x.localizeDecimal(fmt.Sprintf(fs, val))
 		}
 		return fmt.Sprint(v)
 	}
@@ -286,11 +290,11 @@ var goFormatters = map[uint16]FmtFunc{
 	43: zeroDashFunc(addCommas(sprintfFunc("%4.2f", 1))),
 
 	42: switchFmtFunc(
-		surround("$", addCommas(sprintfFunc("%d", 1)), ""),
-		surround("$(", addCommas(sprintfFunc("%d", 1)), ")"),
-		staticFmtFunc("$-")),
+		currencyFunc(addCommas(sprintfFunc("%d", 1)), false),
+		currencyFunc(addCommas(sprintfFunc("%d", 1)), true),
+		currencyFunc(staticFmtFunc("-"), false)),
 	44: switchFmtFunc(
-		surround("$", addCommas(sprintfFunc("%4.2f", 1)), ""),
-		surround("$(", addCommas(sprintfFunc("%4.2f", 1)), ")"),
-		staticFmtFunc("$-")),
+		currencyFunc(addCommas(sprintfFunc("%4.2f", 1)), false),
+		currencyFunc(addCommas(sprintfFunc("%4.2f", 1)), true),
+		currencyFunc(staticFmtFunc("-"), false)),
 }
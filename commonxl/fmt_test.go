@@ -154,3 +154,33 @@ func TestTimeFormats(t *testing.T) {
 		t.Fatal("Time should be 09:37, but was", val)
 	}
 }
+
+func TestWithLocale(t *testing.T) {
+	var base Formatter
+	de := base.WithLocale(LocaleDE)
+	fr := base.WithLocale(LocaleFR)
+
+	cases := []struct {
+		x     *Formatter
+		fmtID uint16
+		v     interface{}
+		s     string
+	}{
+		{&base, 4, 1234567.891, "1,234,567.89"},
+		{&de, 4, 1234567.891, "1.234.567,89"},
+		{&fr, 4, 1234567.891, "1\u202f234\u202f567,89"},
+		{&de, 0, 3.25, "3,25"},
+		{&de, 10, 0.125, "12,50%"},
+		{&base, 44, 1234.5, "$1,234.50"},
+		{&de, 44, 1234.5, "1.234,50 €"},
+		{&de, 44, -1234.5, "(-1.234,50) €"},
+		{&de, 42, 0.0, "- €"},
+		{&de, 3, int64(1234), "1.234"},
+	}
+	for _, c := range cases {
+		s, ok := c.x.Apply(c.fmtID, c.v)
+		if !ok || s != c.s {
+			t.Errorf("format %d of %v: got %q, expected %q", c.fmtID, c.v, s, c.s)
+		}
+	}
+}
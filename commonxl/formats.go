@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Formatter contains formatting methods common to Excel spreadsheets.
@@ -12,6 +13,9 @@ type Formatter struct {
 	flags           uint64
 	customCodes     map[uint16]FmtFunc
 	customCodeTypes map[uint16]CellType
+	codes           map[uint16]string // format codes as declared by the file
+	loc             *time.Location
+	locale          *Locale
 }
 
 const (
@@ -28,11 +32,21 @@ func (x *Formatter) Mode1904(enabled bool) {
 	}
 }
 
+// SetLocation sets the timezone used to interpret date values,
+// which are stored without timezone information. Defaults to UTC.
+func (x *Formatter) SetLocation(loc *time.Location) {
+	x.loc = loc
+}
+
 // Add a custom number format to the formatter.
 func (x *Formatter) Add(fmtID uint16, formatCode string) error {
 	if x.customCodes == nil {
 		x.customCodes = make(map[uint16]FmtFunc)
 		x.customCodeTypes = make(map[uint16]CellType)
+		x.codes = make(map[uint16]string)
+	}
+	if _, ok := x.codes[fmtID]; !ok {
+		x.codes[fmtID] = formatCode
 	}
 	if strings.ToLower(formatCode) == "general" {
 		x.customCodes[fmtID] = goFormatters[0]
@@ -52,6 +66,18 @@ func (x *Formatter) Add(fmtID uint16, formatCode string) error {
 	return nil
 }
 
+// FormatCode returns the format code of the number format (e.g.
+// "#,##0.00" or "dd/mm/yyyy"), as added to the formatter or else as
+// built in, and true if the format is known. Codes added for built-in
+// formats (as XLS files do for the locale-dependent ones) take precedence.
+func (x *Formatter) FormatCode(fmtID uint16) (string, bool) {
+	if code, ok := x.codes[fmtID]; ok {
+		return code, true
+	}
+	code, ok := builtInFormats[fmtID]
+	return code, ok
+}
+
 func (x *Formatter) getCellType(fmtID uint16) (CellType, bool) {
 	if ct, ok := builtInFormatTypes[fmtID]; ok {
 		return ct, true
@@ -219,6 +245,7 @@ func (x *Formatter) Apply(fmtID uint16, val interface{}) (string, bool) {
 		if ok2 {
 			return fs(x, val), true
 		}
+		ff = identFunc
 	}
 	return ff(x, val), ok
 }
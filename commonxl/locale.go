@@ -0,0 +1,65 @@
+package commonxl
+
+import (
+	"strings"
+
+	"github.com/wubin1989/grate"
+)
+
+// Locale describes the conventions used to format numbers and currency
+// amounts as strings. It is the same type as grate.Locale, so that the
+// pre-built locales can be passed to grate.WithLocale.
+type Locale = grate.Locale
+
+// Pre-built locales for common European conventions. French uses a narrow
+// no-break space and Portuguese a no-break space to group digits.
+var (
+	LocaleDE = &Locale{DecimalSeparator: ",", ThousandsSeparator: ".", CurrencySymbol: "€", CurrencyAfter: true}
+	LocaleFR = &Locale{DecimalSeparator: ",", ThousandsSeparator: "\u202f", CurrencySymbol: "€", CurrencyAfter: true}
+	LocaleIT = &Locale{DecimalSeparator: ",", ThousandsSeparator: ".", CurrencySymbol: "€", CurrencyAfter: true}
+	LocaleES = &Locale{DecimalSeparator: ",", ThousandsSeparator: ".", CurrencySymbol: "€", CurrencyAfter: true}
+	LocalePT = &Locale{DecimalSeparator: ",", ThousandsSeparator: "\u00a0", CurrencySymbol: "€", CurrencyAfter: true}
+)
+
+// default English conventions
+var localeEN = &Locale{DecimalSeparator: ".", ThousandsSeparator: ",", CurrencySymbol: "$"}
+
+// WithLocale returns a copy of the formatter which formats numbers using
+// the conventions of loc. Only the string representation of values is
+// affected. A nil loc restores the default English conventions.
+func (x *Formatter) WithLocale(loc *Locale) Formatter {
+	res := *x
+	res.locale = loc
+	return res
+}
+
+func (x *Formatter) getLocale() *Locale {
+	if x == nil || x.locale == nil {
+		return localeEN
+	}
+	return x.locale
+}
+
+// localizeDecimal replaces the decimal point of a formatted number.
+func (x *Formatter) localizeDecimal(s string) string {
+	if sep := x.getLocale().DecimalSeparator; sep != "." && sep != "" {
+		return strings.Replace(s, ".", sep, 1)
+	}
+	return s
+}
+
+// currencyFunc adds the locale's currency symbol to a formatted amount,
+// optionally wrapping the amount in parentheses.
+func currencyFunc(ff FmtFunc, parens bool) FmtFunc {
+	return func(x *Formatter, v interface{}) string {
+		loc := x.getLocale()
+		s := ff(x, v)
+		if parens {
+			s = "(" + s + ")"
+		}
+		if loc.CurrencyAfter {
+			return s + " " + loc.CurrencySymbol
+		}
+		return loc.CurrencySymbol + s
+	}
+}
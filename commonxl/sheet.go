@@ -2,7 +2,7 @@ package commonxl
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/wubin1989/grate"
@@ -11,33 +11,37 @@ import (
 // Sheet holds raw and rendered values for a spreadsheet.
 type Sheet struct {
 	Formatter *Formatter
+	Logger    *slog.Logger
 	NumRows   int
 	NumCols   int
 	Rows      [][]Cell
 
 	CurRow int
+
+	// rows omitted from iteration
+	skipped map[int]bool
 }
 
 // Resize the sheet for the number of rows and cols given.
 // Newly added cells default to blank.
 func (s *Sheet) Resize(rows, cols int) {
-	for i := range s.Rows {
-		if i > rows {
-			break
-		}
-		n := cols - len(s.Rows[i])
-		if n <= 0 {
-			continue
-		}
-		s.Rows[i] = append(s.Rows[i], make([]Cell, n)...)
-	}
-
 	if rows <= 0 {
 		rows = 1
 	}
 	if cols <= 0 {
 		cols = 1
 	}
+
+	// every row must have exactly cols cells, including rows which are
+	// kept beyond the new number of rows
+	for i := range s.Rows {
+		n := cols - len(s.Rows[i])
+		if n < 0 {
+			s.Rows[i] = s.Rows[i][:cols]
+		} else if n > 0 {
+			s.Rows[i] = append(s.Rows[i], make([]Cell, n)...)
+		}
+	}
 	s.CurRow = 0
 	s.NumRows = rows
 	s.NumCols = cols
@@ -47,14 +51,21 @@ func (s *Sheet) Resize(rows, cols int) {
 	}
 }
 
+// logger returns the Logger of the sheet, or the process-wide grate.Logger
+// if there is none.
+func (s *Sheet) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return grate.Logger()
+}
+
 // Put the value at the cell location given.
 func (s *Sheet) Put(row, col int, value interface{}, fmtNum uint16) {
 	//log.Println(row, col, value, fmtNum)
 	if row >= s.NumRows || col >= s.NumCols {
-		if grate.Debug {
-			log.Printf("grate: cell out of bounds row %d>=%d, col %d>=%d",
-				row, s.NumRows, col, s.NumCols)
-		}
+		s.logger().Debug("grate: cell out of bounds",
+			"row", row, "rows", s.NumRows, "col", col, "cols", s.NumCols)
 
 		// per the spec, this is an invalid Excel file
 		// but we'll resize in place instead of crashing out
@@ -84,22 +95,33 @@ func (s *Sheet) Put(row, col int, value interface{}, fmtNum uint16) {
 	s.Rows[row][col].SetFormatNumber(fmtNum)
 }
 
+// PutError puts an error value (such as "#REF!") at the cell location given.
+func (s *Sheet) PutError(row, col int, text string, fmtNum uint16) {
+	s.Put(row, col, text, 0)
+	s.Rows[row][col][1] = ErrorCell
+	s.Rows[row][col].SetFormatNumber(fmtNum)
+}
+
 // Set changes the value in an existing cell location.
 // NB Currently only used for populating string results for formulas.
 func (s *Sheet) Set(row, col int, value interface{}) {
-	if row > s.NumRows || col > s.NumCols {
-		log.Println("grate: cell out of bounds")
+	if row >= s.NumRows || col >= s.NumCols {
+		s.logger().Debug("grate: cell out of bounds", "row", row, "col", col)
 		return
 	}
 
+	if s.Rows[row][col] == nil {
+		// the cell was blank
+		s.Rows[row][col] = NewCell(value)
+	}
 	s.Rows[row][col][0] = value
 	s.Rows[row][col][1] = StringCell
 }
 
 // SetURL adds a hyperlink to an existing cell location.
 func (s *Sheet) SetURL(row, col int, link string) {
-	if row > s.NumRows || col > s.NumCols {
-		log.Println("grate: cell out of bounds")
+	if row >= s.NumRows || col >= s.NumCols {
+		s.logger().Debug("grate: cell out of bounds", "row", row, "col", col)
 		return
 	}
 
@@ -109,11 +131,33 @@ func (s *Sheet) SetURL(row, col int, link string) {
 // Next advances to the next record of content.
 // It MUST be called prior to any Scan().
 func (s *Sheet) Next() bool {
-	if (s.CurRow + 1) > len(s.Rows) {
-		return false
+	for {
+		if (s.CurRow + 1) > len(s.Rows) {
+			return false
+		}
+		s.CurRow++
+		if !s.skipped[s.CurRow-1] {
+			return true
+		}
 	}
-	s.CurRow++
-	return true
+}
+
+// SkipRow omits the row from iteration, e.g. when it could not be parsed.
+func (s *Sheet) SkipRow(row int) {
+	if s.skipped == nil {
+		s.skipped = make(map[int]bool)
+	}
+	s.skipped[row] = true
+}
+
+// RowSkipped reports whether the row was omitted with SkipRow.
+func (s *Sheet) RowSkipped(row int) bool {
+	return s.skipped[row]
+}
+
+// Row returns the zero-based index of the current record.
+func (s *Sheet) Row() int {
+	return s.CurRow - 1
 }
 
 // Raw extracts the raw Cell interfaces underlying the current row.
@@ -133,7 +177,7 @@ func (s *Sheet) Strings() []string {
 			res[i] = ""
 			continue
 		}
-		if cell.Type() == StaticCell {
+		if cell.Type() == StaticCell || cell.Type() == ErrorCell {
 			res[i] = cell.Value().(string)
 			continue
 		}
@@ -149,7 +193,7 @@ func (s *Sheet) Strings() []string {
 
 // Types extracts the data types from the current record into a list.
 // options: "boolean", "integer", "float", "string", "date",
-// and special cases: "blank", "hyperlink" which are string types
+// and special cases: "blank", "hyperlink", "error" which are string types
 func (s *Sheet) Types() []string {
 	res := make([]string, s.NumCols)
 	for i, cell := range s.Rows[s.CurRow-1] {
@@ -158,12 +202,43 @@ func (s *Sheet) Types() []string {
 	return res
 }
 
+// Values extracts the native values of the current record into a list.
+// Numbers, booleans and dates keep their stored values, while all other
+// cells use the same text as Strings.
+func (s *Sheet) Values() []interface{} {
+	res := make([]interface{}, s.NumCols)
+	var strs []string
+	for i, cell := range s.Rows[s.CurRow-1] {
+		switch cell.Type() {
+		case BlankCell:
+			res[i] = ""
+			continue
+		case IntegerCell, FloatCell, BooleanCell, DateCell:
+			switch v := cell.Value().(type) {
+			case int64, float64, bool, time.Time:
+				res[i] = v
+				continue
+			}
+		}
+		if strs == nil {
+			strs = s.Strings()
+		}
+		res[i] = strs[i]
+	}
+	return res
+}
+
 // Formats extracts the format code for the current record into a list.
+// Unknown formats are returned as their number.
 func (s *Sheet) Formats() []string {
+	x := s.Formatter
+	if x == nil {
+		x = &Formatter{}
+	}
 	ok := true
 	res := make([]string, s.NumCols)
 	for i, cell := range s.Rows[s.CurRow-1] {
-		res[i], ok = builtInFormats[cell.FormatNo()]
+		res[i], ok = x.FormatCode(cell.FormatNo())
 		if !ok {
 			res[i] = fmt.Sprint(cell.FormatNo())
 		}
@@ -221,9 +296,22 @@ func (s *Sheet) Scan(args ...interface{}) error {
 	return nil
 }
 
-// IsEmpty returns true if there are no data values.
+// IsEmpty returns true if there are no data values, i.e. the sheet has at
+// most one cell, which is blank.
 func (s *Sheet) IsEmpty() bool {
-	return (s.NumCols <= 1 && s.NumRows <= 1)
+	if s.NumCols > 1 || s.NumRows > 1 {
+		return false
+	}
+	if len(s.Rows) == 0 || len(s.Rows[0]) == 0 {
+		return true
+	}
+	c := s.Rows[0][0]
+	return c.Type() == BlankCell || c.Value() == ""
+}
+
+// Width returns the number of columns of the sheet.
+func (s *Sheet) Width() int {
+	return s.NumCols
 }
 
 // Err returns the last error that occured.
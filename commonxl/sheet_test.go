@@ -0,0 +1,73 @@
+package commonxl
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSheetIsEmpty(t *testing.T) {
+	s := &Sheet{Formatter: &Formatter{}}
+	s.Resize(1, 1)
+	if !s.IsEmpty() {
+		t.Error("expected a sheet with a blank cell to be empty")
+	}
+	s.Put(0, 0, "x", 0)
+	if s.IsEmpty() {
+		t.Error("expected a sheet with one value to not be empty")
+	}
+	s.Resize(2, 1)
+	s.Put(0, 0, "", 0)
+	if s.IsEmpty() {
+		t.Error("expected a sheet with two rows to not be empty")
+	}
+}
+
+func TestSheetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := &Sheet{Formatter: &Formatter{}, Logger: l}
+	s.Resize(1, 1)
+	s.SetURL(3, 0, "https://example.com")
+	if !strings.Contains(buf.String(), "cell out of bounds") {
+		t.Errorf("expected the sheet logger to be used, got %q", buf.String())
+	}
+}
+
+func TestSheetResize(t *testing.T) {
+	s := &Sheet{Formatter: &Formatter{}}
+	s.Resize(2, 3)
+	s.Put(1, 2, "x", 0)
+	s.Resize(2, 2)
+	for i, row := range s.Rows {
+		if len(row) != 2 {
+			t.Errorf("row %d: expected 2 cells after shrinking, got %d", i, len(row))
+		}
+	}
+	s.Resize(3, 4)
+	for i, row := range s.Rows {
+		if len(row) != 4 {
+			t.Errorf("row %d: expected 4 cells after growing, got %d", i, len(row))
+		}
+	}
+}
+
+func TestSheetSet(t *testing.T) {
+	s := &Sheet{Formatter: &Formatter{}}
+	s.Resize(2, 2)
+	// a formula string result may be set on a blank cell
+	s.Set(1, 1, "result")
+	if !s.Next() || !s.Next() {
+		t.Fatal("expected two rows")
+	}
+	if got := s.Strings(); got[1] != "result" {
+		t.Errorf("got %q, expected the set value", got)
+	}
+	// out of bounds cells are ignored
+	s.Set(2, 0, "x")
+	s.Set(0, 2, "x")
+	if s.NumRows != 2 || s.NumCols != 2 {
+		t.Errorf("expected the sheet to keep its size, got %dx%d", s.NumRows, s.NumCols)
+	}
+}
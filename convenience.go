@@ -0,0 +1,43 @@
+package grate
+
+import "fmt"
+
+// ConvenienceSource wraps a Source with helper methods for simple consumers.
+// It embeds the Source, so it can be used wherever a Source is expected.
+type ConvenienceSource struct {
+	Source
+}
+
+// Each calls fn for every collection in the source, in the order returned
+// by List. Iteration stops at the first non-nil error, which is returned.
+func (s ConvenienceSource) Each(fn func(name string, c Collection) error) error {
+	names, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		c, err := s.Get(name)
+		if err != nil {
+			return err
+		}
+		if err = fn(name, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetNonEmpty returns the named collection as with Get, or an error wrapping
+// ErrEmptySource if the collection has no data values (see
+// Collection.IsEmpty). This lets callers tell empty collections apart from
+// parse errors without iterating over them.
+func (s ConvenienceSource) GetNonEmpty(name string) (Collection, error) {
+	c, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsEmpty() {
+		return nil, WrapErr(fmt.Errorf("grate: collection '%s' is empty", name), ErrEmptySource)
+	}
+	return c, nil
+}
@@ -0,0 +1,52 @@
+package grate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConvenienceSourceEach(t *testing.T) {
+	src := ConvenienceSource{&testSource{
+		names: []string{"one", "two", "three"},
+		colls: map[string]*testCollection{
+			"one":   newTestCollection([]string{"1"}),
+			"two":   newTestCollection([]string{"2"}),
+			"three": newTestCollection([]string{"3"}),
+		},
+	}}
+
+	var seen []string
+	errStop := errors.New("stop")
+	err := src.Each(func(name string, c Collection) error {
+		seen = append(seen, name)
+		if name == "two" {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("expected errStop, got %v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected iteration to stop after 2 sheets, got %v", seen)
+	}
+}
+
+func TestConvenienceSourceGetNonEmpty(t *testing.T) {
+	src := ConvenienceSource{&testSource{
+		names: []string{"data", "empty"},
+		colls: map[string]*testCollection{
+			"data":  newTestCollection([]string{"1"}),
+			"empty": newTestCollection(),
+		},
+	}}
+	if c, err := src.GetNonEmpty("data"); err != nil || c == nil {
+		t.Errorf("unexpected result %v, %v", c, err)
+	}
+	if _, err := src.GetNonEmpty("empty"); !errors.Is(err, ErrEmptySource) {
+		t.Errorf("expected ErrEmptySource, got %v", err)
+	}
+	if _, err := src.GetNonEmpty("missing"); !errors.Is(err, ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package grate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// DetectFunc reports whether header, the first bytes of a file, looks like
+// the format it was registered for. It must not retain header.
+type DetectFunc func(header []byte) bool
+
+var detectTable = make(map[string]DetectFunc)
+
+// RegisterDetect associates a detector with the named format, allowing the
+// format to be identified without opening (and allocating parser state for)
+// the whole file.
+func RegisterDetect(name string, fn func([]byte) bool) error {
+	Logger().Debug("registering format detector", "format", name)
+	tableMu.Lock()
+	defer tableMu.Unlock()
+	if _, ok := detectTable[name]; ok {
+		return errors.New("grate: detector already registered for " + name)
+	}
+	detectTable[name] = fn
+	return nil
+}
+
+// DetectFormat returns the name of the first registered format, in priority
+// order, that recognises header. Formats without a detector are tried by
+// opening header with their OpenReaderFunc (if any), so header should
+// contain as much of the file as is practical.
+func DetectFormat(header []byte) (string, error) {
+	for _, o := range sources() {
+		tableMu.RLock()
+		detect, ok := detectTable[o.name]
+		tableMu.RUnlock()
+		if ok {
+			if detect(header) {
+				return o.name, nil
+			}
+			continue
+		}
+		if detectByOpening(o.name, header) {
+			return o.name, nil
+		}
+		Logger().Debug("header is not in format", "format", o.name)
+	}
+	return "", ErrUnknownFormat
+}
+
+// detectByOpening tries the named format's reader opener on header.
+func detectByOpening(name string, header []byte) bool {
+	for _, ro := range readers() {
+		if ro.name != name {
+			continue
+		}
+		src, err := ro.op(io.NopCloser(bytes.NewReader(header)))
+		if err != nil {
+			return false
+		}
+		src.Close()
+		return true
+	}
+	return false
+}
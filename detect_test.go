@@ -0,0 +1,46 @@
+package grate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	src, rdr, det := srcTable, readerTable, detectTable
+	t.Cleanup(func() { srcTable, readerTable, detectTable = src, rdr, det })
+	srcTable, readerTable, detectTable = nil, nil, make(map[string]DetectFunc)
+
+	notInFormat := func(string) (Source, error) { return nil, ErrNotInFormat }
+	Register("magic", 1, notInFormat)
+	RegisterDetect("magic", func(header []byte) bool {
+		return bytes.HasPrefix(header, []byte("MAGIC"))
+	})
+	if err := RegisterDetect("magic", nil); err == nil {
+		t.Error("expected an error registering a second detector")
+	}
+
+	// no detector, so the reader opener is used instead
+	Register("fallback", 2, notInFormat)
+	RegisterReader("fallback", 2, func(r io.ReadCloser) (Source, error) {
+		data, _ := io.ReadAll(r)
+		if !bytes.HasPrefix(data, []byte("FALLBACK")) {
+			return nil, ErrNotInFormat
+		}
+		return &testSource{}, nil
+	})
+
+	for header, want := range map[string]string{
+		"MAGIC header":    "magic",
+		"FALLBACK header": "fallback",
+	} {
+		got, err := DetectFormat([]byte(header))
+		if err != nil || got != want {
+			t.Errorf("DetectFormat(%q) = %q, %v; expected %q", header, got, err, want)
+		}
+	}
+	if _, err := DetectFormat([]byte("unknown")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
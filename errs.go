@@ -7,7 +7,9 @@ var (
 	//   -ldflags="-X github.com/wubin1989/grate.loglevel=debug"
 	loglevel string = "warn"
 
-	// Debug should be set to true to expose detailed logging.
+	// Debug should be set to true to expose detailed logging on stderr.
+	//
+	// Deprecated: use SetLogger, which takes precedence when set.
 	Debug bool = (loglevel == "debug")
 )
 
@@ -21,24 +23,63 @@ var ErrNotInFormat = errors.New("grate: file is not in this format")
 // ErrUnknownFormat is used when grate does not know how to open a file format.
 var ErrUnknownFormat = errors.New("grate: file format is not known/supported")
 
-type errx struct {
-	errs []error
+// ErrTooLarge is returned when opening a file would exceed the memory limit
+// configured with the MaxMemoryBytes option.
+var ErrTooLarge = errors.New("grate: file exceeds the configured memory limit")
+
+// ErrEncrypted is returned when a file is encrypted and cannot be
+// decrypted (e.g. because a password is required).
+var ErrEncrypted = errors.New("grate: file is encrypted")
+
+// ErrWrongPassword is returned when a file is encrypted and the given
+// password is incorrect. It also matches ErrEncrypted with errors.Is.
+var ErrWrongPassword error = &WrappedError{Err: errors.New("grate: incorrect password"), Sentinel: ErrEncrypted}
+
+// ErrExternalReference is returned for cells whose values are stored in
+// an external file, which cannot be read.
+var ErrExternalReference = errors.New("grate: cell references external data")
+
+// ErrSheetNotFound is returned by Source.Get when there is no collection
+// with the requested name.
+var ErrSheetNotFound = errors.New("grate: sheet not found")
+
+// ErrEmptySource is returned by ConvenienceSource.GetNonEmpty when the
+// requested collection has no data values.
+var ErrEmptySource = errors.New("grate: collection is empty")
+
+// ErrIterationStarted is returned by UseFirstRowAsHeader when records have
+// already been read from the collection.
+var ErrIterationStarted = errors.New("grate: iteration has already started")
+
+// ErrNoHeader is returned by UseFirstRowAsHeader when the collection has no
+// records.
+var ErrNoHeader = errors.New("grate: collection has no header row")
+
+// WrappedError is returned by WrapErr. It keeps the original error
+// alongside the sentinel describing it, so that both can be matched with
+// errors.Is and the original error can be extracted with errors.As.
+type WrappedError struct {
+	// Err is the original error.
+	Err error
+	// Sentinel is the error category, e.g. ErrNotInFormat.
+	Sentinel error
 }
 
-func (e errx) Error() string {
-	return e.errs[0].Error()
+func (e *WrappedError) Error() string {
+	return e.Err.Error()
 }
-func (e errx) Unwrap() error {
-	if len(e.errs) > 1 {
-		return e.errs[1]
-	}
-	return nil
+
+// Unwrap returns both the original error and the sentinel.
+func (e *WrappedError) Unwrap() []error {
+	return []error{e.Err, e.Sentinel}
 }
 
-// WrapErr wraps a set of errors.
+// WrapErr wraps a set of errors. The first error is the original error, and
+// the rest are wrapped in turn as its sentinel. A single error is returned
+// as-is.
 func WrapErr(e ...error) error {
 	if len(e) == 1 {
 		return e[0]
 	}
-	return errx{errs: e}
+	return &WrappedError{Err: e[0], Sentinel: WrapErr(e[1:]...)}
 }
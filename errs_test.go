@@ -0,0 +1,29 @@
+package grate
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestWrappedError(t *testing.T) {
+	err := WrapErr(io.ErrUnexpectedEOF, ErrNotInFormat)
+	if !errors.Is(err, ErrNotInFormat) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected %v to match the sentinel and the original error", err)
+	}
+	if err.Error() != io.ErrUnexpectedEOF.Error() {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+
+	var we *WrappedError
+	if !errors.As(err, &we) {
+		t.Fatal("expected errors.As to find a *WrappedError")
+	}
+	if we.Err != io.ErrUnexpectedEOF || we.Sentinel != ErrNotInFormat {
+		t.Fatalf("unexpected wrapped errors %v, %v", we.Err, we.Sentinel)
+	}
+
+	if err := WrapErr(io.EOF); err != io.EOF {
+		t.Fatalf("a single error should be returned as-is, got %v", err)
+	}
+}
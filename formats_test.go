@@ -0,0 +1,144 @@
+package grate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestListFormats(t *testing.T) {
+	src, file, rdr := srcTable, fileTable, readerTable
+	t.Cleanup(func() { srcTable, fileTable, readerTable = src, file, rdr })
+	srcTable, fileTable, readerTable = nil, nil, nil
+
+	if got := ListFormats(); len(got) != 0 {
+		t.Fatalf("expected no formats, got %v", got)
+	}
+
+	open := func(string) (Source, error) { return nil, ErrNotInFormat }
+	openFile := func(fs.File) (Source, error) { return nil, ErrNotInFormat }
+	openReader := func(io.ReadCloser) (Source, error) { return nil, ErrNotInFormat }
+	Register("csv", 15, open)
+	Register("xlsx", 5, open)
+	RegisterFile("xlsx", 5, openFile)
+	RegisterReader("xlsx", 5, openReader)
+	RegisterReader("stream", 1, openReader)
+	Register("tsv", 10, open)
+	Register("alt", 10, open)
+
+	expect := []string{"stream", "xlsx", "alt", "tsv", "csv"}
+	if got := ListFormats(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("got %v, expected %v", got, expect)
+	}
+}
+
+func TestOpenPreferring(t *testing.T) {
+	src := srcTable
+	t.Cleanup(func() { srcTable = src })
+	srcTable = nil
+
+	var tried []string
+	opener := func(name string, ok bool) OpenFunc {
+		return func(string) (Source, error) {
+			tried = append(tried, name)
+			if !ok {
+				return nil, ErrNotInFormat
+			}
+			return &testSource{names: []string{name}}, nil
+		}
+	}
+	Register("first", 1, opener("first", false))
+	Register("second", 2, opener("second", true))
+	Register("third", 3, opener("third", true))
+
+	s, err := OpenPreferring("data", "third")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := s.List(); !reflect.DeepEqual(names, []string{"third"}) {
+		t.Errorf("opened %v, expected third", names)
+	}
+
+	tried = nil
+	s, err = OpenPreferring("data", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := s.List(); !reflect.DeepEqual(names, []string{"second"}) {
+		t.Errorf("opened %v, expected fallback to second", names)
+	}
+	if expect := []string{"first", "second"}; !reflect.DeepEqual(tried, expect) {
+		t.Errorf("tried %v, expected %v", tried, expect)
+	}
+
+	if _, err = OpenPreferring("data", "missing"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestConcurrentRegister(t *testing.T) {
+	src, file, rdr := srcTable, fileTable, readerTable
+	t.Cleanup(func() { srcTable, fileTable, readerTable = src, file, rdr })
+
+	open := func(string) (Source, error) { return nil, ErrNotInFormat }
+	openFile := func(fs.File) (Source, error) { return nil, ErrNotInFormat }
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			Register(fmt.Sprint("fmt", i), i, open)
+			RegisterFile(fmt.Sprint("fmt", i), i, openFile)
+		}(i)
+		go func() {
+			defer wg.Done()
+			Open("testdata/missing.csv")
+			ListFormats()
+		}()
+	}
+	wg.Wait()
+	if n := len(srcTable) - len(src); n != 8 {
+		t.Errorf("expected 8 new formats, got %d", n)
+	}
+}
+
+func TestDeregister(t *testing.T) {
+	src, file, rdr := srcTable, fileTable, readerTable
+	t.Cleanup(func() { srcTable, fileTable, readerTable = src, file, rdr })
+	srcTable, fileTable, readerTable = nil, nil, nil
+
+	open := func(name string) OpenFunc {
+		return func(string) (Source, error) { return &testSource{names: []string{name}}, nil }
+	}
+	openFile := func(fs.File) (Source, error) { return nil, ErrNotInFormat }
+	Register("first", 1, open("first"))
+	RegisterFile("first", 1, openFile)
+	Register("second", 2, open("second"))
+
+	if err := Deregister("first"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ListFormats(); !reflect.DeepEqual(got, []string{"second"}) {
+		t.Errorf("got formats %v, expected [second]", got)
+	}
+	s, err := Open("data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := s.List(); !reflect.DeepEqual(names, []string{"second"}) {
+		t.Errorf("opened %v, expected second", names)
+	}
+	if err := Deregister("first"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+
+	// the format can be registered again
+	Register("first", 1, open("first"))
+	if got := ListFormats(); !reflect.DeepEqual(got, []string{"first", "second"}) {
+		t.Errorf("got formats %v, expected [first second]", got)
+	}
+}
@@ -0,0 +1,55 @@
+package grate
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOpenFS(t *testing.T) {
+	tab := fileTable
+	t.Cleanup(func() { fileTable = tab })
+	fileTable = nil
+
+	opener := func(name string) OpenFileFunc {
+		return func(f fs.File) (Source, error) {
+			data, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+			if string(data) != "data" {
+				return nil, ErrNotInFormat
+			}
+			return &testSource{names: []string{name}}, nil
+		}
+	}
+	RegisterFile("abc", 1, opener("abc"))
+	RegisterFile("xyz", 2, opener("xyz"))
+
+	fsys := fstest.MapFS{
+		"dir/file.xyz": {Data: []byte("data")},
+		"dir/file.txt": {Data: []byte("data")},
+		"dir/bad.abc":  {Data: []byte("other")},
+	}
+	for name, want := range map[string]string{
+		"dir/file.xyz": "xyz",
+		"dir/file.txt": "abc",
+	} {
+		src, err := OpenFS(fsys, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if names, _ := src.List(); names[0] != want {
+			t.Errorf("%s: opened as %s, expected %s", name, names[0], want)
+		}
+	}
+
+	if _, err := OpenFS(fsys, "dir/bad.abc"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+	if _, err := OpenFS(fsys, "missing.xyz"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
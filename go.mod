@@ -1,5 +1,7 @@
 module github.com/wubin1989/grate
 
-go 1.16
+go 1.21
 
 require github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de
+
+require golang.org/x/text v0.14.0
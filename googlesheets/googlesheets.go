@@ -0,0 +1,176 @@
+// Package googlesheets reads spreadsheets stored in Google Sheets through
+// the Sheets API v4, as a grate.Source.
+//
+// Authentication is left to the caller, so that this package does not
+// depend on the OAuth2 libraries: pass an *http.Client which authorizes
+// its requests, such as one returned by oauth2.NewClient for a token
+// source with the spreadsheets.readonly scope.
+package googlesheets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+var (
+	// baseURL is the Sheets API endpoint, replaced in tests.
+	baseURL = "https://sheets.googleapis.com"
+
+	// pageRows is the number of rows fetched by each request.
+	pageRows = 1000
+
+	// retries is the number of times a rate limited (or temporarily
+	// failing) request is retried, waiting retryDelay before the first
+	// retry and doubling it for each of the next.
+	retries    = 5
+	retryDelay = time.Second
+)
+
+// Spreadsheet is a Google Sheets spreadsheet opened as a grate.Source.
+type Spreadsheet struct {
+	id     string
+	client *http.Client
+	opts   grate.Options
+	sheets []sheetProperties
+}
+
+// sheetProperties are the properties of a sheet returned by the API.
+type sheetProperties struct {
+	Title          string `json:"title"`
+	Hidden         bool   `json:"hidden"`
+	GridProperties struct {
+		RowCount    int `json:"rowCount"`
+		ColumnCount int `json:"columnCount"`
+	} `json:"gridProperties"`
+}
+
+// Open fetches the list of sheets of the spreadsheet with the given ID,
+// using client to make the (authorized) API requests. Sheet contents are
+// fetched in pages of rows while iterating over them. Rate limited
+// requests are retried with an exponential backoff.
+func Open(spreadsheetID string, client *http.Client) (grate.Source, error) {
+	return OpenWithOptions(spreadsheetID, client)
+}
+
+// OpenWithOptions opens the spreadsheet like Open, using the given options.
+// Supported: grate.WithDebugLogger.
+func OpenWithOptions(spreadsheetID string, client *http.Client, opts ...grate.Option) (grate.Source, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &Spreadsheet{id: spreadsheetID, client: client, opts: grate.ParseOptions(opts...)}
+	var res struct {
+		Sheets []struct {
+			Properties sheetProperties `json:"properties"`
+		} `json:"sheets"`
+	}
+	q := url.Values{"fields": {"sheets.properties(title,hidden,gridProperties(rowCount,columnCount))"}}
+	if err := s.get(q, &res); err != nil {
+		return nil, err
+	}
+	for _, sh := range res.Sheets {
+		s.sheets = append(s.sheets, sh.Properties)
+	}
+	return s, nil
+}
+
+// List returns the titles of the visible sheets.
+func (s *Spreadsheet) List() ([]string, error) {
+	res := make([]string, 0, len(s.sheets))
+	for _, p := range s.sheets {
+		if !p.Hidden {
+			res = append(res, p.Title)
+		}
+	}
+	return res, nil
+}
+
+// SheetCount returns the number of visible sheets.
+func (s *Spreadsheet) SheetCount() (int, error) {
+	n := 0
+	for _, p := range s.sheets {
+		if !p.Hidden {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Get returns a Collection over the rows of the named sheet.
+func (s *Spreadsheet) Get(name string) (grate.Collection, error) {
+	for _, p := range s.sheets {
+		if p.Title == name {
+			return &Sheet{s: s, props: p, row: -1, width: p.GridProperties.ColumnCount}, nil
+		}
+	}
+	return nil, grate.WrapErr(fmt.Errorf("googlesheets: sheet '%s' not found", name), grate.ErrSheetNotFound)
+}
+
+// Close releases the sheet list. The HTTP client is not closed.
+func (s *Spreadsheet) Close() error {
+	s.sheets = nil
+	return nil
+}
+
+// apiError is the error response of the API.
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// get requests the spreadsheet resource with the query parameters q, and
+// decodes the response into v. Requests which are rate limited or fail
+// with a server error are retried.
+func (s *Spreadsheet) get(q url.Values, v interface{}) error {
+	u := baseURL + "/v4/spreadsheets/" + url.PathEscape(s.id) + "?" + q.Encode()
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := s.client.Get(u)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return json.Unmarshal(body, v)
+		}
+
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if retry && attempt < retries {
+			wait := delay
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(secs) * time.Second
+			}
+			s.opts.Logger().Debug("googlesheets: retrying request", "status", resp.StatusCode, "wait", wait)
+			time.Sleep(wait)
+			delay *= 2
+			continue
+		}
+
+		var ae apiError
+		if json.Unmarshal(body, &ae) == nil && ae.Error.Message != "" {
+			return fmt.Errorf("googlesheets: %s (%s)", ae.Error.Message, resp.Status)
+		}
+		return errors.New("googlesheets: " + resp.Status)
+	}
+}
+
+// quoteTitle quotes a sheet title for use in an A1 notation range.
+func quoteTitle(title string) string {
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
@@ -0,0 +1,149 @@
+package googlesheets
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+const testMetadata = `{"sheets":[
+	{"properties":{"title":"Data","gridProperties":{"rowCount":5,"columnCount":3}}},
+	{"properties":{"title":"Secret","hidden":true,"gridProperties":{"rowCount":1,"columnCount":1}}},
+	{"properties":{"title":"Empty","gridProperties":{"rowCount":4,"columnCount":2}}}]}`
+
+// testPages are the responses for pages of 2 rows of the Data sheet. The
+// second page is empty, so its rows are only returned before the third.
+var testPages = map[string]string{
+	"'Data'!1:2": `{"sheets":[{"data":[{"rowData":[
+		{"values":[{"formattedValue":"name","effectiveValue":{"stringValue":"name"}},{"formattedValue":"qty","effectiveValue":{"stringValue":"qty"}},{"formattedValue":"when","effectiveValue":{"stringValue":"when"}}]},
+		{"values":[{"formattedValue":"apple","effectiveValue":{"stringValue":"apple"}},{"formattedValue":"1.5","effectiveValue":{"numberValue":1.5}},{"formattedValue":"2024-01-02","effectiveValue":{"numberValue":45293.5},"effectiveFormat":{"numberFormat":{"type":"DATE","pattern":"yyyy-mm-dd"}}}]}]}]}]}`,
+	"'Data'!3:4": `{"sheets":[{"data":[{}]}]}`,
+	"'Data'!5:6": `{"sheets":[{"data":[{"rowData":[
+		{"values":[{"formattedValue":"TRUE","effectiveValue":{"boolValue":true}},{"formattedValue":"3","effectiveValue":{"numberValue":3}},{}]}]}]}]}`,
+	"'Empty'!1:2": `{"sheets":[{"data":[{}]}]}`,
+	"'Empty'!3:4": `{"sheets":[{"data":[{}]}]}`,
+}
+
+func testServer(t *testing.T, limited int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v4/spreadsheets/abc" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND"}}`))
+			return
+		}
+		if limited > 0 {
+			limited--
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rng := r.URL.Query().Get("ranges")
+		if rng == "" {
+			w.Write([]byte(testMetadata))
+			return
+		}
+		page, ok := testPages[rng]
+		if !ok {
+			t.Errorf("unexpected range %q", rng)
+		}
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	base, rows, delay := baseURL, pageRows, retryDelay
+	t.Cleanup(func() { baseURL, pageRows, retryDelay = base, rows, delay })
+	baseURL, pageRows, retryDelay = srv.URL, 2, time.Millisecond
+	return srv
+}
+
+func TestOpen(t *testing.T) {
+	testServer(t, 2)
+	src, err := Open("abc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	names, _ := src.List()
+	if !reflect.DeepEqual(names, []string{"Data", "Empty"}) {
+		t.Errorf("unexpected sheets %v", names)
+	}
+	if n, _ := src.SheetCount(); n != 2 {
+		t.Errorf("expected 2 sheets, got %d", n)
+	}
+	if _, err = src.Get("Missing"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+
+	c, err := src.Get("Data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Width() != 3 {
+		t.Errorf("expected width 3 before reading, got %d", c.Width())
+	}
+	var rows, types [][]string
+	var values [][]interface{}
+	for c.Next() {
+		rows = append(rows, c.Strings())
+		types = append(types, c.Types())
+		values = append(values, c.Values())
+	}
+	if err = c.Err(); err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{{"name", "qty", "when"}, {"apple", "1.5", "2024-01-02"}, {}, {}, {"TRUE", "3", ""}}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("got rows %q, expected %q", rows, wantRows)
+	}
+	if want := []string{"string", "float", "date"}; !reflect.DeepEqual(types[1], want) {
+		t.Errorf("got types %q, expected %q", types[1], want)
+	}
+	if want := []string{"boolean", "integer", "blank"}; !reflect.DeepEqual(types[4], want) {
+		t.Errorf("got types %q, expected %q", types[4], want)
+	}
+	when := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	if want := []interface{}{"apple", 1.5, when}; !reflect.DeepEqual(values[1], want) {
+		t.Errorf("got values %v, expected %v", values[1], want)
+	}
+	if c.Width() != 3 {
+		t.Errorf("expected width 3, got %d", c.Width())
+	}
+
+	c, _ = src.Get("Empty")
+	if !c.IsEmpty() || c.Next() {
+		t.Error("expected an empty sheet")
+	}
+	if c.Width() != 2 {
+		t.Errorf("expected the grid width 2, got %d", c.Width())
+	}
+}
+
+func TestOpenWithOptions(t *testing.T) {
+	testServer(t, 1)
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	src, err := OpenWithOptions("abc", nil, grate.WithDebugLogger(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if !strings.Contains(buf.String(), "retrying request") {
+		t.Errorf("expected the retry to be logged, got %q", buf.String())
+	}
+}
+
+func TestOpenError(t *testing.T) {
+	testServer(t, 0)
+	_, err := Open("missing", nil)
+	if err == nil || !strings.Contains(err.Error(), "Requested entity was not found") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package googlesheets
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+// cellData is the value and format of a cell returned by the API.
+type cellData struct {
+	FormattedValue  string         `json:"formattedValue"`
+	EffectiveValue  *extendedValue `json:"effectiveValue"`
+	EffectiveFormat *struct {
+		NumberFormat *struct {
+			Type    string `json:"type"`
+			Pattern string `json:"pattern"`
+		} `json:"numberFormat"`
+	} `json:"effectiveFormat"`
+}
+
+// extendedValue is the calculated value of a cell. Exactly one of the
+// fields is set for non-empty cells.
+type extendedValue struct {
+	NumberValue *float64 `json:"numberValue"`
+	StringValue *string  `json:"stringValue"`
+	BoolValue   *bool    `json:"boolValue"`
+	ErrorValue  *struct {
+		Type string `json:"type"`
+	} `json:"errorValue"`
+}
+
+// numberFormat returns the number format type and pattern of the cell.
+func (c cellData) numberFormat() (typ, pattern string) {
+	if c.EffectiveFormat == nil || c.EffectiveFormat.NumberFormat == nil {
+		return "", ""
+	}
+	return c.EffectiveFormat.NumberFormat.Type, c.EffectiveFormat.NumberFormat.Pattern
+}
+
+// sheetsEpoch is the day zero of the serial numbers of dates and times.
+var sheetsEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Sheet is a sheet of a Google Sheets spreadsheet. Its rows are fetched a
+// page at a time during iteration.
+type Sheet struct {
+	s     *Spreadsheet
+	props sheetProperties
+
+	// rows fetched but not yet returned, starting with the current row
+	rows [][]cellData
+	// the number of rows requested so far, and the number of empty rows
+	// at the end of the last page, which are only returned if more
+	// content follows
+	fetched, pending int
+
+	row   int
+	width int
+	err   error
+}
+
+// fill fetches pages of rows until at least n rows are buffered or the
+// end of the sheet is reached, returning false in the latter case.
+func (t *Sheet) fill(n int) bool {
+	for len(t.rows) < n {
+		if t.err != nil || t.fetched >= t.props.GridProperties.RowCount {
+			return false
+		}
+		start := t.fetched + 1
+		end := t.fetched + pageRows
+		q := url.Values{
+			"ranges":          {fmt.Sprintf("%s!%d:%d", quoteTitle(t.props.Title), start, end)},
+			"includeGridData": {"true"},
+			"fields":          {"sheets.data.rowData.values(formattedValue,effectiveValue,effectiveFormat.numberFormat)"},
+		}
+		var res struct {
+			Sheets []struct {
+				Data []struct {
+					RowData []struct {
+						Values []cellData `json:"values"`
+					} `json:"rowData"`
+				} `json:"data"`
+			} `json:"sheets"`
+		}
+		if t.err = t.s.get(q, &res); t.err != nil {
+			return false
+		}
+		t.fetched = end
+
+		var page [][]cellData
+		if len(res.Sheets) > 0 && len(res.Sheets[0].Data) > 0 {
+			for _, rd := range res.Sheets[0].Data[0].RowData {
+				page = append(page, rd.Values)
+			}
+		}
+		if len(page) > 0 {
+			for ; t.pending > 0; t.pending-- {
+				t.rows = append(t.rows, nil)
+			}
+			t.rows = append(t.rows, page...)
+		}
+		t.pending += pageRows - len(page)
+	}
+	return true
+}
+
+// current returns the cells of the current row.
+func (t *Sheet) current() []cellData {
+	return t.rows[0]
+}
+
+// Next advances to the next row, fetching a page of rows when needed.
+func (t *Sheet) Next() bool {
+	if t.row >= 0 && len(t.rows) > 0 {
+		t.rows = t.rows[1:]
+	}
+	if !t.fill(1) {
+		return false
+	}
+	t.row++
+	if n := len(t.current()); n > t.width {
+		t.width = n
+	}
+	return true
+}
+
+// Row returns the zero-based index of the current row.
+func (t *Sheet) Row() int {
+	return t.row
+}
+
+// Strings returns the formatted values of the current row.
+func (t *Sheet) Strings() []string {
+	cells := t.current()
+	res := make([]string, len(cells))
+	for i, c := range cells {
+		res[i] = c.FormattedValue
+	}
+	return res
+}
+
+// Types returns the types of the current row, inferred from the effective
+// values and number formats of the cells.
+func (t *Sheet) Types() []string {
+	cells := t.current()
+	res := make([]string, len(cells))
+	for i, c := range cells {
+		res[i] = cellType(c)
+	}
+	return res
+}
+
+// cellType returns the grate type name of a cell.
+func cellType(c cellData) string {
+	v := c.EffectiveValue
+	switch {
+	case v == nil:
+		return "blank"
+	case v.NumberValue != nil:
+		switch typ, _ := c.numberFormat(); typ {
+		case "DATE", "TIME", "DATE_TIME":
+			return "date"
+		}
+		if f := *v.NumberValue; f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return "integer"
+		}
+		return "float"
+	case v.BoolValue != nil:
+		return "boolean"
+	case v.ErrorValue != nil:
+		return "error"
+	}
+	return "string"
+}
+
+// Values returns the native values of the current row. Dates and times
+// are returned in UTC.
+func (t *Sheet) Values() []interface{} {
+	cells := t.current()
+	res := make([]interface{}, len(cells))
+	for i, c := range cells {
+		res[i] = c.FormattedValue
+		v := c.EffectiveValue
+		switch cellType(c) {
+		case "date":
+			d := time.Duration(*v.NumberValue * 24 * float64(time.Hour))
+			res[i] = sheetsEpoch.Add(d).Round(time.Millisecond)
+		case "integer":
+			res[i] = int64(*v.NumberValue)
+		case "float":
+			res[i] = *v.NumberValue
+		case "boolean":
+			res[i] = *v.BoolValue
+		case "string":
+			if v.StringValue != nil {
+				res[i] = *v.StringValue
+			}
+		}
+	}
+	return res
+}
+
+// Formats returns the number format patterns of the current row, or
+// "General" for cells without one.
+func (t *Sheet) Formats() []string {
+	cells := t.current()
+	res := make([]string, len(cells))
+	for i, c := range cells {
+		res[i] = "General"
+		if _, pattern := c.numberFormat(); pattern != "" {
+			res[i] = pattern
+		}
+	}
+	return res
+}
+
+// Scan extracts the values of the current row into the arguments, which
+// must be pointers to bool, int, int64, float64, string or time.Time.
+func (t *Sheet) Scan(args ...interface{}) error {
+	vals := t.Values()
+	strs := t.Strings()
+	for i, a := range args {
+		var v interface{}
+		if i < len(vals) {
+			v = vals[i]
+		}
+		var ok bool
+		switch d := a.(type) {
+		case *bool:
+			*d, ok = v.(bool)
+		case *int:
+			var n int64
+			n, ok = v.(int64)
+			*d = int(n)
+		case *int64:
+			*d, ok = v.(int64)
+		case *float64:
+			switch n := v.(type) {
+			case float64:
+				*d, ok = n, true
+			case int64:
+				*d, ok = float64(n), true
+			}
+		case *string:
+			if i < len(strs) {
+				*d = strs[i]
+			}
+			ok = true
+		case *time.Time:
+			*d, ok = v.(time.Time)
+		default:
+			return grate.ErrInvalidScanType
+		}
+		if !ok && v != nil && v != "" {
+			return fmt.Errorf("googlesheets: cannot scan %T value '%v' into %T", v, v, a)
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if the sheet has no rows with values. It may fetch
+// the first page of rows.
+func (t *Sheet) IsEmpty() bool {
+	if t.row >= 0 {
+		return false
+	}
+	return !t.fill(1)
+}
+
+// Width returns the number of columns of the sheet's grid, or the number
+// of cells of the widest row read so far if that is greater.
+func (t *Sheet) Width() int {
+	return t.width
+}
+
+// Err returns the error of the last request, if any.
+func (t *Sheet) Err() error {
+	return t.err
+}
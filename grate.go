@@ -5,10 +5,13 @@ package grate
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
-	"log"
+	"path"
 	"sort"
+	"strings"
+	"sync"
 )
 
 // Source represents a set of data collections.
@@ -16,6 +19,10 @@ type Source interface {
 	// List the individual data tables within this source.
 	List() ([]string, error)
 
+	// SheetCount returns the number of data tables within this source,
+	// without allocating the list of names.
+	SheetCount() (int, error)
+
 	// Get a Collection from the source by name.
 	Get(name string) (Collection, error)
 
@@ -29,14 +36,24 @@ type Collection interface {
 	// It MUST be called prior to any Scan().
 	Next() bool
 
+	// Row returns the zero-based index of the record returned by the last
+	// call to Next, or -1 before the first call.
+	Row() int
+
 	// Strings extracts values from the current record into a list of strings.
 	Strings() []string
 
 	// Types extracts the data types from the current record into a list.
 	// options: "boolean", "integer", "float", "string", "date",
-	// and special cases: "blank", "hyperlink" which are string types
+	// and special cases: "blank", "hyperlink", "error" which are string types
 	Types() []string
 
+	// Values extracts the native values of the current record into a list,
+	// each matching its type from Types(): int64 for "integer", float64 for
+	// "float", bool for "boolean", time.Time for "date", and string for
+	// all other types.
+	Values() []interface{}
+
 	// Formats extracts the format codes for the current record into a list.
 	Formats() []string
 
@@ -49,6 +66,11 @@ type Collection interface {
 	// IsEmpty returns true if there are no data values.
 	IsEmpty() bool
 
+	// Width returns the number of columns: the maximum seen so far during
+	// iteration, or the declared dimension of the collection when the
+	// format provides one. It returns 0 if the width is not known yet.
+	Width() int
+
 	// Err returns the last error that occured.
 	Err() error
 }
@@ -67,65 +89,137 @@ type OpenReaderFunc func(reader io.ReadCloser) (Source, error)
 
 // Open a tabular data file and return a Source for accessing it's contents.
 func Open(filename string) (Source, error) {
-	for _, o := range srcTable {
+	src, _, err := openFormat(filename)
+	return src, err
+}
+
+// OpenPreferring opens a tabular data file as with Open, but tries the
+// named format first, regardless of its registered priority, before falling
+// back to the others in priority order. It returns an error wrapping
+// ErrUnknownFormat if no format with that name is registered.
+func OpenPreferring(filename, formatName string) (Source, error) {
+	table := sources()
+	tabs := make([]*srcOpenTab, 0, len(table))
+	for _, o := range table {
+		if o.name == formatName {
+			tabs = append(tabs, o)
+		}
+	}
+	if len(tabs) == 0 {
+		return nil, WrapErr(fmt.Errorf("grate: format '%s' is not registered", formatName), ErrUnknownFormat)
+	}
+	for _, o := range table {
+		if o.name != formatName {
+			tabs = append(tabs, o)
+		}
+	}
+	src, _, err := openTabs(filename, tabs)
+	return src, err
+}
+
+// openFormat opens a file as with Open, also returning the format name.
+func openFormat(filename string) (Source, string, error) {
+	return openTabs(filename, sources())
+}
+
+// openTabs tries each of the formats in order, returning the first Source
+// opened and the name of its format.
+func openTabs(filename string, tabs []*srcOpenTab) (Source, string, error) {
+	release := acquireOpen()
+	src, name, err := tryTabs(filename, tabs)
+	src, err = limitOpen(release, src, err)
+	return src, name, err
+}
+
+// tryTabs implements openTabs, without the limit set by SetMaxConcurrent.
+func tryTabs(filename string, tabs []*srcOpenTab) (Source, string, error) {
+	for _, o := range tabs {
 		src, err := o.op(filename)
 		if err == nil {
-			return src, nil
+			return src, o.name, nil
 		}
 		if !errors.Is(err, ErrNotInFormat) {
-			return nil, err
-		}
-		if Debug {
-			log.Println(" ", filename, "is not in", o.name, "format")
+			return nil, "", err
 		}
+		Logger().Debug("file is not in format", "filename", filename, "format", o.name)
 	}
-	return nil, ErrUnknownFormat
+	return nil, "", ErrUnknownFormat
 }
 
 // OpenFile opens a tabular data file from an fs.File and returns a Source for accessing its contents.
 func OpenFile(file fs.File) (Source, error) {
-	for _, o := range fileTable {
+	release := acquireOpen()
+	for _, o := range files() {
 		src, err := o.op(file)
+		if err == nil || !errors.Is(err, ErrNotInFormat) {
+			return limitOpen(release, src, err)
+		}
+		Logger().Debug("file is not in format", "format", o.name)
+	}
+	return limitOpen(release, nil, ErrUnknownFormat)
+}
+
+// OpenFS opens the named file from fsys and returns a Source for accessing
+// its contents, as with OpenFile. The format matching the file extension
+// (if any) is tried first, followed by the others in priority order. The
+// file is reopened for each format tried.
+func OpenFS(fsys fs.FS, name string) (Source, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	table := files()
+	tabs := make([]*fileOpenTab, 0, len(table))
+	for _, o := range table {
+		if o.name == ext {
+			tabs = append(tabs, o)
+		}
+	}
+	for _, o := range table {
+		if o.name != ext {
+			tabs = append(tabs, o)
+		}
+	}
+
+	release := acquireOpen()
+	for _, o := range tabs {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return limitOpen(release, nil, err)
+		}
+		src, err := o.op(f)
 		if err == nil {
-			return src, nil
+			return limitOpen(release, src, nil)
 		}
+		f.Close()
 		if !errors.Is(err, ErrNotInFormat) {
-			return nil, err
-		}
-		if Debug {
-			log.Println("file is not in", o.name, "format")
+			return limitOpen(release, nil, err)
 		}
+		Logger().Debug("file is not in format", "filename", name, "format", o.name)
 	}
-	return nil, ErrUnknownFormat
+	return limitOpen(release, nil, ErrUnknownFormat)
 }
 
 // OpenReader opens a tabular data file from an io.ReadCloser and returns a Source for accessing its contents.
 func OpenReader(reader io.ReadCloser) (Source, error) {
+	release := acquireOpen()
 	// 首先读取reader的所有内容到内存中
 	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, err
+		return limitOpen(release, nil, err)
 	}
 	// 关闭原始reader
 	if err := reader.Close(); err != nil {
-		return nil, err
+		return limitOpen(release, nil, err)
 	}
 
-	for _, o := range readerTable {
+	for _, o := range readers() {
 		// 为每个opener创建一个新的reader，保证每个处理器都能读取完整数据
 		clonedReader := io.NopCloser(bytes.NewReader(data))
 		src, err := o.op(clonedReader)
-		if err == nil {
-			return src, nil
-		}
-		if !errors.Is(err, ErrNotInFormat) {
-			return nil, err
-		}
-		if Debug {
-			log.Println("reader is not in", o.name, "format")
+		if err == nil || !errors.Is(err, ErrNotInFormat) {
+			return limitOpen(release, src, err)
 		}
+		Logger().Debug("reader is not in format", "format", o.name)
 	}
-	return nil, ErrUnknownFormat
+	return limitOpen(release, nil, ErrUnknownFormat)
 }
 
 type srcOpenTab struct {
@@ -146,15 +240,41 @@ type readerOpenTab struct {
 	op   OpenReaderFunc
 }
 
+// tableMu guards the registration tables (including detectTable and
+// optTable), which may be modified concurrently by plugins.
+var tableMu sync.RWMutex
+
 var srcTable = make([]*srcOpenTab, 0, 20)
 var fileTable = make([]*fileOpenTab, 0, 20)
 var readerTable = make([]*readerOpenTab, 0, 20)
 
+// sources returns a copy of srcTable which can be iterated without holding
+// the lock.
+func sources() []*srcOpenTab {
+	tableMu.RLock()
+	defer tableMu.RUnlock()
+	return append([]*srcOpenTab(nil), srcTable...)
+}
+
+// files returns a copy of fileTable.
+func files() []*fileOpenTab {
+	tableMu.RLock()
+	defer tableMu.RUnlock()
+	return append([]*fileOpenTab(nil), fileTable...)
+}
+
+// readers returns a copy of readerTable.
+func readers() []*readerOpenTab {
+	tableMu.RLock()
+	defer tableMu.RUnlock()
+	return append([]*readerOpenTab(nil), readerTable...)
+}
+
 // Register the named source as a grate datasource implementation.
 func Register(name string, priority int, opener OpenFunc) error {
-	if Debug {
-		log.Println("Registering the", name, "format at priority", priority)
-	}
+	Logger().Debug("registering format", "format", name, "priority", priority)
+	tableMu.Lock()
+	defer tableMu.Unlock()
 	srcTable = append(srcTable, &srcOpenTab{name: name, pri: priority, op: opener})
 	sort.Slice(srcTable, func(i, j int) bool {
 		return srcTable[i].pri < srcTable[j].pri
@@ -164,9 +284,9 @@ func Register(name string, priority int, opener OpenFunc) error {
 
 // RegisterFile registers the named source as a grate datasource implementation for fs.File.
 func RegisterFile(name string, priority int, opener OpenFileFunc) error {
-	if Debug {
-		log.Println("Registering the", name, "format for fs.File at priority", priority)
-	}
+	Logger().Debug("registering format for fs.File", "format", name, "priority", priority)
+	tableMu.Lock()
+	defer tableMu.Unlock()
 	fileTable = append(fileTable, &fileOpenTab{name: name, pri: priority, op: opener})
 	sort.Slice(fileTable, func(i, j int) bool {
 		return fileTable[i].pri < fileTable[j].pri
@@ -176,9 +296,9 @@ func RegisterFile(name string, priority int, opener OpenFileFunc) error {
 
 // RegisterReader registers the named source as a grate datasource implementation for io.ReadCloser.
 func RegisterReader(name string, priority int, opener OpenReaderFunc) error {
-	if Debug {
-		log.Println("Registering the", name, "format for io.ReadCloser at priority", priority)
-	}
+	Logger().Debug("registering format for io.ReadCloser", "format", name, "priority", priority)
+	tableMu.Lock()
+	defer tableMu.Unlock()
 	readerTable = append(readerTable, &readerOpenTab{name: name, pri: priority, op: opener})
 	sort.Slice(readerTable, func(i, j int) bool {
 		return readerTable[i].pri < readerTable[j].pri
@@ -186,6 +306,78 @@ func RegisterReader(name string, priority int, opener OpenReaderFunc) error {
 	return nil
 }
 
+// Deregister removes the named format from the tables used by Open,
+// OpenFile and OpenReader, along with its detection function and options
+// opener, so that it can be registered again. Opens already in progress
+// with the format's openers complete normally. It returns an error wrapping
+// ErrUnknownFormat if no format with that name is registered.
+func Deregister(name string) error {
+	Logger().Debug("deregistering format", "format", name)
+	tableMu.Lock()
+	defer tableMu.Unlock()
+	n := len(srcTable) + len(fileTable) + len(readerTable)
+	var src []*srcOpenTab
+	for _, o := range srcTable {
+		if o.name != name {
+			src = append(src, o)
+		}
+	}
+	var file []*fileOpenTab
+	for _, o := range fileTable {
+		if o.name != name {
+			file = append(file, o)
+		}
+	}
+	var rdr []*readerOpenTab
+	for _, o := range readerTable {
+		if o.name != name {
+			rdr = append(rdr, o)
+		}
+	}
+	if len(src)+len(file)+len(rdr) == n {
+		return WrapErr(fmt.Errorf("grate: format '%s' is not registered", name), ErrUnknownFormat)
+	}
+	srcTable, fileTable, readerTable = src, file, rdr
+	delete(detectTable, name)
+	delete(optTable, name)
+	return nil
+}
+
+// ListFormats returns the names of all registered formats, ordered by
+// priority. Formats registered for more than one way of opening (filename,
+// fs.File or io.ReadCloser) are listed once, at their highest priority.
+func ListFormats() []string {
+	tableMu.RLock()
+	defer tableMu.RUnlock()
+	pri := make(map[string]int)
+	add := func(name string, p int) {
+		if q, ok := pri[name]; !ok || p < q {
+			pri[name] = p
+		}
+	}
+	for _, o := range srcTable {
+		add(o.name, o.pri)
+	}
+	for _, o := range fileTable {
+		add(o.name, o.pri)
+	}
+	for _, o := range readerTable {
+		add(o.name, o.pri)
+	}
+
+	res := make([]string, 0, len(pri))
+	for name := range pri {
+		res = append(res, name)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if pri[res[i]] != pri[res[j]] {
+			return pri[res[i]] < pri[res[j]]
+		}
+		return res[i] < res[j]
+	})
+	return res
+}
+
 const (
 	// ContinueColumnMerged marks a continuation column within a merged cell.
 	ContinueColumnMerged = "→"
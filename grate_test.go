@@ -0,0 +1,110 @@
+package grate
+
+import "errors"
+
+// testCollection is a minimal in-memory Collection used by the tests.
+type testCollection struct {
+	rows    [][]string
+	types   [][]string      // optional, derived from rows when nil
+	values  [][]interface{} // optional, parsed from rows when nil
+	iterRow int
+	err     error
+}
+
+func newTestCollection(rows ...[]string) *testCollection {
+	return &testCollection{rows: rows, iterRow: -1}
+}
+
+func (t *testCollection) Next() bool {
+	t.iterRow++
+	return t.iterRow < len(t.rows)
+}
+
+func (t *testCollection) Row() int {
+	if t.iterRow >= len(t.rows) {
+		return len(t.rows) - 1
+	}
+	return t.iterRow
+}
+
+func (t *testCollection) Strings() []string {
+	return t.rows[t.iterRow]
+}
+
+func (t *testCollection) Types() []string {
+	if t.types != nil {
+		return t.types[t.iterRow]
+	}
+	res := make([]string, len(t.rows[t.iterRow]))
+	for i, v := range t.rows[t.iterRow] {
+		if v == "" {
+			res[i] = "blank"
+		} else {
+			res[i] = "string"
+		}
+	}
+	return res
+}
+
+func (t *testCollection) Values() []interface{} {
+	if t.values != nil {
+		return t.values[t.iterRow]
+	}
+	return parseValues(t.Strings(), t.Types())
+}
+
+func (t *testCollection) Formats() []string {
+	res := make([]string, len(t.rows[t.iterRow]))
+	for i := range res {
+		res[i] = "General"
+	}
+	return res
+}
+
+func (t *testCollection) Scan(args ...interface{}) error {
+	return ErrInvalidScanType
+}
+
+func (t *testCollection) IsEmpty() bool {
+	return len(t.rows) == 0
+}
+
+func (t *testCollection) Width() int {
+	w := 0
+	for _, row := range t.rows {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w
+}
+
+func (t *testCollection) Err() error {
+	return t.err
+}
+
+// testSource is a minimal in-memory Source used by the tests.
+type testSource struct {
+	names []string
+	colls map[string]*testCollection
+}
+
+func (t *testSource) List() ([]string, error) {
+	return t.names, nil
+}
+
+func (t *testSource) SheetCount() (int, error) {
+	return len(t.names), nil
+}
+
+func (t *testSource) Get(name string) (Collection, error) {
+	c, ok := t.colls[name]
+	if !ok {
+		return nil, WrapErr(errors.New("test: sheet not found"), ErrSheetNotFound)
+	}
+	return c, nil
+}
+
+func (t *testSource) Close() error {
+	return nil
+}
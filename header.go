@@ -0,0 +1,54 @@
+package grate
+
+// HeaderCollection is a Collection whose first record has been consumed as
+// a header row.
+type HeaderCollection interface {
+	Collection
+
+	// Header returns the values of the header row.
+	Header() []string
+}
+
+// UseFirstRowAsHeader reads the first record of c and returns a
+// HeaderCollection which iterates over the remaining records. Row indexes
+// are zero-based from the first record after the header. It returns
+// ErrIterationStarted if Next has already been called on c, and ErrNoHeader
+// if c has no records.
+func UseFirstRowAsHeader(c Collection) (HeaderCollection, error) {
+	if c.Row() >= 0 {
+		return nil, ErrIterationStarted
+	}
+	if !c.Next() {
+		if err := c.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoHeader
+	}
+	header := append([]string(nil), c.Strings()...)
+	return &headerCollection{Collection: c, header: header, row: -1}, nil
+}
+
+type headerCollection struct {
+	Collection
+	header []string
+	row    int
+}
+
+// Header returns the values of the header row.
+func (h *headerCollection) Header() []string {
+	return h.header
+}
+
+// Next advances to the next record after the header.
+func (h *headerCollection) Next() bool {
+	if !h.Collection.Next() {
+		return false
+	}
+	h.row++
+	return true
+}
+
+// Row returns the zero-based index of the current record after the header.
+func (h *headerCollection) Row() int {
+	return h.row
+}
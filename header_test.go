@@ -0,0 +1,40 @@
+package grate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUseFirstRowAsHeader(t *testing.T) {
+	c, err := UseFirstRowAsHeader(newTestCollection(
+		[]string{"name", "age"},
+		[]string{"alice", "30"},
+		[]string{"bob", "40"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"name", "age"}; !reflect.DeepEqual(c.Header(), want) {
+		t.Errorf("got header %q, expected %q", c.Header(), want)
+	}
+	var got []string
+	for c.Next() {
+		if c.Row() != len(got) {
+			t.Errorf("expected row %d, got %d", len(got), c.Row())
+		}
+		got = append(got, c.Strings()[0])
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+
+	started := newTestCollection([]string{"a"}, []string{"b"})
+	started.Next()
+	if _, err := UseFirstRowAsHeader(started); !errors.Is(err, ErrIterationStarted) {
+		t.Errorf("expected ErrIterationStarted, got %v", err)
+	}
+	if _, err := UseFirstRowAsHeader(newTestCollection()); !errors.Is(err, ErrNoHeader) {
+		t.Errorf("expected ErrNoHeader, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Package httpgrate serves tabular data files over HTTP as JSON. It is
+// separate from the grate package, so that programs which do not serve
+// files do not link net/http.
+package httpgrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wubin1989/grate"
+)
+
+type handlerConfig struct {
+	lines bool
+}
+
+// HandlerOption configures the http.Handler returned by NewHandler.
+type HandlerOption func(*handlerConfig)
+
+// WithJSONLines makes the handler write sheet rows as JSON Lines instead
+// of a JSON array.
+func WithJSONLines() HandlerOption {
+	return func(c *handlerConfig) { c.lines = true }
+}
+
+// NewHandler returns an http.Handler serving the tabular data files of fsys
+// as JSON. The URL path names the file, which is opened with grate.OpenFS.
+//
+// Without query parameters, the response is a JSON array of the sheet
+// names. With ?sheet=name the rows of the sheet are written using
+// grate.ToJSON (or grate.ToJSONLines, see WithJSONLines). The rows can be
+// restricted with the "offset" and "limit" parameters, and the columns with
+// "columns", a comma separated list of zero-based column indexes.
+func NewHandler(fsys fs.FS, opts ...HandlerOption) http.Handler {
+	cfg := &handlerConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+	return &handler{fsys: fsys, cfg: cfg}
+}
+
+type handler struct {
+	fsys fs.FS
+	cfg  *handlerConfig
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if !fs.ValidPath(name) || name == "." {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	offset, err := queryInt(q.Get("offset"))
+	if err != nil {
+		http.Error(w, "invalid offset", http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(q.Get("limit"))
+	if err != nil {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	var columns []int
+	if cols := q.Get("columns"); cols != "" {
+		for _, s := range strings.Split(cols, ",") {
+			n, err := queryInt(strings.TrimSpace(s))
+			if err != nil {
+				http.Error(w, "invalid columns", http.StatusBadRequest)
+				return
+			}
+			columns = append(columns, n)
+		}
+	}
+
+	src, err := grate.OpenFS(h.fsys, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			http.NotFound(w, r)
+		case errors.Is(err, grate.ErrUnknownFormat):
+			http.Error(w, "unsupported file format", http.StatusUnsupportedMediaType)
+		default:
+			grate.Logger().Warn("httpgrate: handler failed to open file", "name", name, "error", err)
+			http.Error(w, "unable to open file", http.StatusInternalServerError)
+		}
+		return
+	}
+	defer src.Close()
+
+	if !q.Has("sheet") {
+		names, err := src.List()
+		if err != nil {
+			http.Error(w, "unable to list sheets", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(names)
+		return
+	}
+
+	c, err := src.Get(q.Get("sheet"))
+	if err != nil {
+		if errors.Is(err, grate.ErrSheetNotFound) {
+			http.Error(w, "sheet not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "unable to open sheet", http.StatusInternalServerError)
+		}
+		return
+	}
+	if offset > 0 {
+		c = grate.Skip(c, offset)
+	}
+	if limit > 0 {
+		c = grate.Limit(c, limit)
+	}
+	if columns != nil {
+		c = grate.SelectColumns(c, columns)
+	}
+
+	if h.cfg.lines {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		err = grate.ToJSONLines(c, w)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = grate.ToJSON(c, w)
+	}
+	if err != nil {
+		// the response has already started, so only log the error
+		grate.Logger().Warn("httpgrate: handler failed to write sheet", "name", name, "error", err)
+	}
+}
+
+// queryInt parses a non-negative integer query parameter, where an empty
+// value is zero.
+func queryInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n < 0 {
+		err = errors.New("negative value")
+	}
+	return n, err
+}
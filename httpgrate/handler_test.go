@@ -0,0 +1,50 @@
+package httpgrate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/wubin1989/grate/simple"
+	_ "github.com/wubin1989/grate/xlsx"
+)
+
+func TestHandler(t *testing.T) {
+	fsys := os.DirFS("../testdata")
+
+	tests := []struct {
+		opts   []HandlerOption
+		url    string
+		status int
+		body   string
+	}{
+		{nil, "/basic.xlsx", 200, `["Sheet 1"]` + "\n"},
+		{nil, "/basic.xlsx?sheet=Sheet+1&limit=2", 200, `[["a","b","c","d"],["1","Hello","42","0"]]` + "\n"},
+		{nil, "/basic.xlsx?sheet=Sheet+1&offset=1&limit=1&columns=2,0", 200, `[["42","1"]]` + "\n"},
+		{[]HandlerOption{WithJSONLines()}, "/basic.xlsx?sheet=Sheet+1&offset=5&limit=1&columns=1,9", 200, `["Text",""]` + "\n"},
+		{nil, "/basic.xlsx?sheet=Missing", 404, ""},
+		{nil, "/missing.xlsx", 404, ""},
+		{nil, "/basic.tsv?sheet=basic.tsv&limit=1", 200, `[["a","b","c","d"]]` + "\n"},
+		{nil, "/basic.xlsx?sheet=Sheet+1&limit=-1", 400, ""},
+		{nil, "/basic.xlsx?sheet=Sheet+1&columns=a", 400, ""},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		NewHandler(fsys, tc.opts...).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.url, nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s: got status %d, expected %d", tc.url, rec.Code, tc.status)
+			continue
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Errorf("%s: got body %q, expected %q", tc.url, rec.Body.String(), tc.body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	NewHandler(fsys).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/basic.xlsx", strings.NewReader("")))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d for POST, expected 405", rec.Code)
+	}
+}
@@ -0,0 +1,23 @@
+// Package testutil holds helpers shared by the tests of the format packages.
+package testutil
+
+import "github.com/wubin1989/grate"
+
+// DrainSource reads up to maxRows records of every sheet of src, ignoring
+// errors, as the fuzz targets do to exercise the parsers.
+func DrainSource(src grate.Source, maxRows int) {
+	names, _ := src.List()
+	for _, name := range names {
+		c, err := src.Get(name)
+		if err != nil || c == nil {
+			// dialog sheets have no collection
+			continue
+		}
+		for n := 0; n < maxRows && c.Next(); n++ {
+			c.Strings()
+			c.Types()
+			c.Formats()
+			c.Values()
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package grate
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// ToJSON writes the remaining records of the collection to w as a JSON
+// array, with each record an array of its string values.
+func ToJSON(c Collection, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+	n := 0
+	for c.Next() {
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		data, err := json.Marshal(c.Strings())
+		if err != nil {
+			return err
+		}
+		bw.Write(data)
+		n++
+	}
+	bw.WriteString("]\n")
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ToJSONLines writes the remaining records of the collection to w as JSON
+// Lines, with each record an array of its string values on its own line.
+func ToJSONLines(c Collection, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for c.Next() {
+		if err := enc.Encode(c.Strings()); err != nil {
+			return err
+		}
+	}
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
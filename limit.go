@@ -0,0 +1,84 @@
+package grate
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// openSem holds a slot for each open Source while a limit is set.
+var openSem atomic.Pointer[chan struct{}]
+
+// SetMaxConcurrent limits the number of Sources opened by Open, OpenFile,
+// OpenFS, OpenReader, OpenWithOptions (and the other functions built on
+// them) which may be open at the same time. Once n Sources are open, these
+// functions block until one of them is closed, which provides backpressure
+// for servers processing uploaded files. Pass n <= 0 to remove the limit.
+//
+// Sources which are never closed hold their slot forever. Sources opened
+// before a call to SetMaxConcurrent do not count against the new limit.
+// While a limit is set, the returned Sources wrap those of the format, so
+// use Unwrap before type asserting them to the format's own types or to
+// optional interfaces such as StatsSource and SourceMetadata.
+func SetMaxConcurrent(n int) {
+	if n <= 0 {
+		openSem.Store(nil)
+		return
+	}
+	sem := make(chan struct{}, n)
+	openSem.Store(&sem)
+}
+
+// acquireOpen blocks until a Source may be opened, and returns the function
+// releasing its slot, or nil if there is no limit.
+func acquireOpen() func() {
+	sem := openSem.Load()
+	if sem == nil {
+		return nil
+	}
+	*sem <- struct{}{}
+	var once sync.Once
+	return func() { once.Do(func() { <-*sem }) }
+}
+
+// limitOpen returns src (or err) from an opener called after acquireOpen,
+// releasing the slot if the open failed or tying it to src.Close otherwise.
+func limitOpen(release func(), src Source, err error) (Source, error) {
+	if release == nil {
+		return src, err
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedSource{Source: src, release: release}, nil
+}
+
+// limitedSource releases its slot when it is closed.
+type limitedSource struct {
+	Source
+	release func()
+}
+
+// Close closes the underlying Source and releases its slot.
+func (s *limitedSource) Close() error {
+	defer s.release()
+	return s.Source.Close()
+}
+
+// Unwrap returns the underlying Source.
+func (s *limitedSource) Unwrap() Source {
+	return s.Source
+}
+
+// Unwrap returns the Source of the format from src, which may wrap it (as
+// while a limit is set with SetMaxConcurrent), by calling its Unwrap()
+// Source method until there is none. Other Sources are returned as-is.
+func Unwrap(src Source) Source {
+	for {
+		u, ok := src.(interface{ Unwrap() Source })
+		if !ok {
+			return src
+		}
+		src = u.Unwrap()
+	}
+}
@@ -0,0 +1,135 @@
+package grate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetMaxConcurrent(t *testing.T) {
+	src := srcTable
+	t.Cleanup(func() {
+		srcTable = src
+		SetMaxConcurrent(0)
+	})
+	srcTable = nil
+	Register("limited", 1, func(filename string) (Source, error) {
+		if filename == "bad" {
+			return nil, errors.New("test: broken file")
+		}
+		return &testSource{names: []string{filename}}, nil
+	})
+
+	SetMaxConcurrent(2)
+	s1, err := Open("one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Open("bad"); err == nil {
+		t.Fatal("expected an error")
+	}
+	s2, err := Open("two")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened := make(chan Source)
+	go func() {
+		s3, _ := Open("three")
+		opened <- s3
+	}()
+	select {
+	case <-opened:
+		t.Fatal("expected the third open to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s1.Close()
+	s1.Close() // closing twice releases a single slot
+	var s3 Source
+	select {
+	case s3 = <-opened:
+	case <-time.After(time.Second):
+		t.Fatal("expected the third open to proceed after a close")
+	}
+	if names, _ := s3.List(); len(names) != 1 || names[0] != "three" {
+		t.Errorf("got sheets %v, expected [three]", names)
+	}
+	s2.Close()
+	s3.Close()
+
+	SetMaxConcurrent(0)
+	s1, err = Open("one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s1.(*testSource); !ok {
+		t.Errorf("expected the format's Source without a limit, got %T", s1)
+	}
+}
+
+// metadataSource is a testSource with document properties.
+type metadataSource struct {
+	*testSource
+}
+
+func (metadataSource) Creator() string     { return "Jane Doe" }
+func (metadataSource) Created() time.Time  { return time.Date(2021, 2, 16, 0, 0, 0, 0, time.UTC) }
+func (metadataSource) Modified() time.Time { return time.Time{} }
+func (metadataSource) Application() string { return "test" }
+
+// statsMetadataSource is a metadataSource with sheet statistics.
+type statsMetadataSource struct {
+	metadataSource
+}
+
+func (statsMetadataSource) SheetStats() ([]SheetStats, error) {
+	return []SheetStats{{Name: "Sheet1", EstimatedRows: 10}}, nil
+}
+
+func TestSetMaxConcurrentMetadata(t *testing.T) {
+	src := srcTable
+	t.Cleanup(func() {
+		srcTable = src
+		SetMaxConcurrent(0)
+	})
+	srcTable = nil
+	Register("limited", 1, func(filename string) (Source, error) {
+		ms := metadataSource{&testSource{names: []string{filename}}}
+		if filename == "stats" {
+			return statsMetadataSource{ms}, nil
+		}
+		return ms, nil
+	})
+
+	SetMaxConcurrent(1)
+	for _, fn := range []string{"meta", "stats"} {
+		s, err := Open(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := s.(SourceMetadata); ok {
+			t.Errorf("%s: expected the wrapped Source, got %T", fn, s)
+		}
+		m, ok := Unwrap(s).(SourceMetadata)
+		if !ok {
+			t.Fatalf("%s: expected a SourceMetadata, got %T", fn, s)
+		}
+		if m.Creator() != "Jane Doe" || m.Application() != "test" || m.Created().Year() != 2021 {
+			t.Errorf("%s: unexpected metadata %q %q %v", fn, m.Creator(), m.Application(), m.Created())
+		}
+		if _, ok = Unwrap(s).(StatsSource); ok != (fn == "stats") {
+			t.Errorf("%s: got StatsSource %v", fn, ok)
+		}
+		if st, ok := Unwrap(s).(StatsSource); ok {
+			if stats, _ := st.SheetStats(); len(stats) != 1 || stats[0].EstimatedRows != 10 {
+				t.Errorf("%s: unexpected stats %+v", fn, stats)
+			}
+		}
+		if names, _ := Unwrap(s).List(); len(names) != 1 || names[0] != fn {
+			t.Errorf("%s: unwrapped source has sheets %v", fn, names)
+		}
+		// the single slot is released so the next open does not block
+		s.Close()
+	}
+}
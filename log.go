@@ -0,0 +1,45 @@
+package grate
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger which receives debug output from grate and
+// the registered formats. Messages are logged at slog.LevelDebug, so the
+// logger's handler controls whether they are kept. Pass nil to disable.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+var (
+	discardLogger = slog.New(discardHandler{})
+	stderrLogger  = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+)
+
+// Logger returns the logger set by SetLogger. If none is set, it returns
+// a logger writing to stderr when the deprecated Debug flag is true, and a
+// logger which discards all output otherwise.
+//
+// Sources opened with the WithDebugLogger option log to their own logger
+// instead.
+func Logger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	if Debug {
+		return stderrLogger
+	}
+	return discardLogger
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
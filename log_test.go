@@ -0,0 +1,57 @@
+package grate
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	defer SetLogger(nil)
+
+	if Logger().Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("expected debug output to be disabled by default")
+	}
+
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	if _, err := Open("testdata/missing.bin"); err != ErrUnknownFormat {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+	Logger().Debug("test message", "key", "value")
+	if !strings.Contains(buf.String(), "test message") || !strings.Contains(buf.String(), "key=value") {
+		t.Fatalf("unexpected log output %q", buf.String())
+	}
+}
+
+func TestWithDebugLogger(t *testing.T) {
+	defer SetLogger(nil)
+
+	var global, local bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&global, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l := slog.New(slog.NewTextHandler(&local, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if got := ParseOptions().Logger(); got != Logger() {
+		t.Fatal("expected the global logger without WithDebugLogger")
+	}
+	if got := ParseOptions(WithDebugLogger(l)).Logger(); got != l {
+		t.Fatal("expected the WithDebugLogger logger")
+	}
+
+	src := srcTable
+	t.Cleanup(func() { srcTable = src })
+	srcTable = []*srcOpenTab{{name: "never", pri: 1, op: func(string) (Source, error) {
+		return nil, ErrNotInFormat
+	}}}
+	if _, err := OpenWithOptions("testdata/missing.bin", WithDebugLogger(l)); err != ErrUnknownFormat {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+	if !strings.Contains(local.String(), "file is not in format") {
+		t.Fatalf("expected output on the source logger, got %q", local.String())
+	}
+	if strings.Contains(global.String(), "file is not in format") {
+		t.Fatalf("unexpected output on the global logger %q", global.String())
+	}
+}
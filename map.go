@@ -0,0 +1,24 @@
+package grate
+
+// Map returns a Collection whose Strings() passes each value of c through
+// fn, along with its zero-based row and column index. All other methods
+// (including Scan) delegate to c unchanged.
+func Map(c Collection, fn func(row int, col int, value string) string) Collection {
+	return &mapCollection{Collection: c, fn: fn}
+}
+
+type mapCollection struct {
+	Collection
+	fn func(row int, col int, value string) string
+}
+
+// Strings extracts the mapped values from the current record into a list of strings.
+func (m *mapCollection) Strings() []string {
+	vals := m.Collection.Strings()
+	res := make([]string, len(vals))
+	row := m.Collection.Row()
+	for i, v := range vals {
+		res[i] = m.fn(row, i, v)
+	}
+	return res
+}
@@ -0,0 +1,33 @@
+package grate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	src := newTestCollection([]string{" a ", "b"}, []string{"NULL", " c"})
+	c := Map(src, func(row, col int, v string) string {
+		v = strings.TrimSpace(v)
+		if v == "NULL" {
+			return ""
+		}
+		if row == 1 && col == 1 {
+			return strings.ToUpper(v)
+		}
+		return v
+	})
+
+	var got [][]string
+	for c.Next() {
+		got = append(got, c.Strings())
+	}
+	want := [][]string{{"a", "b"}, {"", "C"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, expected %v", got, want)
+	}
+	if src.rows[1][0] != "NULL" {
+		t.Fatal("underlying collection was modified")
+	}
+}
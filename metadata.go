@@ -0,0 +1,21 @@
+package grate
+
+import "time"
+
+// SourceMetadata is implemented by Sources which record the provenance of
+// the file, such as the document properties of spreadsheets. Check for it
+// with a type assertion. Information which is not stored in the file is
+// returned as the empty string or the zero time.
+type SourceMetadata interface {
+	// Creator returns the name of the author of the file.
+	Creator() string
+
+	// Created returns the time the file was created.
+	Created() time.Time
+
+	// Modified returns the time the file was last saved.
+	Modified() time.Time
+
+	// Application returns the name of the application which wrote the file.
+	Application() string
+}
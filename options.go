@@ -0,0 +1,250 @@
+package grate
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Option configures how a Source is opened by OpenWithOptions. Registered
+// openers type-assert the options they understand and ignore the rest.
+type Option interface {
+	// OptionName returns a short name for the option, used for logging.
+	OptionName() string
+}
+
+// OpenOptionsFunc defines a Source's instantiation function that accepts options.
+// It should return ErrNotInFormat immediately if filename is not of the correct file type.
+type OpenOptionsFunc func(filename string, opts ...Option) (Source, error)
+
+// MaxMemoryBytesOption limits the (uncompressed) size of the data a Source may load.
+type MaxMemoryBytesOption int64
+
+// OptionName implements the Option interface.
+func (MaxMemoryBytesOption) OptionName() string { return "MaxMemoryBytes" }
+
+// MaxMemoryBytes limits the size of the data a Source may load into memory.
+// Formats that exceed the limit return ErrTooLarge.
+func MaxMemoryBytes(n int64) Option { return MaxMemoryBytesOption(n) }
+
+// StrictModeOption selects strict (instead of lenient) parsing.
+type StrictModeOption bool
+
+// OptionName implements the Option interface.
+func (StrictModeOption) OptionName() string { return "StrictMode" }
+
+// StrictMode selects strict parsing, where formats return errors for
+// malformed content instead of making a best effort.
+func StrictMode(enabled bool) Option { return StrictModeOption(enabled) }
+
+// DateTimezoneOption sets the location used to interpret date cells.
+type DateTimezoneOption struct {
+	Location *time.Location
+}
+
+// OptionName implements the Option interface.
+func (DateTimezoneOption) OptionName() string { return "DateTimezone" }
+
+// DateTimezone sets the location used to interpret date cells, which are
+// stored without timezone information. The default is UTC.
+func DateTimezone(loc *time.Location) Option { return DateTimezoneOption{Location: loc} }
+
+// ErrorHandlerOption sets the callback for row-level parse errors.
+type ErrorHandlerOption struct {
+	Handler func(row int, err error) bool
+}
+
+// OptionName implements the Option interface.
+func (ErrorHandlerOption) OptionName() string { return "ErrorHandler" }
+
+// WithErrorHandler sets a callback invoked with the zero-based row index
+// and the first error of each row that cannot be parsed, such as a cell
+// referring to a missing shared string or a truncated cell record. If fn
+// returns true the row is skipped and parsing continues, otherwise parsing
+// stops with the error. Without a handler, parsing stops at the first error.
+func WithErrorHandler(fn func(row int, err error) bool) Option {
+	return ErrorHandlerOption{Handler: fn}
+}
+
+// PasswordOption sets the password used to decrypt encrypted files.
+type PasswordOption string
+
+// OptionName implements the Option interface.
+func (PasswordOption) OptionName() string { return "Password" }
+
+// WithPassword sets the password used to decrypt encrypted files. Formats
+// which do not support encryption ignore it.
+func WithPassword(password string) Option { return PasswordOption(password) }
+
+// DebugLoggerOption sets the logger for a single Source.
+type DebugLoggerOption struct {
+	Logger *slog.Logger
+}
+
+// OptionName implements the Option interface.
+func (DebugLoggerOption) OptionName() string { return "DebugLogger" }
+
+// WithDebugLogger sends the log output of the Source being opened to l,
+// instead of the process-wide logger set by SetLogger. Use it to debug a
+// single file in a process which opens many files concurrently.
+func WithDebugLogger(l *slog.Logger) Option { return DebugLoggerOption{Logger: l} }
+
+// MaxSheetsOption limits the number of sheets available from a Source.
+type MaxSheetsOption int
+
+// OptionName implements the Option interface.
+func (MaxSheetsOption) OptionName() string { return "MaxSheets" }
+
+// WithMaxSheets limits a Source to its first n sheets, as a safety valve
+// for files with pathologically many sheets. List returns only the first n
+// sheets (logging a warning), and Get returns ErrSheetNotFound for the rest.
+// Hidden sheets count towards the limit too, so that it bounds every sheet
+// available from Get; List may then return fewer than n sheets for formats
+// which do not list hidden sheets.
+func WithMaxSheets(n int) Option { return MaxSheetsOption(n) }
+
+// Locale describes the conventions used to format numbers and currency
+// amounts as strings. The commonxl package provides pre-built locales.
+type Locale struct {
+	// DecimalSeparator separates the whole and fractional parts, e.g. ".".
+	DecimalSeparator string
+
+	// ThousandsSeparator separates groups of digits, e.g. ",".
+	ThousandsSeparator string
+
+	// CurrencySymbol replaces the "$" of the built-in currency formats.
+	CurrencySymbol string
+
+	// CurrencyAfter places the currency symbol after the amount, separated
+	// by a space, instead of directly before it.
+	CurrencyAfter bool
+}
+
+// LocaleOption sets the conventions used to format numbers as strings.
+type LocaleOption struct {
+	Locale *Locale
+}
+
+// OptionName implements the Option interface.
+func (LocaleOption) OptionName() string { return "Locale" }
+
+// WithLocale formats the string values of numeric cells using the
+// conventions of loc, e.g. commonxl.LocaleDE, instead of the default
+// English conventions. Only the string representation of values is
+// affected. Formats without number formats ignore it.
+func WithLocale(loc *Locale) Option { return LocaleOption{Locale: loc} }
+
+// Options collects the values of the built-in options, for use by
+// registered openers.
+type Options struct {
+	MaxMemoryBytes int64
+	Strict         bool
+	DateTimezone   *time.Location
+	ErrorHandler   func(row int, err error) bool
+	Password       string
+	DebugLogger    *slog.Logger
+	MaxSheets      int
+	Locale         *Locale
+}
+
+// ParseOptions collects the values of the built-in options from opts.
+// Unrecognised options are ignored.
+func ParseOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case MaxMemoryBytesOption:
+			o.MaxMemoryBytes = int64(v)
+		case StrictModeOption:
+			o.Strict = bool(v)
+		case DateTimezoneOption:
+			o.DateTimezone = v.Location
+		case ErrorHandlerOption:
+			o.ErrorHandler = v.Handler
+		case PasswordOption:
+			o.Password = string(v)
+		case DebugLoggerOption:
+			o.DebugLogger = v.Logger
+		case MaxSheetsOption:
+			o.MaxSheets = int(v)
+		case LocaleOption:
+			o.Locale = v.Locale
+		}
+	}
+	return o
+}
+
+// ExceedsMemory returns true if n bytes is over the configured memory limit.
+func (o Options) ExceedsMemory(n int64) bool {
+	return o.MaxMemoryBytes > 0 && n > o.MaxMemoryBytes
+}
+
+// ExceedsSheets returns true if n sheets is over the configured sheet limit.
+func (o Options) ExceedsSheets(n int) bool {
+	return o.MaxSheets > 0 && n > o.MaxSheets
+}
+
+// Logger returns the logger configured with WithDebugLogger, or the
+// process-wide Logger if there is none.
+func (o Options) Logger() *slog.Logger {
+	if o.DebugLogger != nil {
+		return o.DebugLogger
+	}
+	return Logger()
+}
+
+// SkipRowError reports whether the row with a parse error should be
+// skipped, as decided by the configured error handler. If it returns false,
+// the error should be returned.
+func (o Options) SkipRowError(row int, err error) bool {
+	return o.ErrorHandler != nil && o.ErrorHandler(row, err)
+}
+
+var optTable = make(map[string]OpenOptionsFunc)
+
+// RegisterOptions registers an options-aware opener for the named format. When
+// using OpenWithOptions, it is used in place of the format's OpenFunc (and at
+// the same priority), so the format must also be registered with Register.
+func RegisterOptions(name string, opener OpenOptionsFunc) error {
+	Logger().Debug("registering format with options support", "format", name)
+	tableMu.Lock()
+	defer tableMu.Unlock()
+	if _, ok := optTable[name]; ok {
+		return errors.New("grate: options opener already registered for " + name)
+	}
+	optTable[name] = opener
+	return nil
+}
+
+// OpenWithOptions opens a tabular data file with the given options and returns
+// a Source for accessing it's contents. Formats without options support are
+// opened as with Open. The limit set by SetMaxConcurrent applies.
+func OpenWithOptions(filename string, opts ...Option) (Source, error) {
+	log := ParseOptions(opts...).Logger()
+	release := acquireOpen()
+	for _, o := range sources() {
+		var src Source
+		var err error
+		tableMu.RLock()
+		oo, ok := optTable[o.name]
+		tableMu.RUnlock()
+		if ok {
+			src, err = oo(filename, opts...)
+		} else {
+			src, err = o.op(filename)
+		}
+		if err == nil || !errors.Is(err, ErrNotInFormat) {
+			return limitOpen(release, src, err)
+		}
+		log.Debug("file is not in format", "filename", filename, "format", o.name)
+	}
+	return limitOpen(release, nil, ErrUnknownFormat)
+}
+
+// OpenWithPassword opens a tabular data file which may be encrypted, using
+// password to decrypt it. It is a shorthand for OpenWithOptions with the
+// WithPassword option. If the password is incorrect, the error wraps
+// ErrWrongPassword.
+func OpenWithPassword(filename, password string) (Source, error) {
+	return OpenWithOptions(filename, WithPassword(password))
+}
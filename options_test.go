@@ -0,0 +1,51 @@
+package grate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseOptions(t *testing.T) {
+	loc := time.FixedZone("test", 3600)
+	o := ParseOptions(MaxMemoryBytes(1024), StrictMode(true), DateTimezone(loc))
+	if o.MaxMemoryBytes != 1024 || !o.Strict || o.DateTimezone != loc {
+		t.Fatalf("unexpected options %+v", o)
+	}
+	if !o.ExceedsMemory(1025) || o.ExceedsMemory(1024) {
+		t.Fatal("unexpected memory limit check")
+	}
+	if (Options{}).ExceedsMemory(1 << 40) {
+		t.Fatal("zero limit should be unlimited")
+	}
+
+	if o.SkipRowError(1, ErrNotInFormat) {
+		t.Fatal("expected errors to not be skipped without a handler")
+	}
+	var rows []int
+	o = ParseOptions(WithErrorHandler(func(row int, err error) bool {
+		rows = append(rows, row)
+		return row > 1
+	}))
+	if o.SkipRowError(1, ErrNotInFormat) || !o.SkipRowError(2, ErrNotInFormat) || len(rows) != 2 {
+		t.Fatalf("unexpected error handler calls %v", rows)
+	}
+
+	if o = ParseOptions(WithPassword("s3cret")); o.Password != "s3cret" {
+		t.Fatalf("unexpected password %q", o.Password)
+	}
+
+	if o = ParseOptions(WithMaxSheets(3)); !o.ExceedsSheets(4) || o.ExceedsSheets(3) {
+		t.Fatalf("unexpected sheet limit check %+v", o)
+	}
+}
+
+func TestErrWrongPassword(t *testing.T) {
+	err := WrapErr(errors.New("xls: incorrect password"), ErrWrongPassword)
+	if !errors.Is(err, ErrWrongPassword) || !errors.Is(err, ErrEncrypted) {
+		t.Fatalf("expected %v to match ErrWrongPassword and ErrEncrypted", err)
+	}
+	if errors.Is(ErrEncrypted, ErrWrongPassword) {
+		t.Fatal("ErrEncrypted should not match ErrWrongPassword")
+	}
+}
@@ -0,0 +1,207 @@
+package grate
+
+// Filter returns a Collection containing only the records of c for which
+// keep returns true when passed their string values.
+func Filter(c Collection, keep func(values []string) bool) Collection {
+	return &filterCollection{Collection: c, keep: keep, row: -1}
+}
+
+type filterCollection struct {
+	Collection
+	keep func(values []string) bool
+	row  int
+}
+
+// Next advances to the next record that passes the filter.
+func (f *filterCollection) Next() bool {
+	for f.Collection.Next() {
+		if f.keep(f.Collection.Strings()) {
+			f.row++
+			return true
+		}
+	}
+	return false
+}
+
+// Row returns the zero-based index of the current record after filtering.
+func (f *filterCollection) Row() int {
+	return f.row
+}
+
+// Skip returns a Collection which omits the first n records of c.
+func Skip(c Collection, n int) Collection {
+	return &skipCollection{Collection: c, n: n, row: -1}
+}
+
+type skipCollection struct {
+	Collection
+	n   int
+	row int
+}
+
+// Next advances to the next record, skipping records on the first call.
+func (s *skipCollection) Next() bool {
+	for ; s.n > 0; s.n-- {
+		if !s.Collection.Next() {
+			return false
+		}
+	}
+	if !s.Collection.Next() {
+		return false
+	}
+	s.row++
+	return true
+}
+
+// Row returns the zero-based index of the current record after skipping.
+func (s *skipCollection) Row() int {
+	return s.row
+}
+
+// Limit returns a Collection which stops after the first n records of c.
+func Limit(c Collection, n int) Collection {
+	return &limitCollection{Collection: c, n: n, row: -1}
+}
+
+type limitCollection struct {
+	Collection
+	n   int
+	row int
+}
+
+// Next advances to the next record, until the limit is reached.
+func (l *limitCollection) Next() bool {
+	if l.row+1 >= l.n || !l.Collection.Next() {
+		return false
+	}
+	l.row++
+	return true
+}
+
+// Row returns the zero-based index of the current record.
+func (l *limitCollection) Row() int {
+	return l.row
+}
+
+// SelectColumns returns a Collection with only the given zero-based
+// columns of c, in the order given. Columns beyond the end of a record are
+// blank.
+func SelectColumns(c Collection, columns []int) Collection {
+	return &columnsCollection{Collection: c, columns: columns}
+}
+
+// columnsCollection returns only the selected columns of each record,
+// with blank values for columns beyond the end of the record.
+type columnsCollection struct {
+	Collection
+	columns []int
+}
+
+// selectColumns returns the values of vals in the selected columns.
+func selectColumns(vals []string, columns []int) []string {
+	res := make([]string, len(columns))
+	for i, col := range columns {
+		if col < len(vals) {
+			res[i] = vals[col]
+		}
+	}
+	return res
+}
+
+// Strings extracts the selected values from the current record.
+func (c *columnsCollection) Strings() []string {
+	return selectColumns(c.Collection.Strings(), c.columns)
+}
+
+// Types extracts the data types of the selected values.
+func (c *columnsCollection) Types() []string {
+	res := selectColumns(c.Collection.Types(), c.columns)
+	for i, t := range res {
+		if t == "" {
+			res[i] = "blank"
+		}
+	}
+	return res
+}
+
+// Formats extracts the format codes of the selected values.
+func (c *columnsCollection) Formats() []string {
+	return selectColumns(c.Collection.Formats(), c.columns)
+}
+
+// Values extracts the native values of the selected columns.
+func (c *columnsCollection) Values() []interface{} {
+	vals := c.Collection.Values()
+	res := make([]interface{}, len(c.columns))
+	for i, col := range c.columns {
+		res[i] = ""
+		if col < len(vals) {
+			res[i] = vals[col]
+		}
+	}
+	return res
+}
+
+// Width returns the number of selected columns.
+func (c *columnsCollection) Width() int {
+	return len(c.columns)
+}
+
+// Scan extracts the selected values into the provided arguments.
+// Values are parsed from their string representation.
+func (c *columnsCollection) Scan(args ...interface{}) error {
+	return scanStrings(c.Strings(), args)
+}
+
+// Pipeline is a reusable chain of Collection transformations, built with
+// a fluent API and applied with Apply:
+//
+//	p := grate.NewPipeline().Skip(1).Filter(nonBlank).Map(trim).Limit(1000)
+//	c = p.Apply(c)
+type Pipeline struct {
+	steps []func(Collection) Collection
+}
+
+// NewPipeline returns an empty Pipeline, which returns collections unchanged.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Filter adds a step keeping only the records for which keep returns true.
+func (p *Pipeline) Filter(keep func(values []string) bool) *Pipeline {
+	return p.add(func(c Collection) Collection { return Filter(c, keep) })
+}
+
+// Map adds a step passing each value through fn (see Map).
+func (p *Pipeline) Map(fn func(row int, col int, value string) string) *Pipeline {
+	return p.add(func(c Collection) Collection { return Map(c, fn) })
+}
+
+// Limit adds a step stopping after n records.
+func (p *Pipeline) Limit(n int) *Pipeline {
+	return p.add(func(c Collection) Collection { return Limit(c, n) })
+}
+
+// Skip adds a step omitting the first n records.
+func (p *Pipeline) Skip(n int) *Pipeline {
+	return p.add(func(c Collection) Collection { return Skip(c, n) })
+}
+
+// SelectColumns adds a step keeping only the given columns.
+func (p *Pipeline) SelectColumns(columns []int) *Pipeline {
+	return p.add(func(c Collection) Collection { return SelectColumns(c, columns) })
+}
+
+func (p *Pipeline) add(step func(Collection) Collection) *Pipeline {
+	p.steps = append(p.steps, step)
+	return p
+}
+
+// Apply returns a Collection applying each step of the pipeline, in order,
+// to the records of c.
+func (p *Pipeline) Apply(c Collection) Collection {
+	for _, step := range p.steps {
+		c = step(c)
+	}
+	return c
+}
@@ -0,0 +1,58 @@
+package grate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	nonBlank := func(values []string) bool {
+		return strings.Join(values, "") != ""
+	}
+	upper := func(row, col int, value string) string {
+		return strings.ToUpper(value)
+	}
+	p := NewPipeline().Skip(1).Filter(nonBlank).Map(upper).Limit(2)
+
+	for pass := 0; pass < 2; pass++ {
+		c := p.Apply(newTestCollection(
+			[]string{"header"},
+			[]string{"a"},
+			[]string{""},
+			[]string{"b"},
+			[]string{"c"},
+		))
+		var got []string
+		for c.Next() {
+			if c.Row() != len(got) {
+				t.Errorf("expected row %d, got %d", len(got), c.Row())
+			}
+			got = append(got, c.Strings()[0])
+		}
+		if want := []string{"A", "B"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("pass %d: got %q, expected %q", pass, got, want)
+		}
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	c := NewPipeline().SelectColumns([]int{2, 0, 5}).Apply(newTestCollection(
+		[]string{"a", "b", "c"},
+		[]string{"1", "2"},
+	))
+	var got [][]string
+	for c.Next() {
+		got = append(got, c.Strings())
+		if types := c.Types(); types[2] != "blank" {
+			t.Errorf("expected a blank type beyond the record, got %q", types)
+		}
+	}
+	want := [][]string{{"c", "a", ""}, {"", "1", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+	if c.Width() != 3 {
+		t.Errorf("expected width 3, got %d", c.Width())
+	}
+}
@@ -0,0 +1,45 @@
+// Package plugin loads grate formats from Go plugins. It is separate from
+// the grate package, as using the standard library plugin package links
+// cgo and the dynamic loader into every program.
+package plugin
+
+import (
+	"fmt"
+	goplugin "plugin"
+
+	"github.com/wubin1989/grate"
+)
+
+// Load opens the Go plugin at path and calls its GratePlugin function,
+// which should register the formats it provides (using grate.Register and
+// related functions). The plugin must export either
+//
+//	func GratePlugin() error
+//
+// or a variable of that function type. Plugins are only supported on some
+// platforms (and with cgo enabled), see the plugin package documentation;
+// elsewhere Load returns an error.
+func Load(path string) error {
+	p, err := goplugin.Open(path)
+	if err != nil {
+		return err
+	}
+	sym, err := p.Lookup("GratePlugin")
+	if err != nil {
+		return err
+	}
+	var fn func() error
+	switch v := sym.(type) {
+	case func() error:
+		fn = v
+	case *func() error:
+		fn = *v
+	default:
+		return fmt.Errorf("grate/plugin: %s: GratePlugin has type %T, expected func() error", path, sym)
+	}
+	if fn == nil {
+		return fmt.Errorf("grate/plugin: %s: GratePlugin is nil", path)
+	}
+	grate.Logger().Debug("loading plugin", "path", path)
+	return fn()
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wubin1989/grate"
+)
+
+func TestLoadMissing(t *testing.T) {
+	if err := Load(filepath.Join(t.TempDir(), "missing.so")); err == nil {
+		t.Fatal("expected an error loading a missing plugin")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a plugin")
+	}
+	fn := filepath.Join(t.TempDir(), "example.so")
+	out, err := exec.Command("go", "build", "-buildmode=plugin", "-o", fn, "./testdata/example").CombinedOutput()
+	if err != nil {
+		if bytes.Contains(out, []byte("not supported")) || bytes.Contains(out, []byte("cgo is not enabled")) {
+			t.Skipf("plugins are not supported on this platform: %s", out)
+		}
+		t.Fatalf("building the plugin failed: %v\n%s", err, out)
+	}
+	if err = Load(fn); err != nil {
+		// the plugin package returns this without cgo or on platforms
+		// without plugin support
+		if strings.Contains(err.Error(), "not implemented") {
+			t.Skipf("plugins are not supported on this platform: %v", err)
+		}
+		t.Fatal(err)
+	}
+	for _, name := range grate.ListFormats() {
+		if name == "example" {
+			return
+		}
+	}
+	t.Errorf("expected the example format to be registered, got %v", grate.ListFormats())
+}
@@ -0,0 +1,13 @@
+// Command example is a plugin registering the "example" format, used by
+// the tests of the plugin package.
+package main
+
+import "github.com/wubin1989/grate"
+
+func GratePlugin() error {
+	return grate.Register("example", 100, func(string) (grate.Source, error) {
+		return nil, grate.ErrNotInFormat
+	})
+}
+
+func main() {}
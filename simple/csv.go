@@ -2,38 +2,80 @@ package simple
 
 import (
 	"encoding/csv"
-	"os"
+	"io"
+	"io/fs"
 
 	"github.com/wubin1989/grate"
 )
 
 var _ = grate.Register("csv", 15, OpenCSV)
+var _ = grate.RegisterFile("csv", 15, fileOpener(func(filename string, f fs.File, o grate.Options) (grate.Source, error) {
+	return readCSV(filename, f, o, false)
+}))
+var _ = grate.RegisterOptions("csv", func(filename string, opts ...grate.Option) (grate.Source, error) {
+	rfc4180 := false
+	for _, o := range opts {
+		if _, ok := o.(rfc4180Option); ok {
+			rfc4180 = true
+		}
+	}
+	return openCSVWith(filename, grate.ParseOptions(opts...), rfc4180)
+})
+var _ = grate.RegisterDetect("csv", isText)
 
 // OpenCSV defines a Source's instantiation function.
 // It should return ErrNotInFormat immediately if filename is not of the correct file type.
+//
+// Records are read from the file as the collection is iterated.
 func OpenCSV(filename string) (grate.Source, error) {
-	f, err := os.Open(filename)
+	return openCSV(filename, grate.Options{})
+}
+
+// rfc4180Option selects the strict RFC 4180 CSV parser.
+type rfc4180Option struct{}
+
+// OptionName implements the grate.Option interface.
+func (rfc4180Option) OptionName() string { return "RFC4180" }
+
+// WithRFC4180 parses CSV files strictly according to RFC 4180: fields
+// containing quotes must be quoted, quotes are escaped by doubling them,
+// quoted fields may span multiple lines, and a quoted field must be followed
+// by a comma or line ending. Records are still read as the collection is
+// iterated.
+func WithRFC4180() grate.Option { return rfc4180Option{} }
+
+func openCSV(filename string, o grate.Options) (grate.Source, error) {
+	return openCSVWith(filename, o, false)
+}
+
+// openCSVWith supports the grate.MaxMemoryBytes and grate.StrictMode options.
+// In strict mode, all records must have the same number of fields.
+func openCSVWith(filename string, o grate.Options, rfc4180 bool) (grate.Source, error) {
+	f, err := openFile(filename, o)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	t := &simpleFile{
-		filename: filename,
-		iterRow:  -1,
-	}
+	return readCSV(filename, f, o, rfc4180)
+}
 
-	s := csv.NewReader(f)
-	s.FieldsPerRecord = -1
+// readCSV reads a CSV file from f, which is closed if it is not in the format.
+func readCSV(filename string, f fs.File, o grate.Options, rfc4180 bool) (grate.Source, error) {
+	t := newStreamFile(filename, f, func(r io.Reader) rowReader {
+		if rfc4180 {
+			return newRFC4180Reader(r, o.Strict)
+		}
+		s := csv.NewReader(r)
+		s.FieldsPerRecord = -1
+		if o.Strict {
+			s.FieldsPerRecord = 0
+		}
+		return s.Read
+	})
 
-	total := 0
-	ncols := make(map[int]int)
-	rec, err := s.Read()
-	for ; err == nil; rec, err = s.Read() {
-		ncols[len(rec)]++
-		total++
-		t.rows = append(t.rows, rec)
-	}
-	if err != nil {
+	err := t.readProbe(probeRows)
+	total := len(t.probe)
+	if err != nil && err != io.EOF {
+		t.Close()
 		switch perr := err.(type) {
 		case *csv.ParseError:
 			return nil, grate.WrapErr(perr, grate.ErrNotInFormat)
@@ -46,6 +88,10 @@ func OpenCSV(filename string) (grate.Source, error) {
 	}
 
 	// kinda arbitrary metrics for detecting CSV
+	ncols := make(map[int]int)
+	for _, r := range t.probe {
+		ncols[len(r)]++
+	}
 	looksGood := 0
 	for c, n := range ncols {
 		if c <= 1 {
@@ -59,7 +105,8 @@ func OpenCSV(filename string) (grate.Source, error) {
 		}
 	}
 	if looksGood == 1 {
-		return t, grate.ErrNotInFormat
+		t.Close()
+		return nil, grate.ErrNotInFormat
 	}
 
 	return t, nil
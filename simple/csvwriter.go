@@ -0,0 +1,116 @@
+package simple
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+// csvWriter is a grate.WritableSource creating CSV files.
+type csvWriter struct {
+	filename string
+	files    []*os.File
+	writers  []*csv.Writer
+	saved    bool
+
+	// filenames already created, to avoid overwriting them
+	used map[string]bool
+}
+
+// CreateCSV returns a grate.WritableSource which writes CSV files. The
+// first collection created is written to filename, and any further
+// collections to separate files named after the collection, e.g.
+// "out_Sheet2.csv" for filename "out.csv". Characters which are unsafe in
+// filenames are replaced by "_", and a number is added if two collections
+// would share a file (e.g. "out_a_b_2.csv" for "a/b" after "a_b"). Call
+// Save to finish writing.
+func CreateCSV(filename string) (grate.WritableSource, error) {
+	if filename == "" {
+		return nil, errors.New("grate/simple: CreateCSV requires a filename")
+	}
+	return &csvWriter{filename: filename, used: make(map[string]bool)}, nil
+}
+
+var unsafeFilename = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// Create adds a new CSV file for the named collection.
+func (w *csvWriter) Create(sheetName string) (grate.WritableCollection, error) {
+	if w.saved {
+		return nil, errors.New("grate/simple: CSV files already saved")
+	}
+	fn := w.filename
+	if len(w.files) > 0 {
+		ext := filepath.Ext(fn)
+		base := strings.TrimSuffix(fn, ext) + "_" + unsafeFilename.ReplaceAllString(sheetName, "_")
+		fn = base + ext
+		for i := 2; w.used[fn]; i++ {
+			fn = base + "_" + strconv.Itoa(i) + ext
+		}
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		return nil, err
+	}
+	w.used[fn] = true
+	cw := csv.NewWriter(f)
+	w.files = append(w.files, f)
+	w.writers = append(w.writers, cw)
+	return &csvCollectionWriter{w: cw}, nil
+}
+
+// Save flushes and closes all CSV files, returning the first error.
+func (w *csvWriter) Save() error {
+	if w.saved {
+		return nil
+	}
+	w.saved = true
+	var res error
+	for i, cw := range w.writers {
+		cw.Flush()
+		if err := cw.Error(); err != nil && res == nil {
+			res = err
+		}
+		if err := w.files[i].Close(); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// csvCollectionWriter writes the records of a single CSV file.
+type csvCollectionWriter struct {
+	w *csv.Writer
+}
+
+// WriteRow writes a record of string values.
+func (c *csvCollectionWriter) WriteRow(values []string) error {
+	return c.w.Write(values)
+}
+
+// WriteTypedRow writes a record of native values formatted as strings:
+// nil is written as an empty field and dates in RFC 3339 format.
+func (c *csvCollectionWriter) WriteTypedRow(values []interface{}) error {
+	row := make([]string, len(values))
+	for i, v := range values {
+		switch x := v.(type) {
+		case nil:
+		case string:
+			row[i] = x
+		case float64:
+			row[i] = strconv.FormatFloat(x, 'g', -1, 64)
+		case time.Time:
+			row[i] = x.Format(time.RFC3339)
+		default:
+			row[i] = fmt.Sprint(x)
+		}
+	}
+	return c.w.Write(row)
+}
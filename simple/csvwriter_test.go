@@ -0,0 +1,71 @@
+package simple
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+func TestCreateCSV(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.tsv")
+	if err := os.WriteFile(in, []byte("a\tb,c\n1\t\"2\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := OpenTSV(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	out := filepath.Join(dir, "out.csv")
+	dst, err := CreateCSV(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = grate.Copy(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != "a,\"b,c\"\n1,\"\"\"2\"\"\"\n" {
+		t.Errorf("unexpected CSV output %q", data)
+	}
+
+	// later collections are written to separate files
+	dst, _ = CreateCSV(out)
+	dst.Create("first")
+	w, err := dst.Create("Sheet 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteTypedRow([]interface{}{int64(1), 1.5, true, nil, time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)})
+	if err = dst.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "out_Sheet_2.csv"))
+	if string(data) != "1,1.5,true,,2021-03-04T05:06:07Z\n" {
+		t.Errorf("unexpected typed CSV output %q", data)
+	}
+
+	// names which are the same once sanitised are not overwritten
+	dst, _ = CreateCSV(out)
+	dst.Create("first")
+	for _, name := range []string{"a_b", "a/b"} {
+		w, err = dst.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteRow([]string{name})
+	}
+	if err = dst.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "out_a_b.csv"))
+	data2, _ := os.ReadFile(filepath.Join(dir, "out_a_b_2.csv"))
+	if string(data) != "a_b\n" || string(data2) != "a/b\n" {
+		t.Errorf("unexpected CSV outputs %q and %q", data, data2)
+	}
+}
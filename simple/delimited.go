@@ -0,0 +1,131 @@
+package simple
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/wubin1989/grate"
+)
+
+// pipe and semicolon separated files are only detected by their extension,
+// since most files would also parse as single-column CSV or TSV.
+var _ = grate.Register("psv", 9, byExtension(".psv", OpenPSV))
+var _ = grate.Register("ssv", 9, byExtension(".ssv", OpenSSV))
+var _ = grate.RegisterFile("psv", 9, fileByExtension(".psv", '|'))
+var _ = grate.RegisterFile("ssv", 9, fileByExtension(".ssv", ';'))
+
+// extDelimiters are the field delimiters of the extension-based formats,
+// guarded by extMu. The CSV and TSV extensions are included to prevent
+// registering them again.
+var extMu sync.Mutex
+var extDelimiters = map[string]rune{
+	".csv": ',',
+	".tsv": '\t',
+	".psv": '|',
+	".ssv": ';',
+}
+
+// RegisterExtension registers a format for files with the extension ext
+// (e.g. ".dsv"), with fields separated by delimiter, which must be a single
+// character. The files are then opened by grate.Open as with OpenDelimited.
+// Registering an extension twice returns an error.
+func RegisterExtension(ext, delimiter string) error {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if ext == "." {
+		return errors.New("grate/simple: empty extension")
+	}
+	r, n := utf8.DecodeRuneInString(delimiter)
+	if n == 0 || n != len(delimiter) || !validDelimiter(r) {
+		return errors.New("grate/simple: invalid field delimiter")
+	}
+	extMu.Lock()
+	defer extMu.Unlock()
+	if _, ok := extDelimiters[ext]; ok {
+		return errors.New("grate/simple: extension already registered: " + ext)
+	}
+	extDelimiters[ext] = r
+	if err := grate.RegisterFile(ext[1:], 9, fileByExtension(ext, r)); err != nil {
+		return err
+	}
+	return grate.Register(ext[1:], 9, byExtension(ext, func(filename string) (grate.Source, error) {
+		return OpenDelimited(filename, r)
+	}))
+}
+
+// byExtension only opens files with the given extension.
+func byExtension(ext string, op grate.OpenFunc) grate.OpenFunc {
+	return func(filename string) (grate.Source, error) {
+		if !strings.EqualFold(filepath.Ext(filename), ext) {
+			return nil, grate.ErrNotInFormat
+		}
+		return op(filename)
+	}
+}
+
+// fileByExtension only opens fs.Files with the given extension, with fields
+// separated by delimiter.
+func fileByExtension(ext string, delimiter rune) grate.OpenFileFunc {
+	return fileOpener(func(filename string, f fs.File, o grate.Options) (grate.Source, error) {
+		if !strings.EqualFold(filepath.Ext(filename), ext) {
+			return nil, grate.ErrNotInFormat
+		}
+		return readDelimited(filename, f, delimiter)
+	})
+}
+
+// OpenPSV opens a pipe ('|') separated file.
+func OpenPSV(filename string) (grate.Source, error) {
+	return OpenDelimited(filename, '|')
+}
+
+// OpenSSV opens a semicolon (';') separated file.
+func OpenSSV(filename string) (grate.Source, error) {
+	return OpenDelimited(filename, ';')
+}
+
+// OpenDelimited opens a file of records separated by newlines, and fields
+// separated by delimiter. Fields may be quoted as in CSV files. Unlike the
+// auto-detected formats, the contents are not checked for consistency.
+//
+// Records are read from the file as the collection is iterated.
+func OpenDelimited(filename string, delimiter rune) (grate.Source, error) {
+	if !validDelimiter(delimiter) {
+		return nil, errors.New("grate/simple: invalid field delimiter")
+	}
+	f, err := openFile(filename, grate.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return readDelimited(filename, f, delimiter)
+}
+
+// readDelimited reads a delimited file from f, which is closed on errors.
+func readDelimited(filename string, f fs.File, delimiter rune) (grate.Source, error) {
+	t := newStreamFile(filename, f, func(r io.Reader) rowReader {
+		s := csv.NewReader(r)
+		s.Comma = delimiter
+		s.FieldsPerRecord = -1
+		return s.Read
+	})
+
+	err := t.readProbe(1)
+	if err != nil && err != io.EOF {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// validDelimiter returns true if r can be used to separate fields.
+func validDelimiter(r rune) bool {
+	return r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
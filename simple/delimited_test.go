@@ -0,0 +1,108 @@
+package simple
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/wubin1989/grate"
+)
+
+func TestOpenDelimited(t *testing.T) {
+	dir := t.TempDir()
+	for name, sep := range map[string]string{"data.psv": "|", "data.ssv": ";"} {
+		data := "a" + sep + "b" + sep + "c\n1" + sep + `"x` + sep + `y"` + sep + "3\n"
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// dispatched by extension
+		src, err := grate.Open(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, _ := src.Get(name)
+		var rows [][]string
+		for c.Next() {
+			rows = append(rows, c.Strings())
+		}
+		src.Close()
+		expect := [][]string{{"a", "b", "c"}, {"1", "x" + sep + "y", "3"}}
+		if !reflect.DeepEqual(rows, expect) {
+			t.Errorf("%s: got %q, expected %q", name, rows, expect)
+		}
+	}
+
+	if _, err := OpenDelimited(filepath.Join(dir, "data.psv"), '"'); err == nil {
+		t.Error("expected an invalid delimiter error")
+	}
+}
+
+func TestRegisterExtensionConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		ext := fmt.Sprintf("cc%dsv", i)
+		t.Cleanup(func() {
+			grate.Deregister(ext)
+			extMu.Lock()
+			delete(extDelimiters, "."+ext)
+			extMu.Unlock()
+		})
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = RegisterExtension(ext, ":")
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRegisterExtension(t *testing.T) {
+	t.Cleanup(func() {
+		grate.Deregister("colsv")
+		extMu.Lock()
+		delete(extDelimiters, ".colsv")
+		extMu.Unlock()
+	})
+	if err := RegisterExtension("colsv", ":"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ext := range []string{".colsv", ".CSV", "psv"} {
+		if err := RegisterExtension(ext, ":"); err == nil {
+			t.Errorf("expected a duplicate registration error for %s", ext)
+		}
+	}
+	for _, sep := range []string{"", "::", `"`} {
+		if err := RegisterExtension(".other", sep); err == nil {
+			t.Errorf("expected an invalid delimiter error for %q", sep)
+		}
+	}
+
+	fn := filepath.Join(t.TempDir(), "data.colsv")
+	if err := os.WriteFile(fn, []byte("a:b\n1:2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := grate.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	c, _ := src.Get("data.colsv")
+	var rows [][]string
+	for c.Next() {
+		rows = append(rows, c.Strings())
+	}
+	expect := [][]string{{"a", "b"}, {"1", "2"}}
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("got %q, expected %q", rows, expect)
+	}
+}
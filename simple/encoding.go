@@ -0,0 +1,49 @@
+package simple
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Text encodings reported by Encoding, detected from the byte order mark.
+const (
+	EncodingUTF8    = "UTF-8"
+	EncodingUTF8BOM = "UTF-8 BOM"
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// hasUTF16BOM returns true if header starts with a UTF-16 byte order mark.
+func hasUTF16BOM(header []byte) bool {
+	return bytes.HasPrefix(header, bomUTF16LE) || bytes.HasPrefix(header, bomUTF16BE)
+}
+
+// decodeBOM detects the encoding of r from its byte order mark, and returns
+// a reader of its UTF-8 contents without the byte order mark. Contents
+// without a byte order mark are read as-is (as UTF-8).
+func decodeBOM(r io.Reader) (io.Reader, string) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(3)
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		br.Discard(len(bomUTF8))
+		return br, EncodingUTF8BOM
+	case bytes.HasPrefix(head, bomUTF16LE):
+		dec := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+		return transform.NewReader(br, dec), EncodingUTF16LE
+	case bytes.HasPrefix(head, bomUTF16BE):
+		dec := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+		return transform.NewReader(br, dec), EncodingUTF16BE
+	}
+	return br, EncodingUTF8
+}
@@ -0,0 +1,85 @@
+package simple
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestByteOrderMarks(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 20; i++ {
+		sb.WriteString("名前\tvalue\n")
+	}
+	text := sb.String()
+
+	utf16LE := func(s string) []byte {
+		res := []byte{0xFF, 0xFE}
+		for _, u := range utf16.Encode([]rune(s)) {
+			res = append(res, byte(u), byte(u>>8))
+		}
+		return res
+	}
+	utf16BE := func(s string) []byte {
+		res := []byte{0xFE, 0xFF}
+		for _, u := range utf16.Encode([]rune(s)) {
+			res = append(res, byte(u>>8), byte(u))
+		}
+		return res
+	}
+
+	for _, c := range []struct {
+		data     []byte
+		encoding string
+	}{
+		{[]byte(text), EncodingUTF8},
+		{append([]byte{0xEF, 0xBB, 0xBF}, text...), EncodingUTF8BOM},
+		{utf16LE(text), EncodingUTF16LE},
+		{utf16BE(text), EncodingUTF16BE},
+	} {
+		fn := filepath.Join(t.TempDir(), "data.tsv")
+		if err := os.WriteFile(fn, c.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if !isText(c.data[:8]) {
+			t.Errorf("%s: expected the contents to be detected as text", c.encoding)
+		}
+		src, err := OpenTSV(fn)
+		if err != nil {
+			t.Fatalf("%s: %v", c.encoding, err)
+		}
+		if enc := src.(interface{ Encoding() string }).Encoding(); enc != c.encoding {
+			t.Errorf("expected encoding %s, got %s", c.encoding, enc)
+		}
+		coll, _ := src.Get("data.tsv")
+		for pass := 0; pass < 2; pass++ {
+			if !coll.Next() {
+				t.Fatalf("%s: expected a record", c.encoding)
+			}
+			if got := coll.Strings(); !reflect.DeepEqual(got, []string{"名前", "value"}) {
+				t.Errorf("%s: unexpected first record %q", c.encoding, got)
+			}
+			if err := coll.(*streamFile).Rewind(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		src.Close()
+	}
+
+	// the preloaded file keeps the encoding
+	fn := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(fn, append([]byte{0xEF, 0xBB, 0xBF}, "a,b\n1,2\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := OpenEager(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if enc := src.(interface{ Encoding() string }).Encoding(); enc != EncodingUTF8BOM {
+		t.Errorf("expected encoding %s, got %s", EncodingUTF8BOM, enc)
+	}
+}
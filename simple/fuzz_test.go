@@ -0,0 +1,42 @@
+package simple
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/internal/testutil"
+)
+
+// FuzzOpenSimple checks that malformed delimited files return errors rather
+// than panicking, with each of the delimited formats.
+func FuzzOpenSimple(f *testing.F) {
+	fns, _ := filepath.Glob("../testdata/*.tsv")
+	for _, fn := range fns {
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("a,\"b\"\"c\",d\r\n1,2,3\n"))
+	f.Add([]byte("{\"a\":1}\n{\"a\":\"x\",\"b\":[1]}\n"))
+
+	openers := []func(string) (grate.Source, error){OpenTSV, OpenCSV, OpenPSV, OpenJSONL}
+	// a new temporary directory for every input would slow down fuzzing
+	fn := filepath.Join(f.TempDir(), "data")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := os.WriteFile(fn, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, open := range openers {
+			src, err := open(fn)
+			if err != nil {
+				continue
+			}
+			testutil.DrainSource(src, 10000)
+			src.Close()
+		}
+	})
+}
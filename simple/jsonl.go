@@ -0,0 +1,188 @@
+package simple
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"strings"
+
+	"github.com/wubin1989/grate"
+)
+
+var _ = grate.Register("jsonl", 8, OpenJSONL)
+var _ = grate.RegisterFile("jsonl", 8, fileOpener(readJSONL))
+var _ = grate.RegisterOptions("jsonl", func(filename string, opts ...grate.Option) (grate.Source, error) {
+	return openJSONL(filename, grate.ParseOptions(opts...))
+})
+var _ = grate.RegisterDetect("jsonl", func(header []byte) bool {
+	header = bytes.TrimSpace(header)
+	return len(header) > 0 && (header[0] == '{' || header[0] == '[') && isText(header)
+})
+
+// OpenJSONL defines a Source's instantiation function for JSON Lines files.
+// It should return ErrNotInFormat immediately if filename is not of the correct file type.
+//
+// Each line must contain a JSON object or array. For objects, the keys of the
+// first object are used as a header row, and subsequent objects fill the
+// columns by key (unknown keys are ignored). Arrays are mapped to columns
+// positionally.
+func OpenJSONL(filename string) (grate.Source, error) {
+	return openJSONL(filename, grate.Options{})
+}
+
+// openJSONL supports the grate.MaxMemoryBytes option.
+func openJSONL(filename string, o grate.Options) (grate.Source, error) {
+	f, err := openFile(filename, o)
+	if err != nil {
+		return nil, err
+	}
+	return readJSONL(filename, f, o)
+}
+
+// readJSONL reads all records of a JSON Lines file from f, which is closed.
+func readJSONL(filename string, f fs.File, o grate.Options) (grate.Source, error) {
+	defer f.Close()
+	var err error
+	r, enc := decodeBOM(f)
+	t := &simpleFile{
+		filename: filename,
+		iterRow:  -1,
+		encoding: enc,
+	}
+
+	var header []string
+	colIndex := make(map[string]int)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case '{':
+			keys, vals, err := decodeJSONObject(line)
+			if err != nil {
+				return nil, grate.WrapErr(err, grate.ErrNotInFormat)
+			}
+			if header == nil {
+				header = keys
+				htypes := make([]string, len(keys))
+				for i, k := range keys {
+					colIndex[k] = i
+					htypes[i] = "string"
+				}
+				t.rows = append(t.rows, header)
+				t.types = append(t.types, htypes)
+			}
+			row := make([]string, len(header))
+			types := make([]string, len(header))
+			for i := range types {
+				types[i] = "blank"
+			}
+			for i, k := range keys {
+				if c, ok := colIndex[k]; ok {
+					row[c], types[c] = jsonValue(vals[i])
+				}
+			}
+			t.rows = append(t.rows, row)
+			t.types = append(t.types, types)
+
+		case '[':
+			var vals []json.RawMessage
+			if err = json.Unmarshal(line, &vals); err != nil {
+				return nil, grate.WrapErr(err, grate.ErrNotInFormat)
+			}
+			row := make([]string, len(vals))
+			types := make([]string, len(vals))
+			for i, v := range vals {
+				row[i], types[i] = jsonValue(v)
+			}
+			t.rows = append(t.rows, row)
+			t.types = append(t.types, types)
+
+		default:
+			return nil, grate.ErrNotInFormat
+		}
+	}
+	if s.Err() != nil {
+		return nil, s.Err()
+	}
+	if len(t.rows) == 0 {
+		return nil, grate.ErrNotInFormat
+	}
+	return t, nil
+}
+
+// decodeJSONObject decodes a JSON object while preserving the order of its keys.
+func decodeJSONObject(line []byte) ([]string, []json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, nil, errors.New("grate/simple: expected a JSON object")
+	}
+
+	var keys []string
+	var vals []json.RawMessage
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, errors.New("grate/simple: invalid JSON object key")
+		}
+		var val json.RawMessage
+		if err = dec.Decode(&val); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		vals = append(vals, val)
+	}
+	if _, err = dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, vals, nil
+}
+
+// jsonValue converts a raw JSON value to its string representation and grate type.
+func jsonValue(raw json.RawMessage) (string, string) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "", "blank"
+	}
+	switch raw[0] {
+	case 'n':
+		return "", "blank"
+	case 't', 'f':
+		return string(raw), "boolean"
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return string(raw), "string"
+		}
+		if s == "" {
+			return "", "blank"
+		}
+		return s, "string"
+	case '{', '[':
+		// nested values are kept as compact JSON strings
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return string(raw), "string"
+		}
+		return buf.String(), "string"
+	}
+	num := string(raw)
+	if strings.ContainsAny(num, ".eE") {
+		return num, "float"
+	}
+	return num, "integer"
+}
@@ -0,0 +1,73 @@
+package simple
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenJSONL(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "data.jsonl")
+	data := `{"name":"a","n":1,"x":1.5,"ok":true}
+{"n":2,"name":"b","ok":false,"extra":"ignored"}
+
+{"name":null,"n":3}
+`
+	if err := os.WriteFile(fn, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := OpenJSONL(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := src.Get("data.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := [][]string{
+		{"name", "n", "x", "ok"},
+		{"a", "1", "1.5", "true"},
+		{"b", "2", "", "false"},
+		{"", "3", "", ""},
+	}
+	expectTypes := [][]string{
+		{"string", "string", "string", "string"},
+		{"string", "integer", "float", "boolean"},
+		{"string", "integer", "blank", "boolean"},
+		{"blank", "integer", "blank", "blank"},
+	}
+	expectValues := [][]interface{}{
+		{"name", "n", "x", "ok"},
+		{"a", int64(1), 1.5, true},
+		{"b", int64(2), "", false},
+		{"", int64(3), "", ""},
+	}
+	i := 0
+	for c.Next() {
+		if c.Row() != i {
+			t.Errorf("expected row index %d, got %d", i, c.Row())
+		}
+		if !reflect.DeepEqual(c.Strings(), expect[i]) {
+			t.Errorf("row %d: got %v, expected %v", i, c.Strings(), expect[i])
+		}
+		if !reflect.DeepEqual(c.Types(), expectTypes[i]) {
+			t.Errorf("row %d: got types %v, expected %v", i, c.Types(), expectTypes[i])
+		}
+		if !reflect.DeepEqual(c.Values(), expectValues[i]) {
+			t.Errorf("row %d: got values %#v, expected %#v", i, c.Values(), expectValues[i])
+		}
+		i++
+	}
+	if i != len(expect) {
+		t.Fatalf("expected %d rows, got %d", len(expect), i)
+	}
+}
+
+func TestOpenJSONLNotInFormat(t *testing.T) {
+	_, err := OpenJSONL("../testdata/basic.tsv")
+	if err == nil {
+		t.Fatal("expected tsv file to be rejected")
+	}
+}
@@ -0,0 +1,100 @@
+package simple
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wubin1989/grate"
+)
+
+// ToMarkdown writes the remaining records of c to w as a GitHub Flavored
+// Markdown table. The first record is the header of the table, followed by
+// the separator row. Columns are padded so that the pipes line up: for a
+// grate.CachedCollection (see grate.Cache) the widths are computed from
+// all records first. Collections with a Rewind() error method (such as the
+// files opened by this package) are read twice to compute the widths, and
+// are written from their first record. Otherwise each record is padded to
+// the widest value seen so far. Pipes in values are escaped and line breaks
+// are written as <br>. Nothing is written if c has no records.
+func ToMarkdown(c grate.Collection, w io.Writer) error {
+	var widths []int
+	grow := func(row []string) {
+		for len(widths) < len(row) {
+			widths = append(widths, 3)
+		}
+		for i, v := range row {
+			if n := utf8.RuneCountInString(v); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	cached, scanned := c.(grate.CachedCollection)
+	if scanned {
+		for i := 0; i < cached.Len(); i++ {
+			grow(markdownCells(cached.RowAt(i)))
+		}
+	} else if r, ok := c.(interface{ Rewind() error }); ok && r.Rewind() == nil {
+		// files which cannot be read again fail before any record is read
+		for c.Next() {
+			grow(markdownCells(c.Strings()))
+		}
+		if err := c.Err(); err != nil {
+			return err
+		}
+		if err := r.Rewind(); err != nil {
+			return err
+		}
+		scanned = true
+	}
+
+	bw := bufio.NewWriter(w)
+	writeRow := func(row []string) {
+		bw.WriteByte('|')
+		for i, width := range widths {
+			v := ""
+			if i < len(row) {
+				v = row[i]
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(v)
+			bw.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(v)))
+			bw.WriteString(" |")
+		}
+		bw.WriteByte('\n')
+	}
+
+	first := true
+	for c.Next() {
+		row := markdownCells(c.Strings())
+		if !scanned {
+			grow(row)
+		}
+		writeRow(row)
+		if first {
+			sep := make([]string, len(widths))
+			for i, width := range widths {
+				sep[i] = strings.Repeat("-", width)
+			}
+			writeRow(sep)
+			first = false
+		}
+	}
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// markdownCells escapes the values of a record for use in table cells.
+func markdownCells(row []string) []string {
+	res := make([]string, len(row))
+	for i, v := range row {
+		v = strings.ReplaceAll(v, "|", `\|`)
+		v = strings.ReplaceAll(v, "\r\n", "<br>")
+		res[i] = strings.ReplaceAll(v, "\n", "<br>")
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package simple
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wubin1989/grate"
+)
+
+func TestToMarkdown(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "in.tsv")
+	if err := os.WriteFile(fn, []byte("name\tqty\napple\t3\nkiwi|lime\t12\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	open := func() grate.Collection {
+		src, err := OpenTSV(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { src.Close() })
+		c, err := src.Get(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	var sb strings.Builder
+	cached, err := grate.Cache(open())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ToMarkdown(cached, &sb); err != nil {
+		t.Fatal(err)
+	}
+	want := "| name       | qty |\n" +
+		"| ---------- | --- |\n" +
+		"| apple      | 3   |\n" +
+		"| kiwi\\|lime | 12  |\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+
+	// files are read twice to compute the widths
+	sb.Reset()
+	if err = ToMarkdown(open(), &sb); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+
+	// otherwise, widths grow as records are read
+	sb.Reset()
+	if err = ToMarkdown(grate.Limit(open(), 10), &sb); err != nil {
+		t.Fatal(err)
+	}
+	want = "| name | qty |\n" +
+		"| ---- | --- |\n" +
+		"| apple | 3   |\n" +
+		"| kiwi\\|lime | 12  |\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,141 @@
+package simple
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+)
+
+// rfc4180Reader is a state machine CSV parser following RFC 4180.
+type rfc4180Reader struct {
+	r      *bufio.Reader
+	line   int
+	strict bool
+	ncols  int
+	field  bytes.Buffer
+}
+
+func newRFC4180Reader(r io.Reader, strict bool) rowReader {
+	p := &rfc4180Reader{
+		r:      bufio.NewReader(r),
+		line:   1,
+		strict: strict,
+		ncols:  -1,
+	}
+	return p.read
+}
+
+// read returns the next record, or io.EOF at the end of the input.
+func (p *rfc4180Reader) read() ([]string, error) {
+	if _, err := p.r.Peek(1); err != nil {
+		return nil, err
+	}
+	start := p.line
+	var rec []string
+	for {
+		last, err := p.readField(start, len(rec)+1)
+		if err != nil {
+			return nil, err
+		}
+		rec = append(rec, p.field.String())
+		if last {
+			break
+		}
+	}
+	if p.strict {
+		if p.ncols >= 0 && len(rec) != p.ncols {
+			return nil, &csv.ParseError{StartLine: start, Line: start, Column: 1, Err: csv.ErrFieldCount}
+		}
+		p.ncols = len(rec)
+	}
+	return rec, nil
+}
+
+// readField reads the next field into p.field, returning true if it ended
+// the record.
+func (p *rfc4180Reader) readField(start, col int) (bool, error) {
+	p.field.Reset()
+	c, err := p.r.ReadByte()
+	if err == io.EOF {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if c != '"' {
+		for {
+			switch c {
+			case ',':
+				return false, nil
+			case '\n':
+				p.line++
+				return true, nil
+			case '\r':
+				if next, _ := p.r.Peek(1); len(next) > 0 && next[0] == '\n' {
+					p.r.ReadByte()
+					p.line++
+					return true, nil
+				}
+			case '"':
+				return false, p.errorf(start, col, csv.ErrBareQuote)
+			}
+			p.field.WriteByte(c)
+			if c, err = p.r.ReadByte(); err == io.EOF {
+				return true, nil
+			} else if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// quoted field, which may contain commas, quotes and line breaks
+	for {
+		c, err = p.r.ReadByte()
+		if err == io.EOF {
+			return false, p.errorf(start, col, errUnterminatedQuote)
+		}
+		if err != nil {
+			return false, err
+		}
+		if c == '\n' {
+			p.line++
+		}
+		if c != '"' {
+			p.field.WriteByte(c)
+			continue
+		}
+
+		c, err = p.r.ReadByte()
+		switch {
+		case err == io.EOF:
+			return true, nil
+		case err != nil:
+			return false, err
+		case c == '"':
+			p.field.WriteByte('"')
+		case c == ',':
+			return false, nil
+		case c == '\n':
+			p.line++
+			return true, nil
+		case c == '\r':
+			if next, _ := p.r.Peek(1); len(next) > 0 && next[0] == '\n' {
+				p.r.ReadByte()
+				p.line++
+				return true, nil
+			}
+			return false, p.errorf(start, col, csv.ErrQuote)
+		default:
+			return false, p.errorf(start, col, csv.ErrQuote)
+		}
+	}
+}
+
+var errUnterminatedQuote = errors.New("unterminated quoted field")
+
+func (p *rfc4180Reader) errorf(start, col int, err error) error {
+	return &csv.ParseError{StartLine: start, Line: p.line, Column: col, Err: err}
+}
@@ -0,0 +1,58 @@
+package simple
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRFC4180Reader(t *testing.T) {
+	data := "name,notes\r\n" +
+		"a,\"first line\r\nsecond line\"\r\n" +
+		"\"b, c\",\"say \"\"hi\"\"\"\r\n" +
+		"d,\n" +
+		"e,last"
+	read := newRFC4180Reader(strings.NewReader(data), false)
+
+	expect := [][]string{
+		{"name", "notes"},
+		{"a", "first line\r\nsecond line"},
+		{"b, c", `say "hi"`},
+		{"d", ""},
+		{"e", "last"},
+	}
+	for i, want := range expect {
+		rec, err := read()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(rec, want) {
+			t.Errorf("record %d: got %q, expected %q", i, rec, want)
+		}
+	}
+	if _, err := read(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRFC4180ReaderErrors(t *testing.T) {
+	for data, want := range map[string]error{
+		"a,b\"c\r\n":        csv.ErrBareQuote,
+		"a,\"b\"c\r\n":      csv.ErrQuote,
+		"a,\"open\r\nfield": errUnterminatedQuote,
+		"a,b\r\nc,d,e\r\n":  csv.ErrFieldCount,
+	} {
+		read := newRFC4180Reader(strings.NewReader(data), true)
+		var err error
+		for err == nil {
+			_, err = read()
+		}
+		var perr *csv.ParseError
+		if !errors.As(err, &perr) || perr.Err != want {
+			t.Errorf("%q: got %v, expected %v", data, err, want)
+		}
+	}
+}
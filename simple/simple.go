@@ -1,8 +1,11 @@
 package simple
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -11,11 +14,69 @@ import (
 	"github.com/wubin1989/grate"
 )
 
+// openFile opens the named file, checking it against the configured memory limit.
+func openFile(filename string, o grate.Options) (*os.File, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkSize(f, o); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// checkSize checks the size of f against the configured memory limit.
+func checkSize(f fs.File, o grate.Options) error {
+	if o.MaxMemoryBytes <= 0 {
+		return nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if o.ExceedsMemory(info.Size()) {
+		return grate.ErrTooLarge
+	}
+	return nil
+}
+
+// fileOpener adapts op, which reads the already opened file f, for use with
+// grate.RegisterFile. The file is named by its base name, as from Stat.
+func fileOpener(op func(filename string, f fs.File, o grate.Options) (grate.Source, error)) grate.OpenFileFunc {
+	return func(f fs.File) (grate.Source, error) {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return op(info.Name(), f, grate.Options{})
+	}
+}
+
+// isText returns true if header is non-empty and has no NUL bytes, which
+// never appear in the delimited text formats.
+func isText(header []byte) bool {
+	return len(header) > 0 && (bytes.IndexByte(header, 0) < 0 || hasUTF16BOM(header))
+}
+
 // represents a set of data collections.
 type simpleFile struct {
 	filename string
 	rows     [][]string
 	iterRow  int
+
+	// types of each value, when known from the format (otherwise inferred as strings)
+	types [][]string
+
+	// encoding of the file, detected from its byte order mark
+	encoding string
+}
+
+// Encoding returns the text encoding of the file, one of EncodingUTF8,
+// EncodingUTF8BOM, EncodingUTF16LE or EncodingUTF16BE.
+func (t *simpleFile) Encoding() string {
+	return t.encoding
 }
 
 // List the individual data tables within this source.
@@ -23,6 +84,12 @@ func (t *simpleFile) List() ([]string, error) {
 	return []string{filepath.Base(t.filename)}, nil
 }
 
+// SheetCount returns the number of data tables within this source, which
+// is always 1.
+func (t *simpleFile) SheetCount() (int, error) {
+	return 1, nil
+}
+
 func (t *simpleFile) Close() error {
 	return nil
 }
@@ -32,6 +99,17 @@ func (t *simpleFile) Get(name string) (grate.Collection, error) {
 	return t, nil
 }
 
+// SheetStats returns the number of records and columns loaded from the file.
+func (t *simpleFile) SheetStats() ([]grate.SheetStats, error) {
+	st := grate.SheetStats{Name: filepath.Base(t.filename), EstimatedRows: len(t.rows)}
+	for _, row := range t.rows {
+		if len(row) > st.MaxCols {
+			st.MaxCols = len(row)
+		}
+	}
+	return []grate.SheetStats{st}, nil
+}
+
 // Next advances to the next record of content.
 // It MUST be called prior to any Scan().
 func (t *simpleFile) Next() bool {
@@ -39,6 +117,14 @@ func (t *simpleFile) Next() bool {
 	return t.iterRow < len(t.rows)
 }
 
+// Row returns the zero-based index of the current record.
+func (t *simpleFile) Row() int {
+	if t.iterRow >= len(t.rows) {
+		return len(t.rows) - 1
+	}
+	return t.iterRow
+}
+
 // Strings extracts values from the current record into a list of strings.
 func (t *simpleFile) Strings() []string {
 	return t.rows[t.iterRow]
@@ -46,7 +132,12 @@ func (t *simpleFile) Strings() []string {
 
 // Formats extracts the format code for the current record into a list.
 func (t *simpleFile) Formats() []string {
-	res := make([]string, len(t.rows[t.iterRow]))
+	return rowFormats(t.rows[t.iterRow])
+}
+
+// rowFormats returns the (General) format codes for a row.
+func rowFormats(row []string) []string {
+	res := make([]string, len(row))
 	for i := range res {
 		res[i] = "General"
 	}
@@ -57,8 +148,16 @@ func (t *simpleFile) Formats() []string {
 // options: "boolean", "integer", "float", "string", "date",
 // and special cases: "blank", "hyperlink" which are string types
 func (t *simpleFile) Types() []string {
-	res := make([]string, len(t.rows[t.iterRow]))
-	for i, v := range t.rows[t.iterRow] {
+	if t.types != nil {
+		return t.types[t.iterRow]
+	}
+	return rowTypes(t.rows[t.iterRow])
+}
+
+// rowTypes infers the types of the values in a row as strings or blanks.
+func rowTypes(row []string) []string {
+	res := make([]string, len(row))
+	for i, v := range row {
 		if v == "" {
 			res[i] = "blank"
 		} else {
@@ -68,12 +167,62 @@ func (t *simpleFile) Types() []string {
 	return res
 }
 
+// Values extracts the values of the current record into a list. Values are
+// strings, unless their types are known from the format (as for JSON Lines),
+// in which case numbers and booleans are parsed.
+func (t *simpleFile) Values() []interface{} {
+	if t.types != nil {
+		return parseValues(t.rows[t.iterRow], t.types[t.iterRow])
+	}
+	return rowValues(t.rows[t.iterRow])
+}
+
+// rowValues returns the values of row as a list of strings.
+func rowValues(row []string) []interface{} {
+	res := make([]interface{}, len(row))
+	for i, v := range row {
+		res[i] = v
+	}
+	return res
+}
+
+// parseValues returns the values of row, parsing integers, floats and
+// booleans according to their types. Values which cannot be parsed are kept
+// as strings.
+func parseValues(row, types []string) []interface{} {
+	res := rowValues(row)
+	for i, v := range row {
+		if i >= len(types) {
+			break
+		}
+		switch types[i] {
+		case "integer":
+			if x, err := strconv.ParseInt(v, 10, 64); err == nil {
+				res[i] = x
+			}
+		case "float":
+			if x, err := strconv.ParseFloat(v, 64); err == nil {
+				res[i] = x
+			}
+		case "boolean":
+			if x, err := strconv.ParseBool(v); err == nil {
+				res[i] = x
+			}
+		}
+	}
+	return res
+}
+
 // Scan extracts values from the current record into the provided arguments
 // Arguments must be pointers to one of 5 supported types:
 //     bool, int, float64, string, or time.Time
 func (t *simpleFile) Scan(args ...interface{}) error {
+	return scanRow(t.rows[t.iterRow], args)
+}
+
+// scanRow parses the values of row into args.
+func scanRow(row []string, args []interface{}) error {
 	var err error
-	row := t.rows[t.iterRow]
 	if len(row) != len(args) {
 		return fmt.Errorf("grate/simple: expected %d Scan destinations, got %d", len(row), len(args))
 	}
@@ -112,6 +261,17 @@ func (t *simpleFile) IsEmpty() bool {
 	return len(t.rows) == 0
 }
 
+// Width returns the maximum number of values of the rows.
+func (t *simpleFile) Width() int {
+	w := 0
+	for _, row := range t.rows {
+		if len(row) > w {
+			w = len(row)
+		}
+	}
+	return w
+}
+
 // Err returns the last error that occured.
 func (t *simpleFile) Err() error {
 	return nil
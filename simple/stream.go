@@ -0,0 +1,305 @@
+package simple
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/wubin1989/grate"
+)
+
+// number of rows read ahead to detect the file format
+const probeRows = 1000
+
+// rowReader returns the next record of a file, or io.EOF at the end.
+type rowReader func() ([]string, error)
+
+// streamFile is a Source and Collection which reads one record per call to
+// Next, so memory usage does not depend on the size of the file. The first
+// records are read ahead when opening to detect the file format.
+type streamFile struct {
+	filename string
+	f        fs.File
+
+	// path of the file, if it can be opened again by name
+	path string
+
+	// newReader creates a record reader for the (re-)opened file
+	newReader func(io.Reader) rowReader
+	read      rowReader
+
+	// encoding of the file, detected from its byte order mark
+	encoding string
+
+	probe   [][]string
+	empty   bool
+	width   int
+	row     []string
+	iterRow int
+	done    bool
+	err     error
+}
+
+func newStreamFile(filename string, f fs.File, newReader func(io.Reader) rowReader) *streamFile {
+	r, enc := decodeBOM(f)
+	t := &streamFile{
+		filename:  filename,
+		f:         f,
+		newReader: newReader,
+		read:      newReader(r),
+		encoding:  enc,
+		iterRow:   -1,
+	}
+	if osf, ok := f.(*os.File); ok {
+		t.path = osf.Name()
+	}
+	return t
+}
+
+// errNoRewind is returned by Rewind for files which cannot be read again.
+var errNoRewind = errors.New("grate/simple: the file cannot be rewound")
+
+// Encoding returns the text encoding of the file, one of EncodingUTF8,
+// EncodingUTF8BOM, EncodingUTF16LE or EncodingUTF16BE. Byte order marks are
+// removed, and UTF-16 files are converted to UTF-8 while reading.
+func (t *streamFile) Encoding() string {
+	return t.encoding
+}
+
+// readProbe reads up to n records ahead, returning the first error encountered.
+func (t *streamFile) readProbe(n int) error {
+	defer func() { t.empty = len(t.probe) == 0 }()
+	for len(t.probe) < n {
+		rec, err := t.read()
+		if err != nil {
+			return err
+		}
+		t.probe = append(t.probe, rec)
+	}
+	return nil
+}
+
+// List the individual data tables within this source.
+func (t *streamFile) List() ([]string, error) {
+	return []string{filepath.Base(t.filename)}, nil
+}
+
+// SheetCount returns the number of data tables within this source, which
+// is always 1.
+func (t *streamFile) SheetCount() (int, error) {
+	return 1, nil
+}
+
+// Close the source and the underlying file.
+func (t *streamFile) Close() error {
+	if t.f == nil {
+		return nil
+	}
+	err := t.f.Close()
+	t.f = nil
+	return err
+}
+
+// Get a Collection from the source by name.
+func (t *streamFile) Get(name string) (grate.Collection, error) {
+	return t, nil
+}
+
+// Rewind restarts iteration before the first record, by seeking to the
+// start of the file or reopening it. Files opened from an fs.FS which does
+// not support seeking cannot be rewound.
+func (t *streamFile) Rewind() error {
+	if s, ok := t.f.(io.Seeker); ok {
+		if _, err := s.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	} else if t.path != "" {
+		t.Close()
+		f, err := os.Open(t.path)
+		if err != nil {
+			return err
+		}
+		t.f = f
+	} else {
+		return errNoRewind
+	}
+	r, _ := decodeBOM(t.f)
+	t.read = t.newReader(r)
+	t.probe = nil
+	t.row = nil
+	t.iterRow = -1
+	t.done = false
+	t.err = nil
+	return nil
+}
+
+// Next advances to the next record of content.
+// It MUST be called prior to any Scan().
+func (t *streamFile) Next() bool {
+	if t.done {
+		return false
+	}
+	if t.iterRow+1 < len(t.probe) {
+		t.iterRow++
+		t.setRow(t.probe[t.iterRow])
+		return true
+	}
+	rec, err := t.read()
+	if err != nil {
+		if err != io.EOF {
+			t.err = err
+		}
+		t.done = true
+		return false
+	}
+	t.iterRow++
+	t.setRow(rec)
+	return true
+}
+
+func (t *streamFile) setRow(row []string) {
+	t.row = row
+	if len(row) > t.width {
+		t.width = len(row)
+	}
+}
+
+// Row returns the zero-based index of the current record.
+func (t *streamFile) Row() int {
+	return t.iterRow
+}
+
+// Strings extracts values from the current record into a list of strings.
+func (t *streamFile) Strings() []string {
+	return t.row
+}
+
+// Formats extracts the format code for the current record into a list.
+func (t *streamFile) Formats() []string {
+	return rowFormats(t.row)
+}
+
+// Types extracts the data types from the current record into a list.
+// options: "boolean", "integer", "float", "string", "date",
+// and special cases: "blank", "hyperlink" which are string types
+func (t *streamFile) Types() []string {
+	return rowTypes(t.row)
+}
+
+// Values extracts the values of the current record into a list. All values
+// of simple files are strings.
+func (t *streamFile) Values() []interface{} {
+	return rowValues(t.row)
+}
+
+// Scan extracts values from the current record into the provided arguments
+// Arguments must be pointers to one of 5 supported types:
+//
+//	bool, int, float64, string, or time.Time
+func (t *streamFile) Scan(args ...interface{}) error {
+	return scanRow(t.row, args)
+}
+
+// IsEmpty returns true if there are no data values.
+func (t *streamFile) IsEmpty() bool {
+	return t.empty
+}
+
+// Width returns the maximum number of values of the records read so far.
+func (t *streamFile) Width() int {
+	return t.width
+}
+
+// Err returns the last error that occured.
+func (t *streamFile) Err() error {
+	return t.err
+}
+
+// SheetStats estimates the number of records from the number of lines in
+// the file, and the number of columns from the records read when opening.
+func (t *streamFile) SheetStats() ([]grate.SheetStats, error) {
+	var f io.Reader
+	if t.path != "" {
+		osf, err := os.Open(t.path)
+		if err != nil {
+			return nil, err
+		}
+		defer osf.Close()
+		f = osf
+	} else if ra, ok := t.f.(io.ReaderAt); ok {
+		info, err := t.f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		f = io.NewSectionReader(ra, 0, info.Size())
+	} else {
+		return nil, errNoRewind
+	}
+
+	st := grate.SheetStats{Name: filepath.Base(t.filename)}
+	buf := make([]byte, 64*1024)
+	last := byte('\n')
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			st.EstimatedRows += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if last != '\n' {
+		// final line without a line ending
+		st.EstimatedRows++
+	}
+	for _, rec := range t.probe {
+		if len(rec) > st.MaxCols {
+			st.MaxCols = len(rec)
+		}
+	}
+	return []grate.SheetStats{st}, nil
+}
+
+// preload reads all remaining records into memory.
+func (t *streamFile) preload() (*simpleFile, error) {
+	defer t.Close()
+	res := &simpleFile{
+		filename: t.filename,
+		iterRow:  -1,
+		encoding: t.encoding,
+	}
+	for t.Next() {
+		res.rows = append(res.rows, t.row)
+	}
+	return res, t.Err()
+}
+
+// OpenEager opens a JSON Lines, TSV or CSV file (detected in that order) and
+// loads all of its records into memory before returning, instead of
+// streaming them from the file.
+func OpenEager(filename string) (grate.Source, error) {
+	for _, op := range []func(string, grate.Options) (grate.Source, error){openJSONL, openTSV, openCSV} {
+		src, err := op(filename, grate.Options{})
+		if err == nil {
+			if sf, ok := src.(*streamFile); ok {
+				res, err := sf.preload()
+				if err != nil {
+					return nil, err
+				}
+				return res, nil
+			}
+			return src, nil
+		}
+		if !errors.Is(err, grate.ErrNotInFormat) {
+			return nil, err
+		}
+	}
+	return nil, grate.ErrUnknownFormat
+}
@@ -0,0 +1,172 @@
+package simple
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/wubin1989/grate"
+)
+
+func writeTSV(t *testing.T, nrows int) string {
+	t.Helper()
+	var sb strings.Builder
+	for i := 0; i < nrows; i++ {
+		fmt.Fprintf(&sb, "%d\tvalue %d\tx\n", i, i)
+	}
+	fn := filepath.Join(t.TempDir(), "data.tsv")
+	if err := os.WriteFile(fn, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestStreamingTSV(t *testing.T) {
+	nrows := probeRows + 500
+	src, err := OpenTSV(writeTSV(t, nrows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	c, _ := src.Get("data.tsv")
+	if c.IsEmpty() {
+		t.Fatal("expected data")
+	}
+	if w := c.Width(); w != 0 {
+		t.Fatalf("expected an unknown width before Next, got %d", w)
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		n := 0
+		for c.Next() {
+			var i int
+			var s, x string
+			if err := c.Scan(&i, &s, &x); err != nil {
+				t.Fatal(err)
+			}
+			if i != n || c.Row() != n {
+				t.Fatalf("pass %d: expected row %d, got %d (index %d)", pass, n, i, c.Row())
+			}
+			n++
+		}
+		if err := c.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n != nrows {
+			t.Fatalf("pass %d: expected %d rows, got %d", pass, nrows, n)
+		}
+		if w := c.Width(); w != 3 {
+			t.Fatalf("pass %d: expected width 3, got %d", pass, w)
+		}
+		if err := c.(*streamFile).Rewind(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTSVLineEndings(t *testing.T) {
+	for name, data := range map[string]string{
+		"lf":           "a\tb\n1\t2\n3\t4\n",
+		"crlf":         "a\tb\r\n1\t2\r\n3\t4\r\n",
+		"crlf-no-eol":  "a\tb\r\n1\t2\r\n3\t4\r",
+		"double-cr":    "a\tb\r\r\n1\t2\r\r\n3\t4\r\r\n",
+		"mixed-ending": "a\tb\r\n1\t2\n3\t4\r\n",
+	} {
+		fn := filepath.Join(t.TempDir(), "data.tsv")
+		if err := os.WriteFile(fn, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		src, err := OpenTSV(fn)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		c, _ := src.Get("data.tsv")
+		var got []string
+		for c.Next() {
+			got = append(got, strings.Join(c.Strings(), ","))
+		}
+		src.Close()
+		if expect := "a,b 1,2 3,4"; strings.Join(got, " ") != expect {
+			t.Errorf("%s: got rows %q, expected %q", name, got, expect)
+		}
+	}
+}
+
+func TestOpenEager(t *testing.T) {
+	src, err := OpenEager(writeTSV(t, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, ok := src.(*simpleFile)
+	if !ok {
+		t.Fatalf("expected preloaded rows, got %T", src)
+	}
+	if len(sf.rows) != 20 || sf.rows[19][1] != "value 19" {
+		t.Fatalf("unexpected rows %v", sf.rows)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for header, want := range map[string]string{
+		`{"a": 1}` + "\n": "jsonl",
+		"a\tb\n1\t2\n":    "tsv",
+		"a,b\n1,2\n":      "csv",
+		"\x00\x01binary":  "",
+	} {
+		got, _ := grate.DetectFormat([]byte(header))
+		if got != want {
+			t.Errorf("DetectFormat(%q) = %q, expected %q", header, got, want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	st, err := grate.Stats(writeTSV(t, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Format != "tsv" || st.SheetCount != 1 || st.Sheets[0].EstimatedRows != 20 || st.Sheets[0].MaxCols != 3 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	var csvData, tsvData strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&csvData, "%d,\"value, %d\",x\n", i, i)
+		fmt.Fprintf(&tsvData, "%d\tvalue %d\tx\n", i, i)
+	}
+	fsys := fstest.MapFS{
+		"dir/data.csv": {Data: []byte(csvData.String())},
+		"dir/data.tsv": {Data: []byte(tsvData.String())},
+		"dir/data.psv": {Data: []byte("a|b\n1|2\n")},
+	}
+	for name, expect := range map[string][]string{
+		"dir/data.csv": {"1", "value, 1", "x"},
+		"dir/data.tsv": {"1", "value 1", "x"},
+		"dir/data.psv": {"1", "2"},
+	} {
+		src, err := grate.OpenFS(fsys, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		names, _ := src.List()
+		if want := filepath.Base(name); len(names) != 1 || names[0] != want {
+			t.Errorf("%s: got sheets %q, expected %q", name, names, want)
+		}
+		c, _ := src.Get(names[0])
+		c.Next()
+		c.Next()
+		if got := c.Strings(); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: got %q, expected %q", name, got, expect)
+		}
+		if err := c.(*streamFile).Rewind(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		src.Close()
+	}
+}
@@ -2,42 +2,89 @@ package simple
 
 import (
 	"bufio"
-	"os"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
 	"strings"
 
 	"github.com/wubin1989/grate"
 )
 
 var _ = grate.Register("tsv", 10, OpenTSV)
+var _ = grate.RegisterFile("tsv", 10, fileOpener(readTSV))
+var _ = grate.RegisterOptions("tsv", func(filename string, opts ...grate.Option) (grate.Source, error) {
+	return openTSV(filename, grate.ParseOptions(opts...))
+})
+var _ = grate.RegisterDetect("tsv", func(header []byte) bool {
+	line, _, _ := bytes.Cut(header, []byte("\n"))
+	return bytes.IndexByte(line, '\t') >= 0 && isText(header)
+})
 
 // OpenTSV defines a Source's instantiation function.
 // It should return ErrNotInFormat immediately if filename is not of the correct file type.
+//
+// Rows are read from the file as the collection is iterated.
 func OpenTSV(filename string) (grate.Source, error) {
-	f, err := os.Open(filename)
+	return openTSV(filename, grate.Options{})
+}
+
+// errInconsistentColumns is returned in strict mode for rows with a different number of columns.
+var errInconsistentColumns = errors.New("grate/simple: inconsistent number of columns")
+
+// openTSV supports the grate.MaxMemoryBytes and grate.StrictMode options.
+// In strict mode, all rows must have the same number of columns.
+func openTSV(filename string, o grate.Options) (grate.Source, error) {
+	f, err := openFile(filename, o)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	t := &simpleFile{
-		filename: filename,
-		iterRow:  -1,
-	}
+	return readTSV(filename, f, o)
+}
 
-	s := bufio.NewScanner(f)
-	total := 0
-	ncols := make(map[int]int)
-	for s.Scan() {
-		r := strings.Split(s.Text(), "\t")
-		ncols[len(r)]++
-		total++
-		t.rows = append(t.rows, r)
+// readTSV reads a TSV file from f, which is closed if it is not in the format.
+func readTSV(filename string, f fs.File, o grate.Options) (grate.Source, error) {
+	t := newStreamFile(filename, f, func(r io.Reader) rowReader {
+		s := bufio.NewScanner(r)
+		ncols := -1
+		return func() ([]string, error) {
+			if !s.Scan() {
+				if s.Err() != nil {
+					return nil, s.Err()
+				}
+				return nil, io.EOF
+			}
+			// ScanLines drops the \r of a \r\n line ending, but not
+			// of lines ending in \r\r\n as written by some Windows tools
+			line := strings.TrimRight(s.Text(), "\r")
+			row := strings.Split(line, "\t")
+			if o.Strict {
+				if ncols >= 0 && len(row) != ncols {
+					return nil, errInconsistentColumns
+				}
+				ncols = len(row)
+			}
+			return row, nil
+		}
+	})
+
+	err := t.readProbe(probeRows)
+	if err == errInconsistentColumns {
+		t.Close()
+		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
 	}
-	if s.Err() != nil {
+	if err != nil && err != io.EOF {
 		// this can only be read errors, not format
-		return nil, s.Err()
+		t.Close()
+		return nil, err
 	}
 
 	// kinda arbitrary metrics for detecting TSV
+	total := len(t.probe)
+	ncols := make(map[int]int)
+	for _, r := range t.probe {
+		ncols[len(r)]++
+	}
 	looksGood := 0
 	for c, n := range ncols {
 		if c <= 1 {
@@ -51,7 +98,8 @@ func OpenTSV(filename string) (grate.Source, error) {
 		}
 	}
 	if looksGood == 1 {
-		return t, grate.ErrNotInFormat
+		t.Close()
+		return nil, grate.ErrNotInFormat
 	}
 
 	return t, nil
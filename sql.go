@@ -0,0 +1,202 @@
+package grate
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Dialect selects the SQL variant written by ToSQL.
+type Dialect int
+
+// Supported SQL dialects.
+const (
+	// PostgreSQL quotes identifiers with double quotes.
+	PostgreSQL Dialect = iota
+	// MySQL quotes identifiers with backticks and escapes backslashes in strings.
+	MySQL
+	// SQLite quotes identifiers with double quotes.
+	SQLite
+)
+
+type sqlConfig struct {
+	columns   []string
+	dialect   Dialect
+	batchSize int
+}
+
+// SQLOption configures the output of ToSQL.
+type SQLOption func(*sqlConfig)
+
+// WithColumnNames sets the column list of the INSERT statements. Without
+// it, values are inserted by column position.
+func WithColumnNames(names []string) SQLOption {
+	return func(c *sqlConfig) { c.columns = names }
+}
+
+// WithDialect selects the SQL dialect to write. The default is PostgreSQL.
+func WithDialect(d Dialect) SQLOption {
+	return func(c *sqlConfig) { c.dialect = d }
+}
+
+// WithBatchSize writes up to n rows per INSERT statement. The default is 1.
+func WithBatchSize(n int) SQLOption {
+	return func(c *sqlConfig) { c.batchSize = n }
+}
+
+// ToSQL writes the remaining records of the collection to w as INSERT
+// statements into tableName. Values are quoted according to their Types():
+// blanks become NULL, integers and floats are unquoted, booleans are TRUE or
+// FALSE, dates are ISO 8601 literals and everything else is a quoted string.
+//
+// Every row has the same number of values, so that batched statements are
+// valid: the number of column names given with WithColumnNames, or else the
+// width of the collection when its first record is read. Shorter records
+// are padded with NULL and longer records are truncated.
+func ToSQL(c Collection, tableName string, w io.Writer, opts ...SQLOption) error {
+	if tableName == "" {
+		return errors.New("grate: ToSQL requires a table name")
+	}
+	cfg := &sqlConfig{batchSize: 1}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.batchSize < 1 {
+		cfg.batchSize = 1
+	}
+
+	prefix := "INSERT INTO " + cfg.quoteIdent(tableName)
+	if len(cfg.columns) > 0 {
+		cols := make([]string, len(cfg.columns))
+		for i, name := range cfg.columns {
+			cols[i] = cfg.quoteIdent(name)
+		}
+		prefix += " (" + strings.Join(cols, ", ") + ")"
+	}
+	prefix += " VALUES "
+
+	bw := bufio.NewWriter(w)
+	n := 0
+	width := len(cfg.columns)
+	for c.Next() {
+		vals := c.Strings()
+		types := c.Types()
+		if width == 0 {
+			width = c.Width()
+			if width < len(vals) {
+				width = len(vals)
+			}
+			if width == 0 {
+				width = 1
+			}
+		}
+		if len(vals) > width {
+			vals = vals[:width]
+		}
+		if n == 0 {
+			bw.WriteString(prefix)
+		} else {
+			bw.WriteString(",\n  ")
+		}
+		bw.WriteByte('(')
+		for i, v := range vals {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+			typ := ""
+			if i < len(types) {
+				typ = types[i]
+			}
+			bw.WriteString(cfg.literal(v, typ))
+		}
+		for i := len(vals); i < width; i++ {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+			bw.WriteString("NULL")
+		}
+		bw.WriteByte(')')
+		n++
+		if n == cfg.batchSize {
+			bw.WriteString(";\n")
+			n = 0
+		}
+	}
+	if n > 0 {
+		bw.WriteString(";\n")
+	}
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (c *sqlConfig) quoteIdent(name string) string {
+	if c.dialect == MySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (c *sqlConfig) quoteString(s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+	if c.dialect == MySQL {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + s + "'"
+}
+
+// layouts tried when converting date cells to ISO 8601 literals.
+var sqlDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"01-02-06",
+	"01-02-06 15:04",
+	"1/2/06",
+	"1/2/2006",
+	"1/2/06 15:04",
+	"1/2/2006 15:04:05",
+	"2-Jan-06",
+	"15:04:05",
+}
+
+// sqlNumber matches the numbers which are valid SQL literals. Other values
+// accepted by strconv.ParseFloat (e.g. "NaN", "Inf", "0x1p4" or "1_000")
+// are not.
+var sqlNumber = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][-+]?\d+)?$`)
+
+func (c *sqlConfig) literal(v, typ string) string {
+	switch typ {
+	case "blank":
+		return "NULL"
+	case "integer", "float":
+		// formatted numbers (e.g. "1,234" or "12%") are kept as strings
+		if sqlNumber.MatchString(v) {
+			return v
+		}
+	case "boolean":
+		switch strings.ToLower(v) {
+		case "1", "t", "true", "y", "yes":
+			return "TRUE"
+		case "0", "f", "false", "n", "no":
+			return "FALSE"
+		}
+	case "date":
+		for _, layout := range sqlDateLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				if layout == "15:04:05" {
+					return c.quoteString(t.Format("15:04:05"))
+				}
+				if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+					return c.quoteString(t.Format("2006-01-02"))
+				}
+				return c.quoteString(t.Format("2006-01-02T15:04:05"))
+			}
+		}
+	}
+	return c.quoteString(v)
+}
@@ -0,0 +1,80 @@
+package grate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToSQL(t *testing.T) {
+	c := newTestCollection(
+		[]string{"it's", "42", "1.5", "TRUE", "2021-03-04", ""},
+		[]string{`a\b`, "1,000", "2", "FALSE", "2021-03-04 05:06:07", "x"},
+		[]string{"", "NaN", "-Inf", "", "", ""},
+		[]string{"", "0x1p4", "-1.5e-3", "", "", ""},
+		[]string{"", "1_000", "+1", "", "", ""},
+	)
+	c.types = [][]string{
+		{"string", "integer", "float", "boolean", "date", "blank"},
+		{"string", "integer", "float", "boolean", "date", "string"},
+		{"blank", "float", "float", "blank", "blank", "blank"},
+		{"blank", "float", "float", "blank", "blank", "blank"},
+		{"blank", "integer", "integer", "blank", "blank", "blank"},
+	}
+
+	var sb strings.Builder
+	err := ToSQL(c, "data", &sb, WithColumnNames([]string{"a", "b", "c", "d", "e", "f"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `INSERT INTO "data" ("a", "b", "c", "d", "e", "f") VALUES ('it''s', 42, 1.5, TRUE, '2021-03-04', NULL);
+INSERT INTO "data" ("a", "b", "c", "d", "e", "f") VALUES ('a\b', '1,000', 2, FALSE, '2021-03-04T05:06:07', 'x');
+INSERT INTO "data" ("a", "b", "c", "d", "e", "f") VALUES (NULL, 'NaN', '-Inf', NULL, NULL, NULL);
+INSERT INTO "data" ("a", "b", "c", "d", "e", "f") VALUES (NULL, '0x1p4', -1.5e-3, NULL, NULL, NULL);
+INSERT INTO "data" ("a", "b", "c", "d", "e", "f") VALUES (NULL, '1_000', '+1', NULL, NULL, NULL);
+`
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+}
+
+func TestToSQLBatchedMySQL(t *testing.T) {
+	c := newTestCollection([]string{`a\b`}, []string{"c"}, []string{"d"})
+
+	var sb strings.Builder
+	if err := ToSQL(c, "t", &sb, WithDialect(MySQL), WithBatchSize(2)); err != nil {
+		t.Fatal(err)
+	}
+	want := "INSERT INTO `t` VALUES ('a\\\\b'),\n  ('c');\nINSERT INTO `t` VALUES ('d');\n"
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+}
+
+func TestToSQLRowWidth(t *testing.T) {
+	c := newTestCollection([]string{"a", "b"}, []string{"c"}, []string{"d", "e", "f"}, []string{})
+
+	var sb strings.Builder
+	if err := ToSQL(c, "t", &sb, WithBatchSize(4)); err != nil {
+		t.Fatal(err)
+	}
+	want := `INSERT INTO "t" VALUES ('a', 'b', NULL),
+  ('c', NULL, NULL),
+  ('d', 'e', 'f'),
+  (NULL, NULL, NULL);
+`
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+
+	c = newTestCollection([]string{}, []string{"a", "b", "c"})
+	sb.Reset()
+	if err := ToSQL(c, "t", &sb, WithColumnNames([]string{"x", "y"}), WithBatchSize(2)); err != nil {
+		t.Fatal(err)
+	}
+	want = `INSERT INTO "t" ("x", "y") VALUES (NULL, NULL),
+  ('a', 'b');
+`
+	if sb.String() != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", sb.String(), want)
+	}
+}
@@ -0,0 +1,210 @@
+package grate
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlRows wraps database/sql query results as a Collection.
+type sqlRows struct {
+	rows    *sql.Rows
+	dbTypes []string
+	vals    []interface{}
+	row     int
+	err     error
+}
+
+// FromSQLRows returns a Collection iterating over the query results. Values
+// are converted to strings with fmt.Sprint (and time.RFC3339Nano for
+// times), and their types are taken from the values returned by the driver,
+// or the database column types for drivers returning text. All formats are
+// "General". The rows are closed at the end of the iteration.
+func FromSQLRows(rows *sql.Rows) (Collection, error) {
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	c := &sqlRows{rows: rows, row: -1, dbTypes: make([]string, len(cts))}
+	for i, ct := range cts {
+		c.dbTypes[i] = sqlTypeName(ct.DatabaseTypeName())
+	}
+	return c, nil
+}
+
+// sqlTypeName maps a database column type name to a grate type name.
+func sqlTypeName(dbType string) string {
+	t := strings.ToUpper(dbType)
+	switch {
+	case strings.Contains(t, "INT"):
+		return "integer"
+	case strings.Contains(t, "FLOAT"), strings.Contains(t, "DOUBLE"), strings.Contains(t, "REAL"),
+		strings.Contains(t, "DECIMAL"), strings.Contains(t, "NUMERIC"):
+		return "float"
+	case strings.Contains(t, "BOOL"), t == "BIT":
+		return "boolean"
+	case strings.Contains(t, "DATE"), strings.Contains(t, "TIME"):
+		return "date"
+	}
+	return "string"
+}
+
+// Next advances to the next record of content.
+func (c *sqlRows) Next() bool {
+	if c.err != nil || c.rows == nil {
+		return false
+	}
+	if !c.rows.Next() {
+		c.err = c.rows.Err()
+		c.rows.Close()
+		c.rows = nil
+		return false
+	}
+	vals := make([]interface{}, len(c.dbTypes))
+	ptrs := make([]interface{}, len(vals))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if c.err = c.rows.Scan(ptrs...); c.err != nil {
+		c.rows.Close()
+		c.rows = nil
+		return false
+	}
+	c.vals = vals
+	c.row++
+	return true
+}
+
+// Row returns the zero-based index of the current record.
+func (c *sqlRows) Row() int {
+	return c.row
+}
+
+// Strings extracts values from the current record into a list of strings.
+func (c *sqlRows) Strings() []string {
+	res := make([]string, len(c.vals))
+	for i, v := range c.vals {
+		switch x := v.(type) {
+		case nil:
+		case []byte:
+			res[i] = string(x)
+		case time.Time:
+			res[i] = x.Format(time.RFC3339Nano)
+		default:
+			res[i] = fmt.Sprint(x)
+		}
+	}
+	return res
+}
+
+// Types extracts the data types from the current record into a list.
+func (c *sqlRows) Types() []string {
+	res := make([]string, len(c.vals))
+	for i, v := range c.vals {
+		switch v.(type) {
+		case nil:
+			res[i] = "blank"
+		case int64, int32, int, uint64, uint32:
+			res[i] = "integer"
+		case float64, float32:
+			res[i] = "float"
+		case bool:
+			res[i] = "boolean"
+		case time.Time:
+			res[i] = "date"
+		default:
+			res[i] = c.dbTypes[i]
+		}
+	}
+	return res
+}
+
+// Values extracts the native values of the current record into a list.
+// The values scanned from the driver are returned as they are, except that
+// NULLs are blank strings and byte slices are strings, which are parsed if
+// the database column type is not a string type.
+func (c *sqlRows) Values() []interface{} {
+	res := make([]interface{}, len(c.vals))
+	for i, v := range c.vals {
+		switch x := v.(type) {
+		case nil:
+			res[i] = ""
+		case []byte:
+			res[i] = parseValues([]string{string(x)}, []string{c.dbTypes[i]})[0]
+		case string:
+			res[i] = parseValues([]string{x}, []string{c.dbTypes[i]})[0]
+		default:
+			res[i] = x
+		}
+	}
+	return res
+}
+
+// parseValues converts the string values of row to native values according
+// to their types. Values which cannot be parsed are kept as strings.
+func parseValues(row, types []string) []interface{} {
+	res := make([]interface{}, len(row))
+	for i, v := range row {
+		res[i] = v
+		if i >= len(types) {
+			continue
+		}
+		switch types[i] {
+		case "integer":
+			if x, err := strconv.ParseInt(v, 10, 64); err == nil {
+				res[i] = x
+			}
+		case "float":
+			if x, err := strconv.ParseFloat(v, 64); err == nil {
+				res[i] = x
+			}
+		case "boolean":
+			switch strings.ToLower(v) {
+			case "1", "t", "true", "y", "yes":
+				res[i] = true
+			case "0", "f", "false", "n", "no":
+				res[i] = false
+			}
+		case "date":
+			for _, layout := range sqlDateLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					res[i] = t
+					break
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Formats returns "General" for each value of the current record.
+func (c *sqlRows) Formats() []string {
+	res := make([]string, len(c.vals))
+	for i := range res {
+		res[i] = "General"
+	}
+	return res
+}
+
+// Scan extracts values from the current record into the provided arguments.
+// Native values are stored directly, as returned by Values.
+func (c *sqlRows) Scan(args ...interface{}) error {
+	return scanValues(c.Values(), c.Strings(), args)
+}
+
+// IsEmpty returns true if the query did not return any records (yet).
+func (c *sqlRows) IsEmpty() bool {
+	return c.row < 0
+}
+
+// Width returns the number of columns of the query results.
+func (c *sqlRows) Width() int {
+	return len(c.dbTypes)
+}
+
+// Err returns the last error that occured.
+func (c *sqlRows) Err() error {
+	return c.err
+}
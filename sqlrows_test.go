@@ -0,0 +1,116 @@
+package grate
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeDriver returns a fixed result set for any query.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{data: [][]driver.Value{
+		{int64(1), "one", 1.5, true, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), []byte("7")},
+		{int64(2), nil, 2.5, false, time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC), []byte("8")},
+	}}, nil
+}
+
+type fakeRows struct {
+	data [][]driver.Value
+	i    int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "name", "score", "ok", "at", "n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.data) {
+		return io.EOF
+	}
+	copy(dest, r.data[r.i])
+	r.i++
+	return nil
+}
+func (r *fakeRows) ColumnTypeDatabaseTypeName(i int) string {
+	return []string{"BIGINT", "TEXT", "DOUBLE", "BOOLEAN", "TIMESTAMP", "INTEGER"}[i]
+}
+
+func init() {
+	sql.Register("grate-fake", fakeDriver{})
+}
+
+func TestFromSQLRows(t *testing.T) {
+	db, err := sql.Open("grate-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := FromSQLRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsEmpty() {
+		t.Fatal("expected IsEmpty before the first record")
+	}
+
+	if !c.Next() {
+		t.Fatalf("expected a record, got %v", c.Err())
+	}
+	if c.IsEmpty() {
+		t.Fatal("unexpected IsEmpty after the first record")
+	}
+	if got, want := c.Strings(), []string{"1", "one", "1.5", "true", "2021-03-04T00:00:00Z", "7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Strings() = %q, expected %q", got, want)
+	}
+	if got, want := c.Types(), []string{"integer", "string", "float", "boolean", "date", "integer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Types() = %q, expected %q", got, want)
+	}
+	if got := c.Formats(); got[0] != "General" || len(got) != 6 {
+		t.Errorf("unexpected Formats() %q", got)
+	}
+	want := []interface{}{int64(1), "one", 1.5, true, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), int64(7)}
+	if got := c.Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %#v, expected %#v", got, want)
+	}
+
+	if !c.Next() {
+		t.Fatalf("expected a second record, got %v", c.Err())
+	}
+	var id int64
+	var name string
+	var score float64
+	var ok bool
+	var at time.Time
+	if err := c.Scan(&id, &name, &score, &ok, &at); err != nil || id != 2 || name != "" || score != 2.5 || ok {
+		t.Errorf("Scan = %d, %q, %v, %v, %v", id, name, score, ok, err)
+	}
+	if want := time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC); at != want {
+		t.Errorf("Scan time = %v, expected %v", at, want)
+	}
+	if c.Types()[1] != "blank" {
+		t.Errorf("expected a blank type for NULL, got %q", c.Types()[1])
+	}
+	if c.Next() || c.Err() != nil || c.Row() != 1 {
+		t.Errorf("expected the end of the rows, got %v at row %d", c.Err(), c.Row())
+	}
+}
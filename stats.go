@@ -0,0 +1,84 @@
+package grate
+
+// SourceStats summarizes the contents of a Source.
+type SourceStats struct {
+	// Format is the registered name of the file format, e.g. "xlsx".
+	Format string
+
+	// SheetCount is the number of collections in the source.
+	SheetCount int
+
+	// Sheets describes each collection, in the order returned by List.
+	Sheets []SheetStats
+}
+
+// SheetStats describes the size of a collection.
+type SheetStats struct {
+	Name string
+
+	// EstimatedRows is the number of records, as declared by the file or
+	// estimated from its contents, so it may not be exact.
+	EstimatedRows int
+
+	// MaxCols is the (declared or estimated) number of columns.
+	MaxCols int
+}
+
+// StatsSource is implemented by Sources that can estimate the size of
+// their collections without parsing them in full.
+type StatsSource interface {
+	Source
+
+	// SheetStats returns the statistics of each collection in the source.
+	SheetStats() ([]SheetStats, error)
+}
+
+// Stats opens a tabular data file and returns statistics about its
+// contents. Formats implementing StatsSource provide estimates without a
+// full parse; for other formats every collection is iterated.
+func Stats(filename string) (*SourceStats, error) {
+	src, format, err := openFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	res := &SourceStats{Format: format}
+	if ss, ok := Unwrap(src).(StatsSource); ok {
+		res.Sheets, err = ss.SheetStats()
+	} else {
+		res.Sheets, err = countSheets(src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.SheetCount = len(res.Sheets)
+	return res, nil
+}
+
+// countSheets iterates all records of the source to find their sizes.
+func countSheets(src Source) ([]SheetStats, error) {
+	names, err := src.List()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]SheetStats, 0, len(names))
+	for _, name := range names {
+		c, err := src.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		st := SheetStats{Name: name}
+		for c.Next() {
+			st.EstimatedRows++
+			if n := len(c.Strings()); n > st.MaxCols {
+				st.MaxCols = n
+			}
+		}
+		if err = c.Err(); err != nil {
+			return nil, err
+		}
+		res = append(res, st)
+	}
+	return res, nil
+}
@@ -0,0 +1,42 @@
+package grate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	tab := srcTable
+	t.Cleanup(func() { srcTable = tab })
+	srcTable = nil
+
+	Register("test", 1, func(filename string) (Source, error) {
+		if filename != "data.test" {
+			return nil, ErrNotInFormat
+		}
+		return &testSource{
+			names: []string{"a", "b"},
+			colls: map[string]*testCollection{
+				"a": newTestCollection([]string{"1", "2"}, []string{"3", "4", "5"}),
+				"b": newTestCollection(),
+			},
+		}, nil
+	})
+
+	st, err := Stats("data.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SourceStats{
+		Format:     "test",
+		SheetCount: 2,
+		Sheets:     []SheetStats{{"a", 2, 3}, {"b", 0, 0}},
+	}
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("got %+v, expected %+v", st, want)
+	}
+
+	if _, err = Stats("other.file"); err != ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
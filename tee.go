@@ -0,0 +1,167 @@
+package grate
+
+import "sync"
+
+// Tee returns two Collections which each produce the same sequence of
+// records as c, and can be advanced independently (including from
+// different goroutines). Records read by one output but not yet by the
+// other are buffered in memory, so neither output blocks the other. The
+// buffer is unbounded: if one output falls behind (or is abandoned), every
+// record read by the other is kept until it catches up. Use TeeBuffer to
+// bound it. Records are copied from c when buffered, and the slices
+// returned by both outputs are shared, so they must not be modified.
+//
+// The outputs take ownership of c, which must not be used directly
+// afterwards.
+func Tee(c Collection) (Collection, Collection) {
+	return TeeBuffer(c, 0)
+}
+
+// TeeBuffer is like Tee, but buffers at most size records (or any number
+// if size is 0). When the buffer is full, Next on the output which is
+// ahead blocks until the other output reads a record, so the outputs must
+// be advanced from different goroutines.
+func TeeBuffer(c Collection, size int) (Collection, Collection) {
+	t := &tee{src: c, size: size}
+	t.cond = sync.NewCond(&t.mu)
+	return &teeCollection{t: t, id: 0, row: -1}, &teeCollection{t: t, id: 1, row: -1}
+}
+
+type teeRecord struct {
+	strs    []string
+	types   []string
+	values  []interface{}
+	formats []string
+}
+
+// tee holds the records of the shared collection which have not yet been
+// read by both outputs.
+type tee struct {
+	mu   sync.Mutex
+	cond *sync.Cond // signalled when records are dropped
+	size int        // maximum number of buffered records, or 0
+	src  Collection
+	buf  []teeRecord
+	base int    // row index of buf[0]
+	next [2]int // next row index for each output
+	done bool
+	err  error
+}
+
+// advance returns the next record for output id.
+func (t *tee) advance(id int) (teeRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := t.next[id]
+	for t.size > 0 && n-t.base >= len(t.buf) && len(t.buf) >= t.size {
+		// wait for the other output to read the oldest record
+		t.cond.Wait()
+	}
+	if n-t.base >= len(t.buf) {
+		if t.done || !t.src.Next() {
+			if !t.done {
+				t.done = true
+				t.err = t.src.Err()
+			}
+			return teeRecord{}, false
+		}
+		// copy the record, as collections may reuse their slices
+		t.buf = append(t.buf, teeRecord{
+			strs:    append([]string(nil), t.src.Strings()...),
+			types:   append([]string(nil), t.src.Types()...),
+			values:  append([]interface{}(nil), t.src.Values()...),
+			formats: append([]string(nil), t.src.Formats()...),
+		})
+	}
+	rec := t.buf[n-t.base]
+	t.next[id]++
+
+	// drop records that both outputs have read
+	low := t.next[0]
+	if t.next[1] < low {
+		low = t.next[1]
+	}
+	if drop := low - t.base; drop > 0 {
+		t.buf = t.buf[drop:]
+		t.base = low
+		t.cond.Broadcast()
+	}
+	return rec, true
+}
+
+type teeCollection struct {
+	t   *tee
+	id  int
+	rec teeRecord
+	row int
+	end bool
+}
+
+// Next advances to the next record of content.
+func (c *teeCollection) Next() bool {
+	if c.end {
+		return false
+	}
+	rec, ok := c.t.advance(c.id)
+	if !ok {
+		c.end = true
+		return false
+	}
+	c.rec = rec
+	c.row++
+	return true
+}
+
+// Row returns the zero-based index of the current record.
+func (c *teeCollection) Row() int {
+	return c.row
+}
+
+// Strings extracts values from the current record into a list of strings.
+func (c *teeCollection) Strings() []string {
+	return c.rec.strs
+}
+
+// Types extracts the data types from the current record into a list.
+func (c *teeCollection) Types() []string {
+	return c.rec.types
+}
+
+// Values extracts the native values of the current record into a list, as
+// returned by the source collection.
+func (c *teeCollection) Values() []interface{} {
+	return c.rec.values
+}
+
+// Formats extracts the format codes for the current record into a list.
+func (c *teeCollection) Formats() []string {
+	return c.rec.formats
+}
+
+// Scan extracts values from the current record into the provided arguments.
+// Native values are stored directly, as for the source collection.
+func (c *teeCollection) Scan(args ...interface{}) error {
+	return scanValues(c.rec.values, c.rec.strs, args)
+}
+
+// IsEmpty returns true if there are no data values.
+func (c *teeCollection) IsEmpty() bool {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	return c.t.src.IsEmpty()
+}
+
+// Width returns the number of columns of the source collection.
+func (c *teeCollection) Width() int {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	return c.t.src.Width()
+}
+
+// Err returns the last error that occured.
+func (c *teeCollection) Err() error {
+	c.t.mu.Lock()
+	defer c.t.mu.Unlock()
+	return c.t.err
+}
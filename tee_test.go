@@ -0,0 +1,123 @@
+package grate
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTee(t *testing.T) {
+	rows := [][]string{{"a", "b"}, {"1", "2"}, {"3", ""}}
+	src := newTestCollection(rows...)
+	src.err = errors.New("test: read failed")
+	c1, c2 := Tee(src)
+
+	var wg sync.WaitGroup
+	got := make([][][]string, 2)
+	for i, c := range []Collection{c1, c2} {
+		wg.Add(1)
+		go func(i int, c Collection) {
+			defer wg.Done()
+			for c.Next() {
+				got[i] = append(got[i], c.Strings())
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i := range got {
+		if !reflect.DeepEqual(got[i], rows) {
+			t.Errorf("output %d: got %v, expected %v", i, got[i], rows)
+		}
+	}
+	if c1.Err() != src.err || c2.Err() != src.err {
+		t.Errorf("expected shared error, got %v and %v", c1.Err(), c2.Err())
+	}
+}
+
+func TestTeeBuffering(t *testing.T) {
+	c1, c2 := Tee(newTestCollection([]string{"a"}, []string{"b"}, []string{"c"}))
+	for c1.Next() {
+	}
+	if c1.Row() != 2 {
+		t.Fatalf("expected row 2, got %d", c1.Row())
+	}
+	n := 0
+	for c2.Next() {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 buffered rows, got %d", n)
+	}
+}
+
+// reusingCollection returns the same slice for every record, as some
+// formats do.
+type reusingCollection struct {
+	*testCollection
+	buf []string
+}
+
+func (r *reusingCollection) Strings() []string {
+	r.buf = append(r.buf[:0], r.testCollection.Strings()...)
+	return r.buf
+}
+
+func TestTeeCopiesRecords(t *testing.T) {
+	rows := [][]string{{"a"}, {"b"}, {"c"}}
+	c1, c2 := Tee(&reusingCollection{testCollection: newTestCollection(rows...)})
+	for c1.Next() {
+	}
+	var got [][]string
+	for c2.Next() {
+		got = append(got, c2.Strings())
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("got %v, expected %v", got, rows)
+	}
+}
+
+func TestTeeNativeValues(t *testing.T) {
+	date := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tc := newTestCollection([]string{"3/4/21 5:06", "1,234"})
+	tc.types = [][]string{{"date", "integer"}}
+	tc.values = [][]interface{}{{date, int64(1234)}}
+	c1, c2 := Tee(tc)
+	for _, c := range []Collection{c1, c2} {
+		c.Next()
+		if got := c.Values(); !reflect.DeepEqual(got, tc.values[0]) {
+			t.Errorf("got %#v, expected %#v", got, tc.values[0])
+		}
+	}
+}
+
+func TestTeeBufferSize(t *testing.T) {
+	var rows [][]string
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []string{"x"})
+	}
+	c1, c2 := TeeBuffer(newTestCollection(rows...), 3)
+	tb := c1.(*teeCollection).t
+	done := make(chan int)
+	go func() {
+		n := 0
+		for c1.Next() {
+			n++
+		}
+		done <- n
+	}()
+	n := 0
+	for c2.Next() {
+		n++
+		tb.mu.Lock()
+		if len(tb.buf) > 3 {
+			t.Errorf("buffered %d records", len(tb.buf))
+		}
+		tb.mu.Unlock()
+	}
+	if n1 := <-done; n != 100 || n1 != 100 {
+		t.Errorf("got %d and %d rows, expected 100", n1, n)
+	}
+}
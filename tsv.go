@@ -0,0 +1,97 @@
+package grate
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+type tsvConfig struct {
+	trimSpace      bool
+	removeNewlines bool
+}
+
+// TSVOption configures the output of a TSVWriter.
+type TSVOption func(*tsvConfig)
+
+// TSVTrimSpace removes leading and trailing whitespace from values.
+func TSVTrimSpace(enabled bool) TSVOption {
+	return func(c *tsvConfig) { c.trimSpace = enabled }
+}
+
+// TSVRemoveNewlines replaces embedded tabs and line breaks in values, and
+// runs of spaces, with a single space. It is enabled by default, so that
+// the output is valid TSV. When disabled, values are written as-is, and
+// values containing tabs or line breaks corrupt the output.
+func TSVRemoveNewlines(enabled bool) TSVOption {
+	return func(c *tsvConfig) { c.removeNewlines = enabled }
+}
+
+// tsvSpaces matches the whitespace condensed by TSVRemoveNewlines.
+var tsvSpaces = regexp.MustCompile("[ \n\r\t]+")
+
+// TSVWriter writes the records of a Collection as tab-separated values.
+// It implements io.WriterTo, streaming the collection to a file or HTTP
+// response without building the output in memory.
+type TSVWriter struct {
+	c   Collection
+	cfg tsvConfig
+}
+
+// NewTSVWriter returns a TSVWriter for the remaining records of c.
+func NewTSVWriter(c Collection, opts ...TSVOption) *TSVWriter {
+	t := &TSVWriter{c: c, cfg: tsvConfig{removeNewlines: true}}
+	for _, o := range opts {
+		o(&t.cfg)
+	}
+	return t
+}
+
+// WriteTo writes the remaining records of the collection to w, each on its
+// own line with the values separated by tabs. It returns the number of
+// bytes written.
+func (t *TSVWriter) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for t.c.Next() {
+		for i, v := range t.c.Strings() {
+			if i > 0 {
+				bw.WriteByte('\t')
+			}
+			bw.WriteString(t.clean(v))
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return cw.n, err
+		}
+	}
+	if err := t.c.Err(); err != nil {
+		bw.Flush()
+		return cw.n, err
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// clean applies the sanitisation options to a value.
+func (t *TSVWriter) clean(v string) string {
+	if t.cfg.removeNewlines && strings.ContainsAny(v, " \n\r\t") {
+		v = tsvSpaces.ReplaceAllString(v, " ")
+	}
+	if t.cfg.trimSpace {
+		v = strings.TrimSpace(v)
+	}
+	return v
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,49 @@
+package grate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestTSVWriter(t *testing.T) {
+	rows := [][]string{
+		{"name", "notes"},
+		{"  alice ", "line one\nline\ttwo"},
+		{"bob"},
+	}
+	for _, tc := range []struct {
+		opts   []TSVOption
+		expect string
+	}{
+		{nil, "name\tnotes\n alice \tline one line two\nbob\n"},
+		{[]TSVOption{TSVTrimSpace(true)}, "name\tnotes\nalice\tline one line two\nbob\n"},
+		{[]TSVOption{TSVRemoveNewlines(false)}, "name\tnotes\n  alice \tline one\nline\ttwo\nbob\n"},
+	} {
+		var buf bytes.Buffer
+		var wt io.WriterTo = NewTSVWriter(newTestCollection(rows...), tc.opts...)
+		n, err := wt.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.expect {
+			t.Errorf("got %q, expected %q", got, tc.expect)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("returned %d bytes, wrote %d", n, buf.Len())
+		}
+	}
+}
+
+func TestTSVWriterError(t *testing.T) {
+	c := newTestCollection([]string{"a"}, []string{"b"})
+	c.err = errors.New("test: broken")
+	n, err := NewTSVWriter(c).WriteTo(io.Discard)
+	if err != c.err {
+		t.Errorf("expected the collection error, got %v", err)
+	}
+	if n != 4 {
+		t.Errorf("expected 4 bytes written before the error, got %d", n)
+	}
+}
@@ -0,0 +1,35 @@
+package grate
+
+// VisitCells calls fn for each non-blank cell of the remaining records of
+// c, in row order, with the zero-based row (as returned by Row) and column
+// of the cell, and its string value, type and number format. Cells of type
+// "blank" and cells with an empty value are skipped.
+func VisitCells(c Collection, fn func(row, col int, value, typ, format string)) error {
+	return visitCells(c, false, fn)
+}
+
+// VisitAllCells is like VisitCells, but also calls fn for blank cells.
+func VisitAllCells(c Collection, fn func(row, col int, value, typ, format string)) error {
+	return visitCells(c, true, fn)
+}
+
+func visitCells(c Collection, blanks bool, fn func(row, col int, value, typ, format string)) error {
+	for c.Next() {
+		row := c.Row()
+		vals, types, formats := c.Strings(), c.Types(), c.Formats()
+		for i, v := range vals {
+			typ, format := "", ""
+			if i < len(types) {
+				typ = types[i]
+			}
+			if i < len(formats) {
+				format = formats[i]
+			}
+			if !blanks && (v == "" || typ == "blank") {
+				continue
+			}
+			fn(row, i, v, typ, format)
+		}
+	}
+	return c.Err()
+}
@@ -0,0 +1,33 @@
+package grate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestVisitCells(t *testing.T) {
+	rows := [][]string{{"a", "", "c"}, {}, {"", "e"}}
+	var got []string
+	visit := func(row, col int, value, typ, format string) {
+		got = append(got, fmt.Sprintf("%d,%d=%s(%s,%s)", row, col, value, typ, format))
+	}
+
+	if err := VisitCells(newTestCollection(rows...), visit); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"0,0=a(string,General)", "0,2=c(string,General)", "2,1=e(string,General)"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got %v, expected %v", got, expect)
+	}
+
+	got = nil
+	if err := VisitAllCells(newTestCollection(rows...), visit); err != nil {
+		t.Fatal(err)
+	}
+	expect = []string{"0,0=a(string,General)", "0,1=(blank,General)", "0,2=c(string,General)",
+		"2,0=(blank,General)", "2,1=e(string,General)"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("got %v, expected %v", got, expect)
+	}
+}
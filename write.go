@@ -0,0 +1,64 @@
+package grate
+
+// WritableSource is a destination for tabular data, such as a file being
+// created in one of the supported formats.
+type WritableSource interface {
+	// Create adds a new collection with the given name, and returns it for
+	// writing records.
+	Create(sheetName string) (WritableCollection, error)
+
+	// Save finishes writing all collections, and releases the resources
+	// of the source. It must be called once, after all records are written.
+	Save() error
+}
+
+// WritableCollection receives the records of a collection being written.
+type WritableCollection interface {
+	// WriteRow writes a record of string values.
+	WriteRow(values []string) error
+
+	// WriteTypedRow writes a record of native values, as returned by
+	// Collection.Values. Formats without type information write them as
+	// strings.
+	WriteTypedRow(values []interface{}) error
+}
+
+// Write writes the remaining records of c to w, using their string values.
+func Write(c Collection, w WritableCollection) error {
+	for c.Next() {
+		if err := w.WriteRow(c.Strings()); err != nil {
+			return err
+		}
+	}
+	return c.Err()
+}
+
+// Copy writes every collection of src into a collection of the same name
+// in dst, and then saves dst. Collections are copied in the order returned
+// by List. If copying fails, dst is still saved to release its resources,
+// and the copy error is returned.
+func Copy(src Source, dst WritableSource) (err error) {
+	defer func() {
+		if serr := dst.Save(); err == nil {
+			err = serr
+		}
+	}()
+	names, err := src.List()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		c, err := src.Get(name)
+		if err != nil {
+			return err
+		}
+		w, err := dst.Create(name)
+		if err != nil {
+			return err
+		}
+		if err = Write(c, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
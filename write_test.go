@@ -0,0 +1,66 @@
+package grate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testWritableSource struct {
+	names []string
+	rows  map[string][][]string
+	saved bool
+}
+
+type testWritableCollection struct {
+	src  *testWritableSource
+	name string
+}
+
+func (s *testWritableSource) Create(name string) (WritableCollection, error) {
+	s.names = append(s.names, name)
+	return &testWritableCollection{src: s, name: name}, nil
+}
+
+func (s *testWritableSource) Save() error {
+	s.saved = true
+	return nil
+}
+
+func (c *testWritableCollection) WriteRow(values []string) error {
+	c.src.rows[c.name] = append(c.src.rows[c.name], values)
+	return nil
+}
+
+func (c *testWritableCollection) WriteTypedRow(values []interface{}) error {
+	return errors.New("not supported")
+}
+
+func TestCopy(t *testing.T) {
+	src := &testSource{
+		names: []string{"b", "a"},
+		colls: map[string]*testCollection{
+			"a": newTestCollection([]string{"1", "2"}),
+			"b": newTestCollection([]string{"x"}, []string{"y"}),
+		},
+	}
+	dst := &testWritableSource{rows: make(map[string][][]string)}
+	if err := Copy(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if !dst.saved || !reflect.DeepEqual(dst.names, []string{"b", "a"}) {
+		t.Fatalf("unexpected destination state %v, saved %v", dst.names, dst.saved)
+	}
+	expect := map[string][][]string{"a": {{"1", "2"}}, "b": {{"x"}, {"y"}}}
+	if !reflect.DeepEqual(dst.rows, expect) {
+		t.Errorf("got %q, expected %q", dst.rows, expect)
+	}
+
+	failing := newTestCollection([]string{"1"})
+	failing.err = errors.New("read failed")
+	src.colls["a"] = failing
+	dst = &testWritableSource{rows: make(map[string][][]string)}
+	if err := Copy(src, dst); err != failing.err || !dst.saved {
+		t.Errorf("expected the read error after saving, got %v (saved %v)", err, dst.saved)
+	}
+}
@@ -77,9 +77,12 @@ type directory struct {
 }
 
 func (d *directory) String() string {
-	if (d.NameByteLen&1) == 1 || d.NameByteLen > 64 {
+	if (d.NameByteLen&1) == 1 || d.NameByteLen > 64 || d.NameByteLen < 0 {
 		return "<invalid utf16 string>"
 	}
+	if d.NameByteLen == 0 {
+		return ""
+	}
 	r16 := utf16.Decode(d.Name[:int(d.NameByteLen)/2])
 	// trim off null terminator
 	return string(r16[:len(r16)-1])
@@ -100,6 +103,18 @@ type Document struct {
 
 	ministreamstart uint32
 	ministreamsize  uint32
+
+	// streams of a document created with NewDocument
+	writable bool
+	created  []*streamWriter
+}
+
+// compound file signature, as a little-endian integer
+const signature = 0xe11ab1a1e011cfd0
+
+// HasSignature returns true if data starts with the compound file signature.
+func HasSignature(data []byte) bool {
+	return len(data) >= 8 && binary.LittleEndian.Uint64(data) == signature
 }
 
 func (d *Document) load(rx io.ReadSeeker) error {
@@ -112,7 +127,7 @@ func (d *Document) load(rx io.ReadSeeker) error {
 
 	h := &header{}
 	err = binary.Read(br, binary.LittleEndian, h)
-	if h.Signature != 0xe11ab1a1e011cfd0 {
+	if h.Signature != signature {
 		return grate.ErrNotInFormat // errors.New("ole2: invalid format")
 	}
 	if h.ByteOrder != 0xFFFE {
@@ -157,6 +172,13 @@ func (d *Document) load(rx io.ReadSeeker) error {
 	}
 	d.header = h
 
+	// the sector counts size the tables below, so must fit in the file
+	maxSectors := len(d.data) >> h.SectorShift
+	if h.NumFATSectors < 0 || int(h.NumFATSectors) > maxSectors ||
+		h.NumMiniFATSectors < 0 || int(h.NumMiniFATSectors) > maxSectors {
+		return errors.New("xls/cfb: invalid sector count")
+	}
+
 	numFATentries := (1 << (h.SectorShift - 2))
 	le := binary.LittleEndian
 	d.fat = make([]uint32, 0, numFATentries*int(1+d.header.NumFATSectors))
@@ -168,69 +190,53 @@ func (d *Document) load(rx io.ReadSeeker) error {
 		if sid == secFree {
 			break
 		}
-		offs := int64(1+sid) << int32(h.SectorShift)
-		if offs >= int64(len(d.data)) {
+		sector := d.sector(sid)
+		if sector == nil {
 			return errors.New("xls/cfb: unable to load file")
 		}
-		sector := d.data[offs:]
-		for j := 0; j < numFATentries; j++ {
-			sid2 := le.Uint32(sector)
-			d.fat = append(d.fat, sid2)
-			sector = sector[4:]
-		}
+		d.fat = appendSectorIDs(d.fat, sector)
 	}
 	if h.NumDIFATSectors > 0 {
 		sid1 := h.FirstDIFATSectorLocation
 
-		for sid1 != secEndOfChain {
-			offs := int64(1+sid1) << int32(h.SectorShift)
-			difatSector := d.data[offs:]
+		for n := 0; sid1 != secEndOfChain && sid1 != secFree; n++ {
+			difatSector := d.sector(sid1)
+			if n > maxSectors || len(difatSector) < numFATentries*4 {
+				return errors.New("xls/cfb: unable to load file")
+			}
 
 			for i := 0; i < numFATentries-1; i++ {
-				sid2 := le.Uint32(difatSector)
+				sid2 := le.Uint32(difatSector[i*4:])
 				if sid2 == secFree || sid2 == secEndOfChain {
-					difatSector = difatSector[4:]
 					continue
 				}
 
-				offs := int64(1+sid2) << int32(h.SectorShift)
-				if offs >= int64(len(d.data)) {
+				sector := d.sector(sid2)
+				if sector == nil {
 					return errors.New("xls/cfb: unable to load file")
 				}
-				sector := d.data[offs:]
-				for j := 0; j < numFATentries; j++ {
-					sid3 := le.Uint32(sector)
-					d.fat = append(d.fat, sid3)
-					sector = sector[4:]
-				}
-
-				difatSector = difatSector[4:]
+				d.fat = appendSectorIDs(d.fat, sector)
 			}
 			// chain the next DIFAT sector
-			sid1 = le.Uint32(difatSector)
+			sid1 = le.Uint32(difatSector[(numFATentries-1)*4:])
 		}
 	}
 
 	// step 2: read the mini FAT
 	sid := h.FirstMiniFATSectorLocation
-	for sid != secEndOfChain {
-		offs := int64(1+sid) << int32(h.SectorShift)
-		if offs >= int64(len(d.data)) {
+	for sid != secEndOfChain && sid != secFree {
+		sector := d.sector(sid)
+		if sector == nil {
 			return errors.New("xls/cfb: unable to load file")
 		}
-		sector := d.data[offs:]
-		for j := 0; j < numFATentries; j++ {
-			sid = le.Uint32(sector)
-			d.minifat = append(d.minifat, sid)
-			sector = sector[4:]
-		}
+		d.minifat = appendSectorIDs(d.minifat, sector)
 
-		if len(d.minifat) >= int(h.NumMiniFATSectors) {
+		if len(d.minifat) >= numFATentries*int(h.NumMiniFATSectors) || sid >= uint32(len(d.fat)) {
 			break
 		}
 
 		// chain the next mini FAT sector
-		sid = le.Uint32(sector)
+		sid = d.fat[sid]
 	}
 
 	// step 3: read the Directory Entries
@@ -239,60 +245,95 @@ func (d *Document) load(rx io.ReadSeeker) error {
 	return err
 }
 
+// sector returns the data of sector sid, which is shorter than a sector if
+// the file is truncated, or nil if the sector is not in the file.
+func (d *Document) sector(sid uint32) []byte {
+	offs := (int64(sid) + 1) << d.header.SectorShift
+	if offs >= int64(len(d.data)) {
+		return nil
+	}
+	end := offs + int64(1)<<d.header.SectorShift
+	if end > int64(len(d.data)) {
+		end = int64(len(d.data))
+	}
+	return d.data[offs:end]
+}
+
+// appendSectorIDs appends the sector numbers stored in a FAT sector to ids.
+func appendSectorIDs(ids []uint32, sector []byte) []uint32 {
+	for ; len(sector) >= 4; sector = sector[4:] {
+		ids = append(ids, binary.LittleEndian.Uint32(sector))
+	}
+	return ids
+}
+
 func (d *Document) buildDirs(br *bytes.Reader) error {
 	h := d.header
-	le := binary.LittleEndian
 
-	// step 2: read the Directory
+	// step 2: read the Directory, following the sector chain
 	sid := h.FirstDirectorySectorLocation
-	offs := int64(1+sid) << int64(h.SectorShift)
-	br.Seek(offs, io.SeekStart)
-
-	for j := 0; j < 4; j++ {
-		dirent := &directory{}
-		binary.Read(br, le, dirent)
-		if d.header.MajorVersion == 3 {
-			// mask out upper 32bits
-			dirent.StreamSize = dirent.StreamSize & 0xFFFFFFFF
+	perSector := (1 << h.SectorShift) / 128
+	for n := 0; sid < uint32(len(d.fat)) && n < len(d.fat); n++ {
+		offs := int64(1+sid) << int64(h.SectorShift)
+		br.Seek(offs, io.SeekStart)
+		for j := 0; j < perSector; j++ {
+			if !d.readDir(br) {
+				return nil
+			}
 		}
+		sid = d.fat[sid]
+	}
+	return nil
+}
 
-		switch dirent.ObjectType {
-		case typeRootStorage:
-			d.ministreamstart = uint32(dirent.StartingSectorLocation)
-			d.ministreamsize = uint32(dirent.StreamSize)
-		case typeStorage:
-			//log.Println("got a storage? what to do now?")
-		case typeStream:
-			/*
-				var freader io.Reader
-				if dirent.StreamSize < uint64(d.header.MiniStreamCutoffSize) {
-					freader = d.getMiniStreamReader(uint32(dirent.StartingSectorLocation), dirent.StreamSize)
-				} else if dirent.StreamSize != 0 {
-					freader = d.getStreamReader(uint32(dirent.StartingSectorLocation), dirent.StreamSize)
-				}
-			*/
-		case typeUnknown:
-			return nil
-		}
-		d.dir = append(d.dir, dirent)
+// readDir reads the next directory entry, returning false at the end of
+// the directory.
+func (d *Document) readDir(br *bytes.Reader) bool {
+	dirent := &directory{}
+	if err := binary.Read(br, binary.LittleEndian, dirent); err != nil {
+		return false
+	}
+	if d.header.MajorVersion == 3 {
+		// mask out upper 32bits
+		dirent.StreamSize = dirent.StreamSize & 0xFFFFFFFF
 	}
 
-	return nil
+	switch dirent.ObjectType {
+	case typeRootStorage:
+		d.ministreamstart = uint32(dirent.StartingSectorLocation)
+		d.ministreamsize = uint32(dirent.StreamSize)
+	case typeStorage:
+		//log.Println("got a storage? what to do now?")
+	case typeStream:
+		/*
+			var freader io.Reader
+			if dirent.StreamSize < uint64(d.header.MiniStreamCutoffSize) {
+				freader = d.getMiniStreamReader(uint32(dirent.StartingSectorLocation), dirent.StreamSize)
+			} else if dirent.StreamSize != 0 {
+				freader = d.getStreamReader(uint32(dirent.StartingSectorLocation), dirent.StreamSize)
+			}
+		*/
+	case typeUnknown:
+		return false
+	}
+	d.dir = append(d.dir, dirent)
+	return true
 }
 
 func (d *Document) getStreamReader(sid uint32, size uint64) (io.ReadSeeker, error) {
+	if size > uint64(len(d.data)) {
+		return nil, errors.New("ole2: corrupt data format")
+	}
 	// NB streamData is a slice of slices of the raw data, so this is the
 	// only allocation - for the (much smaller) list of sector slices
 	streamData := make([][]byte, 1+(size>>d.header.SectorShift))
 
 	x := 0
-	secSize := int64(1) << int32(d.header.SectorShift)
 	for sid != secEndOfChain && sid != secFree {
-		offs := int64(1+sid) << int64(d.header.SectorShift)
-		if offs > int64(len(d.data)) {
+		slice := d.sector(sid)
+		if slice == nil || x >= len(streamData) {
 			return nil, errors.New("ole2: corrupt data format")
 		}
-		slice := d.data[offs : offs+secSize]
 		if size < uint64(len(slice)) {
 			slice = slice[:size]
 			size = 0
@@ -303,6 +344,9 @@ func (d *Document) getStreamReader(sid uint32, size uint64) (io.ReadSeeker, erro
 		if size == 0 {
 			break
 		}
+		if sid >= uint32(len(d.fat)) {
+			return nil, errors.New("ole2: corrupt data format")
+		}
 		sid = d.fat[sid]
 		x++
 	}
@@ -314,6 +358,9 @@ func (d *Document) getStreamReader(sid uint32, size uint64) (io.ReadSeeker, erro
 }
 
 func (d *Document) getMiniStreamReader(sid uint32, size uint64) (io.ReadSeeker, error) {
+	if size > uint64(len(d.data)) || d.ministreamsize > uint32(len(d.data)) {
+		return nil, errors.New("ole2: corrupt data format")
+	}
 	// TODO: move into a separate cache so we don't recalculate it each time
 	fatStreamData := make([][]byte, 1+(d.ministreamsize>>d.header.SectorShift))
 
@@ -324,10 +371,11 @@ func (d *Document) getMiniStreamReader(sid uint32, size uint64) (io.ReadSeeker,
 	x := 0
 	fsid := d.ministreamstart
 	fsize := uint64(d.ministreamsize)
-	secSize := int64(1) << int64(d.header.SectorShift)
 	for fsid != secEndOfChain && fsid != secFree {
-		offs := int64(1+fsid) << int64(d.header.SectorShift)
-		slice := d.data[offs : offs+secSize]
+		slice := d.sector(fsid)
+		if slice == nil || x >= len(fatStreamData) || fsid >= uint32(len(d.fat)) {
+			return nil, errors.New("ole2: corrupt data format")
+		}
 		if fsize < uint64(len(slice)) {
 			slice = slice[:fsize]
 			fsize = 0
@@ -340,12 +388,19 @@ func (d *Document) getMiniStreamReader(sid uint32, size uint64) (io.ReadSeeker,
 	}
 
 	x = 0
+	secSize := int64(1) << int64(d.header.SectorShift)
 	miniSecSize := int64(1) << int64(d.header.MiniSectorShift)
 	for sid != secEndOfChain && sid != secFree {
 		offs := int64(sid) << int64(d.header.MiniSectorShift)
 
 		so, si := offs/secSize, offs%secSize
+		if so >= int64(len(fatStreamData)) || x >= len(streamData) || sid >= uint32(len(d.minifat)) {
+			return nil, errors.New("ole2: corrupt data format")
+		}
 		data := fatStreamData[so]
+		if si+miniSecSize > int64(len(data)) {
+			return nil, errors.New("ole2: corrupt data format")
+		}
 
 		slice := data[si : si+miniSecSize]
 		if size < uint64(len(slice)) {
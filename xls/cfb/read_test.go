@@ -0,0 +1,98 @@
+package cfb
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// the Workbook streams of the test files, as read before the directory
+// chain was followed
+var testWorkbooks = map[string]string{
+	"basic.xls":      "81835abe58cd11f5f33f1baf6e280d6965777144",
+	"basic2.xls":     "3ec8a847898ba633f5d7b66d78745f76eca80257",
+	"multi_test.xls": "611deee2f36a65d5ada9936321b3923d0083f66c",
+	"testing.xls":    "3a603c4b24b0a2fee1f88741e3d740eff92bdf39",
+}
+
+func TestReadTestData(t *testing.T) {
+	// the summary streams are in the second directory sector
+	want := []string{"\x01Ole", "\x01CompObj", "Workbook", "\x05SummaryInformation", "\x05DocumentSummaryInformation"}
+	for fn, sum := range testWorkbooks {
+		d, err := Open("../../testdata/" + fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, _ := d.List()
+		if !reflect.DeepEqual(list, want) {
+			t.Errorf("%s: unexpected streams %q", fn, list)
+		}
+		r, err := d.Open("Workbook")
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha1.New()
+		if _, err = io.Copy(h, r); err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+			t.Errorf("%s: Workbook stream has sha1 %s, expected %s", fn, got, sum)
+		}
+	}
+}
+
+func TestReadDirectoryEnd(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/basic.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// mark the Workbook entry (the 4th) as unused, which ends the directory
+	sid := binary.LittleEndian.Uint32(data[48:52])
+	offs := (1+int(sid))<<9 + 3*128 + 66
+	data[offs] = byte(typeUnknown)
+
+	d, err := OpenReader(io.NopCloser(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := d.List()
+	if !reflect.DeepEqual(list, []string{"\x01Ole", "\x01CompObj"}) {
+		t.Errorf("unexpected streams %q", list)
+	}
+}
+
+func TestReadMiniFATChain(t *testing.T) {
+	// 200 mini streams of 64 bytes need two mini FAT sectors
+	doc := NewDocument()
+	for i := 0; i < 200; i++ {
+		w, _ := doc.CreateStream(fmt.Sprintf("S%d", i))
+		w.Write(bytes.Repeat([]byte{byte(i)}, 64))
+		w.Close()
+	}
+	var buf bytes.Buffer
+	if err := doc.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenReader(io.NopCloser(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := d.header.NumMiniFATSectors; n < 2 || len(d.minifat) != 128*int(n) {
+		t.Fatalf("read %d mini FAT entries from %d sectors", len(d.minifat), n)
+	}
+	r, err := d.Open("S199")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	if !bytes.Equal(got, bytes.Repeat([]byte{199}, 64)) {
+		t.Errorf("unexpected data %v", got)
+	}
+}
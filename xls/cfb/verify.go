@@ -0,0 +1,118 @@
+package cfb
+
+import "fmt"
+
+// CFBError describes a problem found in a sector chain by Verify.
+type CFBError struct {
+	// Chain is the name of the stream (or internal structure such as
+	// "<directory>" or "<minifat>") whose chain is damaged.
+	Chain string
+	// Sector is the sector number where the problem was found. For streams
+	// stored in the mini stream this is a mini sector number.
+	Sector uint32
+	// Message describes the problem.
+	Message string
+}
+
+func (e CFBError) Error() string {
+	return fmt.Sprintf("cfb: chain %q at sector %d: %s", e.Chain, e.Sector, e.Message)
+}
+
+// Verify walks every sector chain of the document and reports cycles,
+// references to sectors outside of the file, chains ending in free sectors
+// and sectors claimed by more than one chain. A nil result means that all
+// chains are intact.
+//
+// Verify never modifies the document, so the streams of a damaged document
+// can still be opened individually to recover as much data as possible.
+func (d *Document) Verify() []CFBError {
+	h := d.header
+	secSize := int64(1) << h.SectorShift
+	numSectors := uint32(0)
+	if n := int64(len(d.data))/secSize - 1; n > 0 {
+		numSectors = uint32(n)
+	}
+	if int(numSectors) > len(d.fat) {
+		numSectors = uint32(len(d.fat))
+	}
+
+	fat := &chainWalker{next: d.fat, limit: numSectors, owner: make(map[uint32]string)}
+	fat.walk("<directory>", h.FirstDirectorySectorLocation)
+	if h.NumMiniFATSectors > 0 {
+		fat.walk("<minifat>", h.FirstMiniFATSectorLocation)
+	}
+
+	numMini := uint32(d.ministreamsize >> h.MiniSectorShift)
+	if int(numMini) > len(d.minifat) {
+		numMini = uint32(len(d.minifat))
+	}
+	mini := &chainWalker{next: d.minifat, limit: numMini, owner: make(map[uint32]string), mini: true}
+
+	for _, e := range d.dir {
+		switch e.ObjectType {
+		case typeRootStorage:
+			if e.StreamSize > 0 {
+				fat.walk(e.String(), uint32(e.StartingSectorLocation))
+			}
+		case typeStream:
+			if e.StreamSize == 0 {
+				continue
+			}
+			if e.StreamSize < uint64(h.MiniStreamCutoffSize) {
+				mini.walk(e.String(), uint32(e.StartingSectorLocation))
+			} else {
+				fat.walk(e.String(), uint32(e.StartingSectorLocation))
+			}
+		}
+	}
+
+	return append(fat.errs, mini.errs...)
+}
+
+// chainWalker follows chains through a FAT or mini FAT, remembering which
+// chain each sector belongs to.
+type chainWalker struct {
+	next  []uint32
+	limit uint32
+	mini  bool
+	owner map[uint32]string
+	errs  []CFBError
+}
+
+func (w *chainWalker) fail(chain string, sid uint32, format string, args ...interface{}) {
+	kind := "sector"
+	if w.mini {
+		kind = "mini sector"
+	}
+	msg := kind + " " + fmt.Sprintf(format, args...)
+	w.errs = append(w.errs, CFBError{Chain: chain, Sector: sid, Message: msg})
+}
+
+// walk follows a single chain starting at sid until the end of chain
+// marker or the first problem found.
+func (w *chainWalker) walk(chain string, sid uint32) {
+	seen := make(map[uint32]bool)
+	for sid != secEndOfChain {
+		switch {
+		case sid == secFree:
+			w.fail(chain, sid, "is marked free but is still part of the chain")
+			return
+		case sid > secMaxRegular:
+			w.fail(chain, sid, "has reserved value 0x%08X in the chain", sid)
+			return
+		case sid >= w.limit:
+			w.fail(chain, sid, "is out of range (%d sectors)", w.limit)
+			return
+		case seen[sid]:
+			w.fail(chain, sid, "is visited twice, chain contains a cycle")
+			return
+		}
+		if other, ok := w.owner[sid]; ok {
+			w.fail(chain, sid, "is also claimed by %q", other)
+			return
+		}
+		seen[sid] = true
+		w.owner[sid] = chain
+		sid = w.next[sid]
+	}
+}
@@ -0,0 +1,54 @@
+package cfb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	d, err := Open("../../testdata/testing.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := d.Verify(); len(errs) != 0 {
+		t.Fatalf("expected an intact document, got %v", errs)
+	}
+
+	starts := make(map[string]uint32)
+	for _, e := range d.dir {
+		starts[e.String()] = uint32(e.StartingSectorLocation)
+	}
+	fat := append([]uint32(nil), d.fat...)
+	minifat := append([]uint32(nil), d.minifat...)
+
+	// the Root Entry (mini stream) is stored in the FAT, the small Workbook
+	// stream of this file in the mini FAT
+	root, wb := starts["Root Entry"], starts["Workbook"]
+	cases := []struct {
+		name    string
+		corrupt func()
+		chain   string
+		msg     string
+	}{
+		{"cycle", func() { d.fat[root] = root }, "Root Entry", "sector is visited twice"},
+		{"out of range", func() { d.fat[root] = secMaxRegular }, "Root Entry", "out of range"},
+		{"shared", func() { d.fat[root] = d.header.FirstDirectorySectorLocation }, "Root Entry", `claimed by "<directory>"`},
+		{"mini cycle", func() { d.minifat[wb] = wb }, "Workbook", "mini sector is visited twice"},
+		{"mini free", func() { d.minifat[wb] = secFree }, "Workbook", "marked free"},
+		{"mini shared", func() { d.minifat[wb] = starts["\x01CompObj"] }, "Workbook", `claimed by "\x01CompObj"`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			copy(d.fat, fat)
+			copy(d.minifat, minifat)
+			c.corrupt()
+			errs := d.Verify()
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %v", errs)
+			}
+			if errs[0].Chain != c.chain || !strings.Contains(errs[0].Message, c.msg) {
+				t.Errorf("unexpected error %v", errs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// sizes used when writing version 3 documents
+	writeSectorSize     = 512
+	writeMiniSectorSize = 64
+	writeMiniCutoff     = 4096
+
+	// number of FAT sector locations stored in the header
+	headerDIFATEntries = 109
+
+	// NOSTREAM, the empty directory entry reference
+	noStream uint32 = 0xFFFFFFFF
+
+	// ENDOFCHAIN as the (signed) starting sector of a directory entry
+	noSector int32 = -2
+)
+
+// NewDocument creates an empty in-memory Compound File Binary Format
+// document. Add streams to its root storage with CreateStream and write it
+// with Save.
+func NewDocument() *Document {
+	return &Document{writable: true}
+}
+
+// streamWriter buffers the contents of a stream created by CreateStream.
+type streamWriter struct {
+	name   string
+	buf    bytes.Buffer
+	closed bool
+}
+
+// Write implements the io.Writer interface.
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, fmt.Errorf("cfb: write to closed stream '%s'", s.name)
+	}
+	return s.buf.Write(p)
+}
+
+// Close finishes the stream. It must be called before Save.
+func (s *streamWriter) Close() error {
+	s.closed = true
+	return nil
+}
+
+// CreateStream creates a new stream within the root storage of a document
+// created with NewDocument. The contents written are kept in memory until
+// the document is saved. Stream names are at most 31 characters, and may
+// not contain '/', '\', ':' or '!'.
+func (d *Document) CreateStream(name string) (io.WriteCloser, error) {
+	if !d.writable {
+		return nil, errors.New("cfb: document is read-only")
+	}
+	n16 := utf16.Encode([]rune(name))
+	if len(n16) == 0 || len(n16) > 31 || strings.ContainsAny(name, "/\\:!") {
+		return nil, fmt.Errorf("cfb: invalid stream name '%s'", name)
+	}
+	for _, s := range d.created {
+		if strings.EqualFold(s.name, name) {
+			return nil, fmt.Errorf("cfb: stream '%s' already exists", name)
+		}
+	}
+	s := &streamWriter{name: name}
+	d.created = append(d.created, s)
+	return s, nil
+}
+
+// Save serialises a document created with NewDocument to w, using the
+// version 3 format (512 byte sectors). All streams must be closed. Documents
+// are limited to 109 FAT sectors, or about 6.8MB.
+func (d *Document) Save(w io.Writer) error {
+	if !d.writable {
+		return errors.New("cfb: document is read-only")
+	}
+	for _, s := range d.created {
+		if !s.closed {
+			return fmt.Errorf("cfb: stream '%s' is not closed", s.name)
+		}
+	}
+
+	// the directory: the root entry followed by the streams, in name order
+	streams := append([]*streamWriter(nil), d.created...)
+	sort.Slice(streams, func(i, j int) bool {
+		return compareNames(streams[i].name, streams[j].name) < 0
+	})
+	dir := make([]*directory, 1+len(streams))
+	dir[0] = newDirectory("Root Entry", typeRootStorage)
+	for i, s := range streams {
+		dir[1+i] = newDirectory(s.name, typeStream)
+		dir[1+i].StreamSize = uint64(s.buf.Len())
+	}
+	dir[0].ChildID = buildTree(dir, 1, len(dir), 0, treeDepth(len(streams)))
+
+	// small streams are packed into the mini stream
+	var mini bytes.Buffer
+	var minifat []uint32
+	for i, s := range streams {
+		e := dir[1+i]
+		e.StartingSectorLocation = noSector
+		if s.buf.Len() == 0 || s.buf.Len() >= writeMiniCutoff {
+			continue
+		}
+		e.StartingSectorLocation = int32(len(minifat))
+		minifat = appendChain(minifat, sectorCount(s.buf.Len(), writeMiniSectorSize))
+		mini.Write(s.buf.Bytes())
+		mini.Write(make([]byte, len(minifat)*writeMiniSectorSize-mini.Len()))
+	}
+
+	// sector counts of each part of the file
+	entriesPerSector := writeSectorSize / 4
+	numDir := sectorCount(len(dir)*128, writeSectorSize)
+	numMiniFAT := sectorCount(len(minifat)*4, writeSectorSize)
+	numMini := sectorCount(mini.Len(), writeSectorSize)
+	numData := numDir + numMiniFAT + numMini
+	for _, s := range streams {
+		if s.buf.Len() >= writeMiniCutoff {
+			numData += sectorCount(s.buf.Len(), writeSectorSize)
+		}
+	}
+	numFAT := 1
+	for numFAT*entriesPerSector < numFAT+numData {
+		numFAT++
+	}
+	if numFAT > headerDIFATEntries {
+		return errors.New("cfb: document is too large to save")
+	}
+
+	// build the FAT: FAT sectors first, then the directory, the mini FAT,
+	// the mini stream and the large streams
+	fat := make([]uint32, 0, numFAT*entriesPerSector)
+	for i := 0; i < numFAT; i++ {
+		fat = append(fat, secFAT)
+	}
+	h := newHeader()
+	h.NumFATSectors = int32(numFAT)
+	for i := range h.DIFAT {
+		h.DIFAT[i] = secFree
+		if i < numFAT {
+			h.DIFAT[i] = uint32(i)
+		}
+	}
+	h.FirstDirectorySectorLocation = uint32(len(fat))
+	fat = appendChain(fat, numDir)
+	if numMiniFAT > 0 {
+		h.FirstMiniFATSectorLocation = uint32(len(fat))
+		h.NumMiniFATSectors = int32(numMiniFAT)
+		fat = appendChain(fat, numMiniFAT)
+	}
+	dir[0].StartingSectorLocation = noSector
+	if numMini > 0 {
+		dir[0].StartingSectorLocation = int32(len(fat))
+		dir[0].StreamSize = uint64(mini.Len())
+		fat = appendChain(fat, numMini)
+	}
+	for i, s := range streams {
+		if s.buf.Len() >= writeMiniCutoff {
+			dir[1+i].StartingSectorLocation = int32(len(fat))
+			fat = appendChain(fat, sectorCount(s.buf.Len(), writeSectorSize))
+		}
+	}
+	for len(fat) < numFAT*entriesPerSector {
+		fat = append(fat, secFree)
+	}
+	for len(minifat) < numMiniFAT*entriesPerSector {
+		minifat = append(minifat, secFree)
+	}
+
+	// write everything in the same order
+	bw := &sectorWriter{w: w}
+	bw.write(h)
+	bw.write(fat)
+	for _, e := range dir {
+		bw.write(e)
+	}
+	unused := &directory{LeftSiblingID: noStream, RightSiblingID: noStream, ChildID: noStream}
+	for i := len(dir); i < numDir*writeSectorSize/128; i++ {
+		bw.write(unused)
+	}
+	bw.write(minifat)
+	bw.pad(mini.Bytes())
+	for _, s := range streams {
+		if s.buf.Len() >= writeMiniCutoff {
+			bw.pad(s.buf.Bytes())
+		}
+	}
+	return bw.err
+}
+
+// newHeader returns a version 3 header without any sectors.
+func newHeader() *header {
+	return &header{
+		Signature:                  signature,
+		MinorVersion:               0x3E,
+		MajorVersion:               3,
+		ByteOrder:                  0xFFFE,
+		SectorShift:                9,
+		MiniSectorShift:            6,
+		MiniStreamCutoffSize:       writeMiniCutoff,
+		FirstMiniFATSectorLocation: secEndOfChain,
+		FirstDIFATSectorLocation:   secEndOfChain,
+	}
+}
+
+// newDirectory returns a black directory entry without siblings or children.
+func newDirectory(name string, typ objectType) *directory {
+	e := &directory{
+		ObjectType:     typ,
+		ColorFlag:      1,
+		LeftSiblingID:  noStream,
+		RightSiblingID: noStream,
+		ChildID:        noStream,
+	}
+	n16 := utf16.Encode([]rune(name))
+	copy(e.Name[:], n16)
+	e.NameByteLen = int16(2 * (len(n16) + 1))
+	return e
+}
+
+// buildTree links the directory entries dir[lo:hi], which are sorted by
+// name, into a balanced red-black tree, returning the ID of its root. Levels
+// above the deepest are complete, so making the deepest level red keeps
+// the black height equal on all paths.
+func buildTree(dir []*directory, lo, hi, depth, maxDepth int) uint32 {
+	if lo >= hi {
+		return noStream
+	}
+	mid := lo + (hi-lo)/2
+	e := dir[mid]
+	if depth == maxDepth && depth > 0 {
+		e.ColorFlag = 0
+	}
+	e.LeftSiblingID = buildTree(dir, lo, mid, depth+1, maxDepth)
+	e.RightSiblingID = buildTree(dir, mid+1, hi, depth+1, maxDepth)
+	return uint32(mid)
+}
+
+// treeDepth returns the depth of the deepest node of a balanced tree of n
+// nodes.
+func treeDepth(n int) int {
+	d := -1
+	for n > 0 {
+		n /= 2
+		d++
+	}
+	return d
+}
+
+// compareNames orders directory entry names as required by the format:
+// shorter names first, then by the upper-case UTF-16 code units.
+func compareNames(a, b string) int {
+	a16 := utf16.Encode([]rune(strings.ToUpper(a)))
+	b16 := utf16.Encode([]rune(strings.ToUpper(b)))
+	if len(a16) != len(b16) {
+		return len(a16) - len(b16)
+	}
+	for i := range a16 {
+		if a16[i] != b16[i] {
+			return int(a16[i]) - int(b16[i])
+		}
+	}
+	return 0
+}
+
+// sectorCount returns the number of sectors needed to store n bytes.
+func sectorCount(n, size int) int {
+	return (n + size - 1) / size
+}
+
+// appendChain appends a chain of n consecutive sectors to the allocation
+// table.
+func appendChain(table []uint32, n int) []uint32 {
+	start := uint32(len(table))
+	for i := 1; i < n; i++ {
+		table = append(table, start+uint32(i))
+	}
+	if n > 0 {
+		table = append(table, secEndOfChain)
+	}
+	return table
+}
+
+// sectorWriter writes little-endian structures, remembering the first error.
+type sectorWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *sectorWriter) write(v interface{}) {
+	if s.err == nil {
+		s.err = binary.Write(s.w, binary.LittleEndian, v)
+	}
+}
+
+// pad writes data followed by zeroes up to the next sector boundary.
+func (s *sectorWriter) pad(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	s.write(data)
+	if rem := len(data) % writeSectorSize; rem != 0 {
+		s.write(make([]byte, writeSectorSize-rem))
+	}
+}
@@ -0,0 +1,95 @@
+package cfb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSave(t *testing.T) {
+	streams := map[string][]byte{
+		"Workbook": bytes.Repeat([]byte("0123456789abcdef"), 700), // FAT
+		"Empty":    nil,
+	}
+	for i := 0; i < 9; i++ {
+		// mini streams, more than one mini FAT sector in total
+		streams[fmt.Sprintf("Small%d", i)] = bytes.Repeat([]byte{byte(i)}, 1000+i*300)
+	}
+
+	doc := NewDocument()
+	names := make([]string, 0, len(streams))
+	for name, data := range streams {
+		w, err := doc.CreateStream(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if _, err := doc.CreateStream("workbook"); err == nil {
+		t.Fatal("expected an error for a duplicate stream name")
+	}
+	if _, err := doc.CreateStream("a/b"); err == nil {
+		t.Fatal("expected an error for an invalid stream name")
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len()%writeSectorSize != 0 {
+		t.Fatalf("file size %d is not a multiple of the sector size", buf.Len())
+	}
+
+	d, err := OpenReader(io.NopCloser(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := d.Verify(); len(errs) != 0 {
+		t.Fatalf("expected an intact document, got %v", errs)
+	}
+	list, _ := d.List()
+	sort.Strings(list)
+	sort.Strings(names)
+	if !reflect.DeepEqual(list, names) {
+		t.Fatalf("unexpected streams %v, expected %v", list, names)
+	}
+	for name, data := range streams {
+		if len(data) == 0 {
+			continue
+		}
+		r, err := d.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("stream %s: read %d bytes, expected %d", name, len(got), len(data))
+		}
+	}
+
+	if _, err = d.CreateStream("New"); err == nil {
+		t.Fatal("expected an error creating a stream in a loaded document")
+	}
+}
+
+func TestSaveUnclosed(t *testing.T) {
+	doc := NewDocument()
+	if _, err := doc.CreateStream("Open"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Save(io.Discard); err == nil {
+		t.Fatal("expected an error saving an unclosed stream")
+	}
+}
@@ -24,6 +24,9 @@ func TestAllFiles(t *testing.T) {
 		if err != nil {
 			return err
 		}
+		if n, _ := wb.SheetCount(); n != len(sheets) {
+			t.Errorf("%s: SheetCount() = %d, expected %d", p, n, len(sheets))
+		}
 		for _, s := range sheets {
 			sheet, err := wb.Get(s)
 			if err != nil {
@@ -1,14 +1,19 @@
 // Package crypto implements excel encryption algorithms from the
 // MS-OFFCRYPTO design specs. Currently only standard/basic RC4
-// "obfuscation" is supported.
+// encryption and XOR obfuscation are supported.
 package crypto
 
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 )
 
+// ErrVerificationFailed is returned when the password does not match
+// the verifier stored in the file.
+var ErrVerificationFailed = errors.New("xls: password verification failed")
+
 // Decryptor describes methods to decrypt an excel sheet.
 type Decryptor interface {
 	// SetPassword for the decryption.
@@ -77,8 +82,16 @@ type basicRC4Encryption struct {
 	VerifierHash [16]byte
 }
 
-// NewBasicRC4 implements the standard RC4 decryption.
+// NewBasicRC4 implements the standard RC4 decryption, using the default password.
 func NewBasicRC4(data []byte) (Decryptor, error) {
+	return NewBasicRC4WithPassword(data, DefaultXLSPassword)
+}
+
+// NewBasicRC4WithPassword implements the standard RC4 decryption using the given password.
+func NewBasicRC4WithPassword(data []byte, password string) (Decryptor, error) {
+	if password == "" {
+		password = DefaultXLSPassword
+	}
 	h := basicRC4Encryption{}
 	b := bytes.NewReader(data)
 	err := binary.Read(b, binary.LittleEndian, &h)
@@ -98,6 +111,8 @@ func NewBasicRC4(data []byte) (Decryptor, error) {
 		Salt: make([]byte, len(h.Salt)),
 	}
 	copy(d.Salt, h.Salt[:])
+	d.Password = []rune(password)
+	d.encKey = generateStd97Key(d.Password, d.Salt)
 
 	return d, d.Verify(h.Verifier[:], h.VerifierHash[:])
 }
@@ -5,7 +5,6 @@ import (
 	"crypto/md5"
 	"crypto/rc4"
 	"encoding/binary"
-	"fmt"
 )
 
 var _ Decryptor = &rc4Writer{}
@@ -108,7 +107,7 @@ func (d *rc4Writer) Verify(everifier, everifierHash []byte) error {
 	newhash := md5.Sum(temp1[:])
 	for i, c := range newhash {
 		if temp2[i] != c {
-			return fmt.Errorf("verification failed")
+			return ErrVerificationFailed
 		}
 	}
 	return nil
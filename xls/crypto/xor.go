@@ -0,0 +1,174 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// XOR obfuscation (method 1) per MS-OFFCRYPTO section 2.3.7.
+
+// XORObfuscation decodes the XOR obfuscated records of a BIFF8 stream.
+type XORObfuscation struct {
+	xorArray [16]byte
+}
+
+// NewXOR sets up XOR deobfuscation using the FilePass record data (after
+// the 2-byte encryption type) and the given password. An error is returned
+// if the password does not match the stored verifier.
+func NewXOR(data []byte, password string) (*XORObfuscation, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("xls: XOR obfuscation header is too short (%d bytes)", len(data))
+	}
+	key := binary.LittleEndian.Uint16(data)
+	verifier := binary.LittleEndian.Uint16(data[2:])
+
+	pass := xorPasswordBytes(password)
+	if len(pass) == 0 || createXorKey(pass) != key || createPasswordVerifier(pass) != verifier {
+		return nil, ErrVerificationFailed
+	}
+	x := &XORObfuscation{}
+	x.xorArray = createXorArray(pass)
+	return x, nil
+}
+
+// XORHeader returns the FilePass record data (after the 2-byte encryption
+// type) for XOR obfuscation with the given password.
+func XORHeader(password string) []byte {
+	if password == "" {
+		password = DefaultXLSPassword
+	}
+	pass := xorPasswordBytes(password)
+	res := make([]byte, 4)
+	binary.LittleEndian.PutUint16(res, createXorKey(pass))
+	binary.LittleEndian.PutUint16(res[2:], createPasswordVerifier(pass))
+	return res
+}
+
+// Decrypt deobfuscates p in place, starting at position index of the XOR array.
+func (x *XORObfuscation) Decrypt(p []byte, index int) {
+	for i, c := range p {
+		c ^= x.xorArray[(index+i)%16]
+		p[i] = c>>5 | c<<3
+	}
+}
+
+// Encrypt obfuscates p in place, starting at position index of the XOR array.
+func (x *XORObfuscation) Encrypt(p []byte, index int) {
+	for i, c := range p {
+		c = c<<5 | c>>3
+		p[i] = c ^ x.xorArray[(index+i)%16]
+	}
+}
+
+// xorPasswordBytes reduces the password to single bytes as described in
+// section 2.3.7.4, truncated to 15 characters.
+func xorPasswordBytes(password string) []byte {
+	res := make([]byte, 0, 15)
+	for _, c := range password {
+		if len(res) == 15 {
+			break
+		}
+		if c&0xFF != 0 {
+			res = append(res, byte(c))
+		} else {
+			res = append(res, byte(c>>8))
+		}
+	}
+	return res
+}
+
+// 2.3.7.1
+func createPasswordVerifier(pass []byte) uint16 {
+	var verifier uint16
+	arr := append([]byte{byte(len(pass))}, pass...)
+	for i := len(arr) - 1; i >= 0; i-- {
+		var i1 uint16
+		if verifier&0x4000 != 0 {
+			i1 = 1
+		}
+		i2 := (verifier * 2) & 0x7FFF
+		verifier = (i1 | i2) ^ uint16(arr[i])
+	}
+	return verifier ^ 0xCE4B
+}
+
+var xorInitialCode = [15]uint16{
+	0xE1F0, 0x1D0F, 0xCC9C, 0x84C0, 0x110C, 0x0E10, 0xF1CE, 0x313E,
+	0x1872, 0xE139, 0xD40F, 0x84F9, 0x280C, 0xA96A, 0x4EC3,
+}
+
+var xorMatrix = [105]uint16{
+	0xAEFC, 0x4DD9, 0x9BB2, 0x2745, 0x4E8A, 0x9D14, 0x2A09,
+	0x7B61, 0xF6C2, 0xFDA5, 0xEB6B, 0xC6F7, 0x9DCF, 0x2BBF,
+	0x4563, 0x8AC6, 0x05AD, 0x0B5A, 0x16B4, 0x2D68, 0x5AD0,
+	0x0375, 0x06EA, 0x0DD4, 0x1BA8, 0x3750, 0x6EA0, 0xDD40,
+	0xD849, 0xA0B3, 0x5147, 0xA28E, 0x553D, 0xAA7A, 0x44D5,
+	0x6F45, 0xDE8A, 0xAD35, 0x4A4B, 0x9496, 0x390D, 0x721A,
+	0xEB23, 0xC667, 0x9CEF, 0x29FF, 0x53FE, 0xA7FC, 0x5FD9,
+	0x47D3, 0x8FA6, 0x0F6D, 0x1EDA, 0x3DB4, 0x7B68, 0xF6D0,
+	0xB861, 0x60E3, 0xC1C6, 0x93AD, 0x377B, 0x6EF6, 0xDDEC,
+	0x45A0, 0x8B40, 0x06A1, 0x0D42, 0x1A84, 0x3508, 0x6A10,
+	0xAA51, 0x4483, 0x8906, 0x022D, 0x045A, 0x08B4, 0x1168,
+	0x76B4, 0xED68, 0xCAF1, 0x85C3, 0x1BA7, 0x374E, 0x6E9C,
+	0x3730, 0x6E60, 0xDCC0, 0xA9A1, 0x4363, 0x86C6, 0x1DAD,
+	0x3331, 0x6662, 0xCCC4, 0x89A9, 0x0373, 0x06E6, 0x0DCC,
+	0x1021, 0x2042, 0x4084, 0x8108, 0x1231, 0x2462, 0x48C4,
+}
+
+// 2.3.7.2
+func createXorKey(pass []byte) uint16 {
+	key := xorInitialCode[len(pass)-1]
+	elem := 0x68
+	for i := len(pass) - 1; i >= 0; i-- {
+		c := pass[i]
+		for j := 0; j < 7; j++ {
+			if c&0x40 != 0 {
+				key ^= xorMatrix[elem]
+			}
+			c *= 2
+			elem--
+		}
+	}
+	return key
+}
+
+var xorPadArray = [15]byte{
+	0xBB, 0xFF, 0xFF, 0xBA, 0xFF, 0xFF, 0xB9, 0x80,
+	0x00, 0xBE, 0x0F, 0x00, 0xBF, 0x0F, 0x00,
+}
+
+func xorRor(b1, b2 byte) byte {
+	b := b1 ^ b2
+	return b>>1 | b<<7
+}
+
+// 2.3.7.3
+func createXorArray(pass []byte) [16]byte {
+	var res [16]byte
+	key := createXorKey(pass)
+	hi, lo := byte(key>>8), byte(key)
+
+	index := len(pass)
+	if index%2 == 1 {
+		res[index] = xorRor(xorPadArray[0], hi)
+		index--
+		res[index] = xorRor(pass[len(pass)-1], lo)
+	}
+	for index > 0 {
+		index--
+		res[index] = xorRor(pass[index], hi)
+		index--
+		res[index] = xorRor(pass[index], lo)
+	}
+
+	index = 15
+	for pad := 15 - len(pass); pad > 0; {
+		res[index] = xorRor(xorPadArray[pad], hi)
+		index--
+		pad--
+		res[index] = xorRor(xorPadArray[pad], lo)
+		index--
+		pad--
+	}
+	return res
+}
@@ -0,0 +1,143 @@
+package xls
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/xls/crypto"
+)
+
+// xorObfuscate inserts a FilePass record after the first BOF and
+// obfuscates the rest of the stream with the password.
+func xorObfuscate(t *testing.T, raw []byte, password string) []byte {
+	filePass := append([]byte{0x2F, 0x00, 0x06, 0x00, 0x00, 0x00}, crypto.XORHeader(password)...)
+	dec, err := crypto.NewXOR(filePass[6:], password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bofSize := 4 + int(binary.LittleEndian.Uint16(raw[2:4]))
+	res := append([]byte{}, raw[:bofSize]...)
+	res = append(res, filePass...)
+	res = append(res, raw[bofSize:]...)
+
+	pos := bofSize + len(filePass)
+	for len(res[pos:]) > 4 {
+		rt := recordType(binary.LittleEndian.Uint16(res[pos:]))
+		size := int(binary.LittleEndian.Uint16(res[pos+2:]))
+		pos += 4
+		data := res[pos : pos+size]
+		index := pos + size
+		switch {
+		case isClearRecord(rt):
+			data = nil
+		case rt == RecTypeBoundSheet8:
+			// substreams have moved by the size of the FilePass record
+			binary.LittleEndian.PutUint32(data, binary.LittleEndian.Uint32(data)+uint32(len(filePass)))
+			data = data[4:]
+			index += 4
+		}
+		dec.Encrypt(data, index)
+		pos += size
+	}
+	return res
+}
+
+func loadTestStream(t *testing.T, raw []byte, password string) (*WorkBook, error) {
+	b := &WorkBook{
+		password:      password,
+		pos2substream: make(map[int64]int, 16),
+		xfs:           make([]uint16, 0, 128),
+	}
+	return b, b.loadFromStream(raw)
+}
+
+func TestXORObfuscation(t *testing.T) {
+	raw := readTestStream(t, "../testdata/basic.xls")
+
+	plain, err := loadTestStream(t, raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := xorObfuscate(t, raw, "s3cret")
+
+	if _, err = loadTestStream(t, enc, ""); !errors.Is(err, grate.ErrEncrypted) {
+		t.Fatalf("expected ErrEncrypted without password, got %v", err)
+	}
+	if _, err = loadTestStream(t, enc, "wrong"); !errors.Is(err, grate.ErrEncrypted) {
+		t.Fatalf("expected ErrEncrypted with wrong password, got %v", err)
+	}
+	if !errors.Is(err, grate.ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword with wrong password, got %v", err)
+	}
+
+	wb, err := loadTestStream(t, enc, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _ := plain.List()
+	names2, _ := wb.List()
+	if !reflect.DeepEqual(names, names2) {
+		t.Fatalf("sheet names differ: %v vs %v", names, names2)
+	}
+	for _, name := range names {
+		s1, err := plain.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s2, err := wb.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for s1.Next() {
+			if !s2.Next() {
+				t.Fatalf("%s: decrypted sheet has fewer rows", name)
+			}
+			if !reflect.DeepEqual(s1.Strings(), s2.Strings()) {
+				t.Fatalf("%s: rows differ: %v vs %v", name, s1.Strings(), s2.Strings())
+			}
+		}
+	}
+}
+
+// testdata/xor_obfuscated.xls holds the Workbook stream of basic.xls,
+// obfuscated with the password "s3cret" as described in MS-XLS 2.4.117
+// (FilePass) and MS-OFFCRYPTO 2.3.7 (XOR obfuscation method 1).
+func TestXORObfuscatedFile(t *testing.T) {
+	if _, err := Open("testdata/xor_obfuscated.xls"); !errors.Is(err, grate.ErrEncrypted) {
+		t.Fatalf("expected ErrEncrypted without password, got %v", err)
+	}
+	wb, err := OpenWithPassword("testdata/xor_obfuscated.xls", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	plain, err := Open("../testdata/basic.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+
+	names, _ := plain.List()
+	if names2, _ := wb.List(); !reflect.DeepEqual(names, names2) {
+		t.Fatalf("sheet names differ: %v vs %v", names, names2)
+	}
+	for _, name := range names {
+		s1, _ := plain.Get(name)
+		s2, err := wb.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for s1.Next() {
+			if !s2.Next() || !reflect.DeepEqual(s1.Strings(), s2.Strings()) {
+				t.Fatalf("%s: row %d differs: %v vs %v", name, s1.Row(), s1.Strings(), s2.Strings())
+			}
+		}
+		if s2.Next() {
+			t.Errorf("%s: decrypted sheet has more rows", name)
+		}
+	}
+}
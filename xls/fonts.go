@@ -0,0 +1,137 @@
+package xls
+
+import (
+	"encoding/binary"
+	"image/color"
+)
+
+// Font describes a font of the workbook, from its Font record
+// (section 2.4.122).
+type Font struct {
+	Name string
+	// Size is the font height in points.
+	Size   float64
+	Bold   bool
+	Italic bool
+	// Color is resolved from the workbook palette. The automatic colour
+	// is returned as opaque black.
+	Color color.RGBA
+	// Charset is the character set (e.g. 0 for ANSI, 128 for Shift JIS).
+	Charset int
+}
+
+// fontRec is a parsed Font record, with its unresolved colour index.
+type fontRec struct {
+	Font
+	icv uint16
+}
+
+// parseFont decodes a Font record, returning false if it is too short.
+func parseFont(data []byte) (fontRec, bool) {
+	if len(data) < 16 {
+		return fontRec{}, false
+	}
+	cch, wide := int(data[14]), (data[15]&1) != 0
+	if (wide && len(data) < 16+2*cch) || len(data) < 16+cch {
+		return fontRec{}, false
+	}
+	name, _, err := decodeShortXLUnicodeString(data[14:])
+	if err != nil {
+		return fontRec{}, false
+	}
+	grbit := binary.LittleEndian.Uint16(data[2:])
+	return fontRec{
+		Font: Font{
+			Name:    name,
+			Size:    float64(binary.LittleEndian.Uint16(data)) / 20,
+			Bold:    binary.LittleEndian.Uint16(data[6:]) >= 700,
+			Italic:  (grbit & 0x02) != 0,
+			Charset: int(data[12]),
+		},
+		icv: binary.LittleEndian.Uint16(data[4:]),
+	}, true
+}
+
+// Fonts returns the font table of the workbook, in the order of its Font
+// records. Font indexes in formats and rich text runs skip the value 4, so
+// use Font to resolve an index.
+func (b *WorkBook) Fonts() []Font {
+	res := make([]Font, len(b.fonts))
+	for i, f := range b.fonts {
+		res[i] = f.Font
+		res[i].Color = b.paletteColor(f.icv)
+	}
+	return res
+}
+
+// Font returns the font with the index ifnt, as referenced by cell formats
+// and rich text runs, and true if it exists.
+func (b *WorkBook) Font(ifnt int) (Font, bool) {
+	if ifnt == 4 {
+		return Font{}, false
+	}
+	if ifnt > 4 {
+		ifnt--
+	}
+	if ifnt < 0 || ifnt >= len(b.fonts) {
+		return Font{}, false
+	}
+	f := b.fonts[ifnt].Font
+	f.Color = b.paletteColor(b.fonts[ifnt].icv)
+	return f, true
+}
+
+// paletteColor resolves a colour index (section 2.5.161), using the
+// Palette record of the workbook if it has one.
+func (b *WorkBook) paletteColor(icv uint16) color.RGBA {
+	switch {
+	case icv < 8:
+		return defaultPalette[icv]
+	case icv < 64:
+		if i := int(icv) - 8; i < len(b.palette) {
+			return b.palette[i]
+		}
+		return defaultPalette[icv]
+	case icv == 0x41:
+		// system window background
+		return color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	}
+	// system window text, and the automatic colour
+	return color.RGBA{0, 0, 0, 0xFF}
+}
+
+// parsePalette decodes the custom colours 8-63 of a Palette record
+// (section 2.4.188).
+func parsePalette(data []byte) []color.RGBA {
+	if len(data) < 2 {
+		return nil
+	}
+	n := int(binary.LittleEndian.Uint16(data))
+	data = data[2:]
+	res := make([]color.RGBA, 0, n)
+	for i := 0; i < n && len(data) >= 4; i++ {
+		res = append(res, color.RGBA{data[0], data[1], data[2], 0xFF})
+		data = data[4:]
+	}
+	return res
+}
+
+// defaultPalette is the built-in colour table, including the default
+// values of the custom colours 8-63.
+var defaultPalette = func() [64]color.RGBA {
+	rgb := [64]uint32{
+		0x000000, 0xFFFFFF, 0xFF0000, 0x00FF00, 0x0000FF, 0xFFFF00, 0xFF00FF, 0x00FFFF,
+		0x000000, 0xFFFFFF, 0xFF0000, 0x00FF00, 0x0000FF, 0xFFFF00, 0xFF00FF, 0x00FFFF,
+		0x800000, 0x008000, 0x000080, 0x808000, 0x800080, 0x008080, 0xC0C0C0, 0x808080,
+		0x9999FF, 0x993366, 0xFFFFCC, 0xCCFFFF, 0x660066, 0xFF8080, 0x0066CC, 0xCCCCFF,
+		0x000080, 0xFF00FF, 0xFFFF00, 0x00FFFF, 0x800080, 0x800000, 0x008080, 0x0000FF,
+		0x00CCFF, 0xCCFFFF, 0xCCFFCC, 0xFFFF99, 0x99CCFF, 0xFF99CC, 0xCC99FF, 0xFFCC99,
+		0x3366FF, 0x33CCCC, 0x99CC00, 0xFFCC00, 0xFF9900, 0xFF6600, 0x666699, 0x969696,
+		0x003366, 0x339966, 0x003300, 0x333300, 0x993300, 0x993366, 0x333399, 0x333333,
+	}
+	var res [64]color.RGBA
+	for i, c := range rgb {
+		res[i] = color.RGBA{uint8(c >> 16), uint8(c >> 8), uint8(c), 0xFF}
+	}
+	return res
+}()
@@ -0,0 +1,88 @@
+package xls
+
+import (
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+func TestFonts(t *testing.T) {
+	wb, err := Open("../testdata/basic.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	b := wb.(*WorkBook)
+	fonts := b.Fonts()
+	if len(fonts) != 4 {
+		t.Fatalf("expected 4 fonts, got %d", len(fonts))
+	}
+	black := color.RGBA{0, 0, 0, 0xFF}
+	want := Font{Name: "Verdana", Size: 16, Bold: true, Italic: true, Color: black}
+	if fonts[3] != want {
+		t.Errorf("got %+v, expected %+v", fonts[3], want)
+	}
+	// there is no font 4
+	if _, ok := b.Font(4); ok {
+		t.Error("expected no font with index 4")
+	}
+	if f, ok := b.Font(3); !ok || f != want {
+		t.Errorf("got %+v, expected %+v", f, want)
+	}
+}
+
+func TestFontColor(t *testing.T) {
+	rec := []byte{
+		0xF0, 0x00, // 12pt
+		0x00, 0x00, // grbit
+		0x0A, 0x00, // icv: red
+		0x90, 0x01, // bls: normal
+		0x00, 0x00, 0x00, 0x00,
+		0x80, // Shift JIS
+		0x00,
+		0x05, 0x00, 'A', 'r', 'i', 'a', 'l',
+	}
+	f, ok := parseFont(rec)
+	if !ok {
+		t.Fatal("failed to parse font")
+	}
+	b := &WorkBook{fonts: []fontRec{f}}
+	want := Font{Name: "Arial", Size: 12, Color: color.RGBA{0xFF, 0, 0, 0xFF}, Charset: 128}
+	if got := b.Fonts()[0]; got != want {
+		t.Errorf("got %+v, expected %+v", got, want)
+	}
+
+	b.palette = parsePalette([]byte{0x03, 0x00, 1, 1, 1, 0, 2, 2, 2, 0, 0x12, 0x34, 0x56, 0})
+	if got := b.Fonts()[0].Color; got != (color.RGBA{0x12, 0x34, 0x56, 0xFF}) {
+		t.Errorf("unexpected palette colour %v", got)
+	}
+
+	if _, ok := parseFont(rec[:18]); ok {
+		t.Error("expected a truncated record to fail")
+	}
+}
+
+func TestMalformedFont(t *testing.T) {
+	raw := readTestStream(t, "../testdata/basic.xls")
+	// make the name of the first font overrun its record
+	for pos := 0; pos+4 < len(raw); {
+		rt := recordType(binary.LittleEndian.Uint16(raw[pos:]))
+		size := int(binary.LittleEndian.Uint16(raw[pos+2:]))
+		if rt == RecTypeFont {
+			raw[pos+4+14] = 0xFF
+			break
+		}
+		pos += 4 + size
+	}
+	b, err := loadTestStream(t, raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fonts := b.Fonts()
+	if len(fonts) != 4 {
+		t.Fatalf("expected 4 fonts, got %d", len(fonts))
+	}
+	if fonts[0].Name != "" || fonts[3].Name != "Verdana" {
+		t.Errorf("unexpected fonts %+v", fonts)
+	}
+}
@@ -0,0 +1,31 @@
+package xls
+
+import "testing"
+
+func TestFormatCodes(t *testing.T) {
+	wb, err := Open("../testdata/multi_test.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// custom formats declared by FORMAT records, and built-in ones
+	want := map[[2]int]string{
+		{0, 1}:  "@",
+		{1, 4}:  "mmmm d, yyyy",
+		{2, 3}:  "# ###/###",
+		{4, 4}:  "mmm-yy",
+		{14, 5}: `"yes";"yes";"no"`,
+	}
+	for c.Next() {
+		formats := c.Formats()
+		for pos, code := range want {
+			if pos[0] == c.Row() && formats[pos[1]] != code {
+				t.Errorf("row %d col %d: got format %q, expected %q", pos[0], pos[1], formats[pos[1]], code)
+			}
+		}
+	}
+}
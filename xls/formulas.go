@@ -0,0 +1,182 @@
+package xls
+
+import "encoding/binary"
+
+// cellPos is a zero-based (row, column) cell position.
+type cellPos [2]int
+
+// formulaRgce returns the parsed formula tokens of a Formula record
+// (section 2.4.127), or nil if the record is too short.
+func formulaRgce(data []byte) []byte {
+	if len(data) < 22 {
+		return nil
+	}
+	cce := int(binary.LittleEndian.Uint16(data[20:]))
+	if len(data) < 22+cce {
+		return nil
+	}
+	return data[22 : 22+cce]
+}
+
+// shrFmlaRgce returns the parsed formula tokens of a ShrFmla record
+// (section 2.4.260), or nil if the record is too short.
+func shrFmlaRgce(data []byte) []byte {
+	if len(data) < 10 {
+		return nil
+	}
+	cce := int(binary.LittleEndian.Uint16(data[8:]))
+	if len(data) < 10+cce {
+		return nil
+	}
+	return data[10 : 10+cce]
+}
+
+// FormulaReferences returns the cell and area references (e.g. "A1",
+// "$B$2:C5" or "Sheet2!A1") used by the formula of the cell, in order, and
+// true if the cell contains a formula. Shared formulas are expanded, so the
+// relative references of the master formula are resolved for each cell of
+// the shared range. The values of formula cells are always the results
+// last calculated by Excel.
+func (s *Sheet) FormulaReferences(row, col int) ([]string, bool) {
+	rgce, ok := s.formulas[cellPos{row, col}]
+	if !ok {
+		return nil, false
+	}
+	if len(rgce) == 5 && rgce[0] == 0x01 { // PtgExp
+		master := cellPos{
+			int(binary.LittleEndian.Uint16(rgce[1:])),
+			int(binary.LittleEndian.Uint16(rgce[3:])),
+		}
+		if shared, ok := s.shared[master]; ok {
+			return s.b.formulaRefs(shared, row, col), true
+		}
+	}
+	return s.b.formulaRefs(rgce, row, col), true
+}
+
+// formulaRefs decodes the references in the parsed formula tokens. Relative
+// references (PtgRefN and PtgAreaN, used in shared formulas) are resolved
+// from the cell at (row, col). Decoding stops at unsupported tokens.
+func (b *WorkBook) formulaRefs(rgce []byte, row, col int) []string {
+	var res []string
+	le := binary.LittleEndian
+	for len(rgce) > 0 {
+		ptg := rgce[0]
+		rgce = rgce[1:]
+
+		size := -1
+		switch {
+		case ptg == 0x01 || ptg == 0x02: // PtgExp, PtgTbl
+			size = 4
+		case ptg >= 0x03 && ptg <= 0x16: // operators
+			size = 0
+		case ptg == 0x17: // PtgStr
+			if len(rgce) < 2 {
+				return res
+			}
+			size = 2 + int(rgce[0])
+			if (rgce[1] & 1) != 0 {
+				size += int(rgce[0])
+			}
+		case ptg == 0x19: // PtgAttr
+			if len(rgce) < 3 {
+				return res
+			}
+			size = 3
+			if (rgce[0] & 0x04) != 0 { // PtgAttrChoose
+				size += 2 * (int(le.Uint16(rgce[1:])) + 1)
+			}
+		case ptg == 0x1C || ptg == 0x1D: // PtgErr, PtgBool
+			size = 1
+		case ptg == 0x1E: // PtgInt
+			size = 2
+		case ptg == 0x1F: // PtgNum
+			size = 8
+		case ptg >= 0x20 && ptg < 0x80:
+			size = ptgOperandSize[ptg&0x1F]
+		}
+		if size < 0 || len(rgce) < size {
+			return res
+		}
+
+		switch ptg & 0x1F {
+		case 0x04: // PtgRef
+			if ptg >= 0x20 {
+				res = append(res, cellRef(le.Uint16(rgce), le.Uint16(rgce[2:])))
+			}
+		case 0x05: // PtgArea
+			if ptg >= 0x20 {
+				res = append(res, areaRef(le.Uint16(rgce), le.Uint16(rgce[2:]), le.Uint16(rgce[4:]), le.Uint16(rgce[6:])))
+			}
+		case 0x0C: // PtgRefN
+			if ptg >= 0x20 {
+				rw, cl := relativeLoc(le.Uint16(rgce), le.Uint16(rgce[2:]), row, col)
+				res = append(res, cellRef(rw, cl))
+			}
+		case 0x0D: // PtgAreaN
+			if ptg >= 0x20 {
+				rw1, cl1 := relativeLoc(le.Uint16(rgce), le.Uint16(rgce[4:]), row, col)
+				rw2, cl2 := relativeLoc(le.Uint16(rgce[2:]), le.Uint16(rgce[6:]), row, col)
+				res = append(res, areaRef(rw1, rw2, cl1, cl2))
+			}
+		case 0x1A: // PtgRef3d
+			res = append(res, b.xtiPrefix(le.Uint16(rgce))+cellRef(le.Uint16(rgce[2:]), le.Uint16(rgce[4:])))
+		case 0x1B: // PtgArea3d
+			res = append(res, b.xtiPrefix(le.Uint16(rgce))+areaRef(le.Uint16(rgce[2:]), le.Uint16(rgce[4:]), le.Uint16(rgce[6:]), le.Uint16(rgce[8:])))
+		}
+		rgce = rgce[size:]
+	}
+	return res
+}
+
+// ptgOperandSize is the size of the data following the operand tokens
+// 0x20-0x3F (and their value and array class variants), -1 if unsupported.
+var ptgOperandSize = [32]int{
+	0x00: 7,  // PtgArray
+	0x01: 2,  // PtgFunc
+	0x02: 3,  // PtgFuncVar
+	0x03: 4,  // PtgName
+	0x04: 4,  // PtgRef
+	0x05: 8,  // PtgArea
+	0x06: 6,  // PtgMemArea
+	0x07: 6,  // PtgMemErr
+	0x08: 6,  // PtgMemNoMem
+	0x09: 2,  // PtgMemFunc
+	0x0A: 4,  // PtgRefErr
+	0x0B: 8,  // PtgAreaErr
+	0x0C: 4,  // PtgRefN
+	0x0D: 8,  // PtgAreaN
+	0x0E: -1, // unused
+	0x0F: -1,
+	0x10: -1,
+	0x11: -1,
+	0x12: -1,
+	0x13: -1,
+	0x14: -1,
+	0x15: -1,
+	0x16: -1,
+	0x17: -1,
+	0x18: -1,
+	0x19: 6,  // PtgNameX
+	0x1A: 6,  // PtgRef3d
+	0x1B: 10, // PtgArea3d
+	0x1C: 6,  // PtgRefErr3d
+	0x1D: 10, // PtgAreaErr3d
+	0x1E: -1,
+	0x1F: -1,
+}
+
+// relativeLoc resolves a row and a ColRelNeu of a shared formula
+// (section 2.5.198.105) for the cell at (row, col). Offsets are applied to
+// the relative parts, and the relative flags are kept for formatting.
+func relativeLoc(rw, cl uint16, row, col int) (uint16, uint16) {
+	flags := cl & 0xC000
+	c := cl & 0x00FF
+	if (cl & 0x4000) != 0 {
+		c = uint16(uint8(col) + uint8(c))
+	}
+	if (cl & 0x8000) != 0 {
+		rw = uint16(row) + rw
+	}
+	return rw, c | flags
+}
@@ -0,0 +1,78 @@
+package xls
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// formulaRec builds a Formula record with a numeric value.
+func formulaRec(row, col uint16, value float64, shared bool, rgce ...byte) *rec {
+	le := binary.LittleEndian
+	data := make([]byte, 22, 22+len(rgce))
+	le.PutUint16(data, row)
+	le.PutUint16(data[2:], col)
+	le.PutUint64(data[6:], math.Float64bits(value))
+	if shared {
+		le.PutUint16(data[14:], 0x0008) // fShrFmla
+	}
+	le.PutUint16(data[20:], uint16(len(rgce)))
+	data = append(data, rgce...)
+	return &rec{RecType: RecTypeFormula, RecSize: uint16(len(data)), Data: data}
+}
+
+func TestSharedFormulaReferences(t *testing.T) {
+	dims := make([]byte, 14)
+	binary.LittleEndian.PutUint32(dims[4:], 3)  // rows 0-2
+	binary.LittleEndian.PutUint16(dims[10:], 3) // cols 0-2
+
+	// B1:B3 share the formula A1*2 (relative), C1 is $A$1
+	shrfmla := []byte{0, 0, 2, 0, 1, 1, 0, 3, 9, 0,
+		0x2C, 0, 0, 0xFF, 0xC0, // PtgRefN, same row, one column to the left
+		0x1E, 2, 0, // PtgInt 2
+		0x05, // PtgMul
+	}
+	exp := []byte{0x01, 0, 0, 1, 0} // PtgExp B1
+	b := &WorkBook{substreams: [][]*rec{{
+		{RecType: RecTypeBOF, Data: make([]byte, 16)},
+		{RecType: RecTypeDimensions, Data: dims},
+		formulaRec(0, 1, 2, true, exp...),
+		{RecType: RecTypeShrFmla, Data: shrfmla},
+		formulaRec(1, 1, 4, true, exp...),
+		formulaRec(2, 1, 6, true, exp...),
+		formulaRec(0, 2, 1, false, 0x24, 0, 0, 0, 0), // PtgRef $A$1
+		{RecType: RecTypeEOF},
+	}}}
+	s, err := b.parseSheet(nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		row, col int
+		refs     []string
+	}{
+		{0, 1, []string{"A1"}},
+		{1, 1, []string{"A2"}},
+		{2, 1, []string{"A3"}},
+		{0, 2, []string{"$A$1"}},
+	} {
+		refs, ok := s.FormulaReferences(c.row, c.col)
+		if !ok || !reflect.DeepEqual(refs, c.refs) {
+			t.Errorf("FormulaReferences(%d, %d) = %v, %v, expected %v", c.row, c.col, refs, ok, c.refs)
+		}
+	}
+	if _, ok := s.FormulaReferences(0, 0); ok {
+		t.Error("expected no formula in A1")
+	}
+
+	// the values are the results stored for each cell
+	var got []string
+	for len(got) < 3 && s.Next() {
+		got = append(got, s.Strings()[1])
+	}
+	if !reflect.DeepEqual(got, []string{"2", "4", "6"}) {
+		t.Errorf("unexpected values %v", got)
+	}
+}
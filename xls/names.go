@@ -0,0 +1,226 @@
+package xls
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// DefinedName describes a named range (or constant/formula) defined in the workbook.
+type DefinedName struct {
+	// Name of the defined name. Built-in names use the same "_xlnm." prefix as xlsx, e.g. "_xlnm.Print_Area".
+	Name string
+
+	// Sheet is the name of the owning sheet for sheet-local names, empty for workbook-global names.
+	Sheet string
+
+	// Range is the referenced cell range in A1 notation (e.g. "Sheet1!$A$1:$C$10"),
+	// or empty if the definition could not be decoded as a cell reference.
+	Range string
+
+	// Hidden is true if the name is not displayed in the user interface.
+	Hidden bool
+}
+
+// xti is an entry of the ExternSheet record, section 2.5.172
+type xti struct {
+	SupBook   uint16
+	FirstItab int16
+	LastItab  int16
+}
+
+// built-in name codes, section 2.5.114
+var builtinNames = map[byte]string{
+	0x00: "Consolidate_Area",
+	0x01: "Auto_Open",
+	0x02: "Auto_Close",
+	0x03: "Extract",
+	0x04: "Database",
+	0x05: "Criteria",
+	0x06: "Print_Area",
+	0x07: "Print_Titles",
+	0x08: "Recorder",
+	0x09: "Data_Form",
+	0x0A: "Auto_Activate",
+	0x0B: "Auto_Deactivate",
+	0x0C: "Sheet_Title",
+	0x0D: "_FilterDatabase",
+}
+
+// DefinedNames returns the names defined in the workbook (Lbl records).
+func (b *WorkBook) DefinedNames() []DefinedName {
+	res := make([]DefinedName, 0, len(b.names))
+	for _, raw := range b.names {
+		dn, ok := b.decodeLbl(raw)
+		if ok {
+			res = append(res, dn)
+		}
+	}
+	return res
+}
+
+// 2.4.150
+func (b *WorkBook) decodeLbl(raw []byte) (DefinedName, bool) {
+	var dn DefinedName
+	if len(raw) < 15 {
+		return dn, false
+	}
+	grbit := binary.LittleEndian.Uint16(raw[:2])
+	cch := int(raw[3])
+	cce := int(binary.LittleEndian.Uint16(raw[4:6]))
+	itab := int(binary.LittleEndian.Uint16(raw[8:10]))
+	flags := raw[14]
+	raw = raw[15:]
+
+	dn.Hidden = (grbit & 0x0001) != 0
+	if itab > 0 && itab <= len(b.sheets) {
+		dn.Sheet = b.sheets[itab-1].Name
+	}
+
+	// XLUnicodeStringNoCch
+	content := make([]uint16, cch)
+	if (flags & 0x1) == 0 {
+		if len(raw) < cch {
+			return dn, false
+		}
+		for i := 0; i < cch; i++ {
+			content[i] = uint16(raw[i])
+		}
+		raw = raw[cch:]
+	} else {
+		if len(raw) < cch*2 {
+			return dn, false
+		}
+		for i := 0; i < cch; i++ {
+			content[i] = binary.LittleEndian.Uint16(raw[i*2:])
+		}
+		raw = raw[cch*2:]
+	}
+
+	if (grbit & 0x0020) != 0 {
+		// built-in name is stored as a single character code
+		if cch == 0 {
+			return dn, false
+		}
+		bn, ok := builtinNames[byte(content[0])]
+		if !ok {
+			bn = fmt.Sprintf("Builtin_%02X", content[0])
+		}
+		dn.Name = "_xlnm." + bn
+	} else {
+		dn.Name = string(utf16.Decode(content))
+	}
+
+	if cce > len(raw) {
+		cce = len(raw)
+	}
+	dn.Range = b.decodeRangeFormula(raw[:cce])
+	return dn, true
+}
+
+// decodeRangeFormula decodes the subset of parsed formula tokens used to
+// describe cell ranges: 3D references and areas, optionally combined
+// into a union. Returns an empty string for anything else.
+func (b *WorkBook) decodeRangeFormula(rgce []byte) string {
+	var parts []string
+	for len(rgce) > 0 {
+		ptg := rgce[0]
+		rgce = rgce[1:]
+		switch ptg {
+		case 0x3A, 0x5A, 0x7A: // PtgRef3d
+			if len(rgce) < 6 {
+				return ""
+			}
+			ixti := binary.LittleEndian.Uint16(rgce)
+			rw := binary.LittleEndian.Uint16(rgce[2:])
+			col := binary.LittleEndian.Uint16(rgce[4:])
+			rgce = rgce[6:]
+			parts = append(parts, b.xtiPrefix(ixti)+cellRef(rw, col))
+
+		case 0x3B, 0x5B, 0x7B: // PtgArea3d
+			if len(rgce) < 10 {
+				return ""
+			}
+			ixti := binary.LittleEndian.Uint16(rgce)
+			rwFirst := binary.LittleEndian.Uint16(rgce[2:])
+			rwLast := binary.LittleEndian.Uint16(rgce[4:])
+			colFirst := binary.LittleEndian.Uint16(rgce[6:])
+			colLast := binary.LittleEndian.Uint16(rgce[8:])
+			rgce = rgce[10:]
+			parts = append(parts, b.xtiPrefix(ixti)+areaRef(rwFirst, rwLast, colFirst, colLast))
+
+		case 0x10: // PtgUnion
+			// operands are already in parts
+		case 0x29, 0x49, 0x69: // PtgMemFunc
+			// the expression contains a union of references
+			if len(rgce) < 2 {
+				return ""
+			}
+			rgce = rgce[2:]
+		default:
+			return ""
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (b *WorkBook) xtiPrefix(ixti uint16) string {
+	if int(ixti) >= len(b.xtis) {
+		return ""
+	}
+	x := b.xtis[ixti]
+	if x.FirstItab < 0 || int(x.FirstItab) >= len(b.sheets) {
+		return ""
+	}
+	name := b.sheets[x.FirstItab].Name
+	if x.LastItab != x.FirstItab && x.LastItab >= 0 && int(x.LastItab) < len(b.sheets) {
+		name += ":" + b.sheets[x.LastItab].Name
+	}
+	if strings.ContainsAny(name, " -'!") {
+		name = "'" + strings.ReplaceAll(name, "'", "''") + "'"
+	}
+	return name + "!"
+}
+
+// colName converts a 0-based column index into letters: 0=A, 25=Z, 26=AA.
+func colName(c int) string {
+	s := ""
+	for c >= 0 {
+		s = string(rune('A'+c%26)) + s
+		c = c/26 - 1
+	}
+	return s
+}
+
+// cellRef formats a row and a ColRelU (section 2.5.13) as an A1-style reference.
+func cellRef(rw, col uint16) string {
+	s := ""
+	if (col & 0x4000) == 0 {
+		s += "$"
+	}
+	s += colName(int(col & 0x3FFF))
+	if (col & 0x8000) == 0 {
+		s += "$"
+	}
+	return s + fmt.Sprint(int(rw)+1)
+}
+
+// areaRef formats an area, collapsing whole rows and columns as Excel does.
+func areaRef(rwFirst, rwLast, colFirst, colLast uint16) string {
+	cf, cl := colFirst&0x3FFF, colLast&0x3FFF
+	if rwFirst == 0 && rwLast == 0xFFFF {
+		// entire columns
+		return "$" + colName(int(cf)) + ":$" + colName(int(cl))
+	}
+	if cf == 0 && cl == 0xFF {
+		// entire rows
+		return fmt.Sprintf("$%d:$%d", int(rwFirst)+1, int(rwLast)+1)
+	}
+	first := cellRef(rwFirst, colFirst)
+	last := cellRef(rwLast, colLast)
+	if first == last {
+		return first
+	}
+	return first + ":" + last
+}
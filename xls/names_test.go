@@ -0,0 +1,83 @@
+package xls
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wubin1989/grate"
+)
+
+func TestDecodeLbl(t *testing.T) {
+	b := &WorkBook{
+		sheets: []*boundSheet{{Name: "Data"}, {Name: "My Sheet"}},
+		xtis:   []xti{{SupBook: 0, FirstItab: 1, LastItab: 1}},
+	}
+
+	// sheet-local built-in Print_Area name on "My Sheet" => 'My Sheet'!$A$1:$C$10
+	lbl := []byte{
+		0x20, 0x00, // grbit: fBuiltin
+		0x00,       // chKey
+		0x01,       // cch
+		0x0B, 0x00, // cce
+		0x00, 0x00, // reserved
+		0x02, 0x00, // itab
+		0x00, 0x00, 0x00, 0x00,
+		0x00, // 8-bit chars
+		0x06, // Print_Area
+		0x3B, 0x00, 0x00, 0x00, 0x00, 0x09, 0x00, 0x00, 0x00, 0x02, 0x00,
+	}
+	dn, ok := b.decodeLbl(lbl)
+	if !ok {
+		t.Fatal("failed to decode name")
+	}
+	want := DefinedName{Name: "_xlnm.Print_Area", Sheet: "My Sheet", Range: "'My Sheet'!$A$1:$C$10"}
+	if dn != want {
+		t.Fatalf("got %+v, expected %+v", dn, want)
+	}
+
+	// global user-defined name
+	lbl = []byte{
+		0x00, 0x00, 0x00, 0x05, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 'T', 'o', 't', 'a', 'l',
+		0x3A, 0x00, 0x00, 0x04, 0x00, 0x01, 0x00,
+	}
+	dn, ok = b.decodeLbl(lbl)
+	if !ok {
+		t.Fatal("failed to decode name")
+	}
+	want = DefinedName{Name: "Total", Range: "'My Sheet'!$B$5"}
+	if dn != want {
+		t.Fatalf("got %+v, expected %+v", dn, want)
+	}
+
+	// built-in name without its character code
+	lbl = []byte{
+		0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	if dn, ok = b.decodeLbl(lbl); ok {
+		t.Fatalf("expected a malformed name, got %+v", dn)
+	}
+}
+
+func TestMaxSheets(t *testing.T) {
+	b := &WorkBook{
+		sheets: []*boundSheet{{Name: "One"}, {Name: "Hidden", HiddenState: 1}, {Name: "Two"}, {Name: "Three"}},
+		opts:   grate.ParseOptions(grate.WithMaxSheets(3)),
+	}
+	names, _ := b.List()
+	if !reflect.DeepEqual(names, []string{"One", "Two"}) {
+		t.Errorf("unexpected sheets %v", names)
+	}
+	if n, _ := b.SheetCount(); n != 2 {
+		t.Errorf("expected 2 sheets, got %d", n)
+	}
+	if hidden, _ := b.ListHidden(); !reflect.DeepEqual(hidden, []string{"Hidden"}) {
+		t.Errorf("unexpected hidden sheets %v", hidden)
+	}
+	if _, err := b.Get("Three"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+}
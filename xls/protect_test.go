@@ -0,0 +1,204 @@
+package xls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/commonxl"
+	"github.com/wubin1989/grate/xls/cfb"
+)
+
+// readTestStream returns the raw Workbook stream of an xls file.
+func readTestStream(t *testing.T, filename string) []byte {
+	t.Helper()
+	doc, err := cfb.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdr, err := doc.Open("Workbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// insertRecords inserts encoded records after the record starting at
+// stream position at, updating the BoundSheet8 substream positions.
+func insertRecords(raw []byte, at int, recs []byte) []byte {
+	at += 4 + int(binary.LittleEndian.Uint16(raw[at+2:]))
+	res := append([]byte{}, raw[:at]...)
+	res = append(res, recs...)
+	res = append(res, raw[at:]...)
+
+	for pos := 0; len(res[pos:]) > 4; {
+		rt := recordType(binary.LittleEndian.Uint16(res[pos:]))
+		size := int(binary.LittleEndian.Uint16(res[pos+2:]))
+		if rt == RecTypeBoundSheet8 {
+			if p := binary.LittleEndian.Uint32(res[pos+4:]); int(p) >= at {
+				binary.LittleEndian.PutUint32(res[pos+4:], p+uint32(len(recs)))
+			}
+		}
+		pos += 4 + size
+	}
+	return res
+}
+
+func TestProtection(t *testing.T) {
+	raw := readTestStream(t, "../testdata/basic.xls")
+	wb, err := loadTestStream(t, raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _ := wb.List()
+	s, err := wb.Sheet(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wb.IsProtected() || s.IsProtected() || s.PasswordHash() != 0 {
+		t.Fatal("expected unprotected workbook")
+	}
+	if _, err = wb.Get("no such sheet"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+
+	// protect the workbook globals and the first sheet (with a password)
+	sheetPos := int(wb.sheets[0].Position)
+	raw = insertRecords(raw, sheetPos, []byte{
+		0x12, 0x00, 0x02, 0x00, 0x01, 0x00, // Protect
+		0x13, 0x00, 0x02, 0x00, 0x4B, 0xCE, // Password
+	})
+	raw = insertRecords(raw, 0, []byte{0x12, 0x00, 0x02, 0x00, 0x01, 0x00})
+
+	wb, err = loadTestStream(t, raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = wb.Sheet(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wb.IsProtected() || !s.IsProtected() || s.PasswordHash() != 0xCE4B {
+		t.Fatalf("expected protection, got workbook=%v sheet=%v hash=%04x",
+			wb.IsProtected(), s.IsProtected(), s.PasswordHash())
+	}
+	if !s.Next() {
+		t.Fatal("expected protected sheet contents")
+	}
+}
+
+// bookDoc is a compound file with only a "Book" stream, which fails to be
+// read after its BOF record.
+type bookDoc []byte
+
+func (d bookDoc) Open(name string) (io.ReadSeeker, error) {
+	if name != "Book" {
+		return nil, errors.New("not found")
+	}
+	return failingReader{bytes.NewReader(d)}, nil
+}
+
+type failingReader struct {
+	*bytes.Reader
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func TestUnsupportedBIFFVersionReadError(t *testing.T) {
+	_, err := readWorkbookStream(bookDoc{0x09, 0x08, 0x08, 0x00, 0x00, 0x05, 0x05, 0x00})
+	var verr ErrUnsupportedBIFFVersion
+	if !errors.As(err, &verr) || verr.Version != 5 {
+		t.Fatalf("expected ErrUnsupportedBIFFVersion{5}, got %v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected the read error, got %v", err)
+	}
+}
+
+func TestUnsupportedBIFFVersion(t *testing.T) {
+	// BIFF5 BOF (workbook globals) followed by EOF
+	raw := []byte{
+		0x09, 0x08, 0x08, 0x00, 0x00, 0x05, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x0A, 0x00, 0x00, 0x00,
+	}
+	_, err := loadTestStream(t, raw, "")
+	var verr ErrUnsupportedBIFFVersion
+	if !errors.As(err, &verr) || verr.Version != 5 {
+		t.Fatalf("expected ErrUnsupportedBIFFVersion{5}, got %v", err)
+	}
+	if v := biffVersion(raw); v != 5 {
+		t.Errorf("expected BIFF version 5, got %d", v)
+	}
+
+	// a BOF record too short to hold the version
+	raw = []byte{0x09, 0x08, 0x01, 0x00, 0x00, 0x0A, 0x00, 0x00, 0x00}
+	if _, err = loadTestStream(t, raw, ""); err == nil {
+		t.Fatal("expected an error for a truncated BOF record")
+	}
+}
+
+func TestSheetStats(t *testing.T) {
+	wb, err := Open("../testdata/basic.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	st, err := wb.(*WorkBook).SheetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _ := wb.List()
+	if len(st) != len(names) {
+		t.Fatalf("expected %d sheets, got %d", len(names), len(st))
+	}
+	c, _ := wb.Get(names[0])
+	xs := c.(*commonxl.Sheet)
+	if st[0].Name != names[0] || st[0].EstimatedRows != xs.NumRows || st[0].MaxCols != xs.NumCols {
+		t.Errorf("got %+v, expected %d rows and %d columns", st[0], xs.NumRows, xs.NumCols)
+	}
+}
+
+func TestSheetDimensions(t *testing.T) {
+	wb, err := Open("../testdata/basic.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	names, _ := wb.List()
+	s, err := wb.(*WorkBook).Sheet(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, cols, err := s.Dimensions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != s.NumRows || cols != s.NumCols {
+		t.Errorf("got %dx%d, expected %dx%d", rows, cols, s.NumRows, s.NumCols)
+	}
+
+	// the same dimensions are available without parsing the sheet
+	rows2, cols2, err := wb.(*WorkBook).SheetDimensions(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows2 != rows || cols2 != cols {
+		t.Errorf("got %dx%d, expected %dx%d", rows2, cols2, rows, cols)
+	}
+	if _, _, err := wb.(*WorkBook).SheetDimensions("missing"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+}
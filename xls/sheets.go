@@ -3,7 +3,7 @@ package xls
 import (
 	"encoding/binary"
 	"errors"
-	"log"
+	"fmt"
 	"math"
 	"unicode/utf16"
 
@@ -11,10 +11,19 @@ import (
 	"github.com/wubin1989/grate/commonxl"
 )
 
+// availableSheets returns the sheets of the workbook up to the limit set
+// with grate.WithMaxSheets, which counts hidden sheets too.
+func (b *WorkBook) availableSheets() []*boundSheet {
+	if b.opts.ExceedsSheets(len(b.sheets)) {
+		return b.sheets[:b.opts.MaxSheets]
+	}
+	return b.sheets
+}
+
 // List (visible) sheet names from the workbook.
 func (b *WorkBook) List() ([]string, error) {
 	res := make([]string, 0, len(b.sheets))
-	for _, s := range b.sheets {
+	for _, s := range b.availableSheets() {
 		if (s.HiddenState & 0x03) == 0 {
 			res = append(res, s.Name)
 		}
@@ -22,10 +31,21 @@ func (b *WorkBook) List() ([]string, error) {
 	return res, nil
 }
 
+// SheetCount returns the number of visible sheets in the workbook.
+func (b *WorkBook) SheetCount() (int, error) {
+	n := 0
+	for _, s := range b.availableSheets() {
+		if (s.HiddenState & 0x03) == 0 {
+			n++
+		}
+	}
+	return n, nil
+}
+
 // ListHidden sheet names in the workbook.
 func (b *WorkBook) ListHidden() ([]string, error) {
 	res := make([]string, 0, len(b.sheets))
-	for _, s := range b.sheets {
+	for _, s := range b.availableSheets() {
 		if (s.HiddenState & 0x03) != 0 {
 			res = append(res, s.Name)
 		}
@@ -35,18 +55,152 @@ func (b *WorkBook) ListHidden() ([]string, error) {
 
 // Get opens the named worksheet and return an iterator for its contents.
 func (b *WorkBook) Get(sheetName string) (grate.Collection, error) {
-	for _, s := range b.sheets {
+	s, err := b.Sheet(sheetName)
+	if s == nil {
+		return nil, err
+	}
+	return s.Sheet, err
+}
+
+// Sheet is a worksheet of the workbook. It embeds the parsed cell
+// contents, and provides access to xls-specific sheet metadata.
+type Sheet struct {
+	*commonxl.Sheet
+
+	protected    bool
+	passwordHash uint16
+
+	b  *WorkBook
+	ss int
+
+	// parsed formula tokens by cell, and of shared formulas by master cell
+	formulas map[cellPos][]byte
+	shared   map[cellPos][]byte
+}
+
+// Dimensions returns the number of rows declared by the INDEX record of the
+// sheet (falling back to the DIMENSIONS record), and the number of columns
+// declared by the DIMENSIONS record. Only those records are decoded, so the
+// result reflects what the file claims rather than the parsed cells.
+func (s *Sheet) Dimensions() (rows, cols int, err error) {
+	return s.b.substreamDimensions(s.ss)
+}
+
+// IsProtected returns true if the sheet is protected from editing.
+// Protection does not affect reading the sheet contents.
+func (s *Sheet) IsProtected() bool {
+	return s.protected
+}
+
+// PasswordHash returns the stored hash of the sheet protection password,
+// or 0 if there is no password.
+func (s *Sheet) PasswordHash() uint16 {
+	return s.passwordHash
+}
+
+// Sheet opens the named worksheet. Dialog sheets return a nil Sheet.
+func (b *WorkBook) Sheet(sheetName string) (*Sheet, error) {
+	for _, s := range b.availableSheets() {
 		if s.Name == sheetName {
 			ss := b.pos2substream[int64(s.Position)]
 			return b.parseSheet(s, ss)
 		}
 	}
-	return nil, errors.New("xls: sheet not found")
+	return nil, grate.WrapErr(errors.New("xls: sheet not found"), grate.ErrSheetNotFound)
+}
+
+// SheetDimensions returns the dimensions of the named sheet as for
+// Sheet.Dimensions, without parsing its cells.
+func (b *WorkBook) SheetDimensions(sheetName string) (rows, cols int, err error) {
+	for _, s := range b.availableSheets() {
+		if s.Name == sheetName {
+			return b.substreamDimensions(b.pos2substream[int64(s.Position)])
+		}
+	}
+	return 0, 0, grate.WrapErr(errors.New("xls: sheet not found"), grate.ErrSheetNotFound)
+}
+
+// substreamDimensions returns the declared dimensions of substream ss, or
+// an error if it has neither an INDEX nor a DIMENSIONS record.
+func (b *WorkBook) substreamDimensions(ss int) (rows, cols int, err error) {
+	rows, cols, ok := b.sheetDimensions(ss)
+	if !ok {
+		return 0, 0, errors.New("xls: sheet has no INDEX or DIMENSIONS record")
+	}
+	return rows, cols, nil
+}
+
+// SheetStats returns the size of each visible sheet as declared by its
+// INDEX and DIMENSIONS records, without parsing the cells.
+func (b *WorkBook) SheetStats() ([]grate.SheetStats, error) {
+	res := make([]grate.SheetStats, 0, len(b.sheets))
+	for _, s := range b.availableSheets() {
+		if (s.HiddenState & 0x03) != 0 {
+			continue
+		}
+		st := grate.SheetStats{Name: s.Name}
+		st.EstimatedRows, st.MaxCols, _ = b.sheetDimensions(b.pos2substream[int64(s.Position)])
+		res = append(res, st)
+	}
+	return res, nil
+}
+
+// sheetDimensions returns the number of rows from the INDEX record of the
+// substream (falling back to the DIMENSIONS record), and the number of
+// columns from the DIMENSIONS record. ok is false if neither record exists.
+func (b *WorkBook) sheetDimensions(ss int) (rows, cols int, ok bool) {
+	if ss >= len(b.substreams) {
+		return 0, 0, false
+	}
+	dimRows, indexRows := -1, -1
+	inSubstream := 0
+	for idx, r := range b.substreams[ss] {
+		if inSubstream > 0 {
+			if r.RecType == RecTypeEOF {
+				inSubstream--
+			}
+			continue
+		}
+		switch r.RecType {
+		case RecTypeBOF:
+			// skip embedded content like charts
+			if idx > 0 {
+				inSubstream++
+			}
+		case RecTypeIndex:
+			// reserved (4), rwMic (4), rwMac (4), ...
+			if len(r.Data) >= 12 {
+				indexRows = int(binary.LittleEndian.Uint32(r.Data[8:12]))
+			}
+		case RecTypeDimensions:
+			// rwMic (4), rwMac (4), colMic (2), colMac (2)
+			if len(r.Data) >= 12 {
+				dimRows = int(binary.LittleEndian.Uint32(r.Data[4:8]))
+				cols = int(binary.LittleEndian.Uint16(r.Data[10:12]))
+			}
+		}
+	}
+	rows = indexRows
+	if rows < 0 {
+		rows = dimRows
+	}
+	if rows < 0 {
+		return 0, cols, false
+	}
+	return rows, cols, true
 }
 
-func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
+// cellBytes is the size of a pre-allocated (blank) cell of a sheet.
+const cellBytes = 24
+
+func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*Sheet, error) {
 	res := &commonxl.Sheet{
 		Formatter: &b.nfmt,
+		Logger:    b.opts.Logger(),
+	}
+	sheet := &Sheet{Sheet: res, b: b, ss: ss,
+		formulas: make(map[cellPos][]byte),
+		shared:   make(map[cellPos][]byte),
 	}
 	var minRow, maxRow uint32
 	var minCol, maxCol uint16
@@ -72,30 +226,48 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 				continue
 			}
 		case RecTypeWsBool:
-			if (r.Data[1] & 0x10) != 0 {
+			if len(r.Data) >= 2 && (r.Data[1]&0x10) != 0 {
 				// it's a dialog
 				return nil, nil
 			}
 
 		case RecTypeDimensions:
+			if len(r.Data) < 12 {
+				return nil, fmt.Errorf("xls: truncated %s record", r.RecType)
+			}
 			// max = 0-based index of the row AFTER the last valid index
 			minRow = binary.LittleEndian.Uint32(r.Data[:4])
 			maxRow = binary.LittleEndian.Uint32(r.Data[4:8]) // max = 0x010000
 			minCol = binary.LittleEndian.Uint16(r.Data[8:10])
 			maxCol = binary.LittleEndian.Uint16(r.Data[10:12]) // max = 0x000100
-			if grate.Debug {
-				log.Printf("    Sheet dimensions (%d, %d) - (%d,%d)",
-					minCol, minRow, maxCol, maxRow)
-			}
-			if minRow > 0x0000FFFF || maxRow > 0x00010000 {
-				log.Println("invalid dimensions")
+			b.opts.Logger().Debug("xls: sheet dimensions",
+				"minCol", minCol, "minRow", minRow, "maxCol", maxCol, "maxRow", maxRow)
+			if minRow > 0x0000FFFF || maxRow > 0x00010000 || minCol > 0x00FF || maxCol > 0x0100 {
+				b.opts.Logger().Debug("xls: invalid sheet dimensions")
+				// don't pre-allocate more than a BIFF8 sheet can hold
+				if maxRow > 0x00010000 {
+					maxRow = 0x00010000
+				}
+				if maxCol > 0x0100 {
+					maxCol = 0x0100
+				}
 			}
-			if minCol > 0x00FF || maxCol > 0x0100 {
-				log.Println("invalid dimensions")
+
+			// pre-allocate cells, unless the declared size alone is over
+			// the memory limit, in which case cells are added as they are
+			// read
+			if n := int64(maxRow) * int64(maxCol) * cellBytes; b.opts.ExceedsMemory(n) {
+				b.opts.Logger().Debug("xls: sheet dimensions over the memory limit", "bytes", n)
+			} else {
+				res.Resize(int(maxRow), int(maxCol))
 			}
 
-			// pre-allocate cells
-			res.Resize(int(maxRow), int(maxCol))
+		case RecTypeProtect:
+			sheet.protected = len(r.Data) >= 2 && binary.LittleEndian.Uint16(r.Data) != 0
+		case RecTypePassword:
+			if len(r.Data) >= 2 {
+				sheet.passwordHash = binary.LittleEndian.Uint16(r.Data)
+			}
 		}
 	}
 	inSubstream = 0
@@ -105,8 +277,8 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 		if inSubstream > 0 {
 			if r.RecType == RecTypeEOF {
 				inSubstream--
-			} else if grate.Debug {
-				log.Println("      Unhandled sheet substream record type:", r.RecType, ridx)
+			} else {
+				b.opts.Logger().Debug("xls: unhandled sheet substream record type", "type", r.RecType, "index", ridx)
 			}
 			continue
 		}
@@ -118,10 +290,27 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 			FORMULA = [Uncalced] Formula [Array / Table / ShrFmla / SUB] [String *Continue]
 
 			Not parsed form the list above:
-				DBCell, EntExU2, Uncalced, Array, Table,ShrFmla
+				DBCell, EntExU2, Uncalced, Array, Table
 				NB: no idea what "SUB" is
 		*/
 
+		if len(r.Data) < minCellRecordSize[r.RecType] {
+			err := fmt.Errorf("xls: truncated %s record", r.RecType)
+			if !isCellRecord(r.RecType) || len(r.Data) < 2 {
+				return nil, err
+			}
+			// the row of the cell is known, so only it is lost
+			if !b.skipRow(res, int(binary.LittleEndian.Uint16(r.Data)), err) {
+				return nil, err
+			}
+			continue
+		}
+		if isCellRecord(r.RecType) && binary.LittleEndian.Uint16(r.Data[2:4]) > 0xFF {
+			// BIFF8 sheets have 256 columns, so the record is corrupt
+			b.opts.Logger().Debug("xls: cell column out of range", "type", r.RecType, "index", ridx)
+			continue
+		}
+
 		switch r.RecType {
 		case RecTypeBOF:
 			if ridx > 0 {
@@ -157,9 +346,12 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 
 		case RecTypeMulRk:
 			// MulRk encodes multiple RK values in a row
-			nrk := int((r.RecSize - 6) / 6)
+			nrk := (len(r.Data) - 6) / 6
 			rowIndex := int(binary.LittleEndian.Uint16(r.Data[:2]))
 			colIndex := int(binary.LittleEndian.Uint16(r.Data[2:4]))
+			if colIndex+nrk > 0x100 {
+				nrk = 0x100 - colIndex
+			}
 			for i := 0; i < nrk; i++ {
 				off := 4 + i*6
 				ixfe := int(binary.LittleEndian.Uint16(r.Data[off:]))
@@ -217,6 +409,9 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 			formulaCol = binary.LittleEndian.Uint16(r.Data[2:4])
 			ixfe := int(binary.LittleEndian.Uint16(r.Data[4:6]))
 			fdata := r.Data[6:]
+			if rgce := formulaRgce(r.Data); rgce != nil {
+				sheet.formulas[cellPos{int(formulaRow), int(formulaCol)}] = append([]byte(nil), rgce...)
+			}
 			var fno uint16
 			if ixfe < len(b.xfs) {
 				fno = b.xfs[ixfe]
@@ -244,7 +439,7 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 				case 3:
 					// blank string
 				default:
-					log.Printf("unknown formula value type %d", fdata[0])
+					b.opts.Logger().Debug("xls: unknown formula value type", "type", fdata[0])
 				}
 			} else {
 				xnum := binary.LittleEndian.Uint64(fdata)
@@ -253,6 +448,16 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 			}
 			//log.Printf("formula spec: %d %d ~~ %+v", formulaRow, formulaCol, r.Data)
 
+		case RecTypeShrFmla:
+			// the formula shared by the range, which follows the Formula
+			// record of its master cell (the first cell of the range)
+			if rgce := shrFmlaRgce(r.Data); rgce != nil {
+				rgce = append([]byte(nil), rgce...)
+				sheet.shared[cellPos{int(formulaRow), int(formulaCol)}] = rgce
+				first := cellPos{int(binary.LittleEndian.Uint16(r.Data)), int(r.Data[4])}
+				sheet.shared[first] = rgce
+			}
+
 		case RecTypeString:
 			// String is the previously rendered value of a formula
 			// NB similar to the workbook SST, this can continue over
@@ -267,6 +472,9 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 				fstr = string(r.Data[3:])
 			} else {
 				raw := r.Data[3:]
+				if int(charCount) > len(raw)/2 {
+					charCount = uint16(len(raw) / 2)
+				}
 				if int(charCount) > cap(us) {
 					us = make([]uint16, charCount)
 				}
@@ -286,11 +494,18 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 					if r2.RecType != RecTypeContinue {
 						break
 					}
+					if len(r2.Data) == 0 {
+						ridx2++
+						continue
+					}
 					if (r2.Data[0] & 1) == 0 {
 						fstr += string(r2.Data[1:])
 					} else {
 						raw := r2.Data[1:]
 						slen := len(raw) / 2
+						if slen > cap(us) {
+							us = make([]uint16, slen)
+						}
 						us = us[:slen]
 						for i := 0; i < slen; i++ {
 							us[i] = binary.LittleEndian.Uint16(raw)
@@ -309,8 +524,12 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 			colIndex := int(binary.LittleEndian.Uint16(r.Data[2:4]))
 			ixfe := int(binary.LittleEndian.Uint16(r.Data[4:6]))
 			sstIndex := int(binary.LittleEndian.Uint32(r.Data[6:]))
-			if sstIndex > len(b.strings) {
-				return nil, errors.New("xls: invalid sst index")
+			if sstIndex >= len(b.strings) {
+				err := fmt.Errorf("xls: invalid sst index %d", sstIndex)
+				if !b.skipRow(res, rowIndex, err) {
+					return nil, err
+				}
+				continue
 			}
 			var fno uint16
 			if ixfe < len(b.xfs) {
@@ -340,12 +559,15 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 			if lastCol == 0xFF { // placeholder value indicate "last"
 				lastCol = uint16(maxCol) - 1
 			}
+			if lastCol > 0xFF { // BIFF8 sheets have 256 columns
+				lastCol = 0xFF
+			}
 
 			// decode the hyperlink datastructure and try to find the
 			// display text and separate the URL itself.
 			displayText, linkText, err := decodeHyperlinks(r.Data[8:])
 			if err != nil {
-				log.Println(err)
+				b.opts.Logger().Debug("xls: invalid hyperlink", "error", err)
 				continue
 			}
 
@@ -385,7 +607,7 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 
 			cmcs := binary.LittleEndian.Uint16(r.Data[:2])
 			raw := r.Data[2:]
-			for i := 0; i < int(cmcs); i++ {
+			for i := 0; i < int(cmcs) && len(raw) >= 8; i++ {
 				firstRow := binary.LittleEndian.Uint16(raw[:2])
 				lastRow := binary.LittleEndian.Uint16(raw[2:4])
 				firstCol := binary.LittleEndian.Uint16(raw[4:6])
@@ -398,6 +620,9 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 				if lastCol == 0xFF { // placeholder value indicate "last"
 					lastCol = uint16(maxCol) - 1
 				}
+				if lastCol > 0xFF { // BIFF8 sheets have 256 columns
+					lastCol = 0xFF
+				}
 				for rn := int(firstRow); rn <= int(lastRow); rn++ {
 					for cn := int(firstCol); cn <= int(lastCol); cn++ {
 						if rn == int(firstRow) && cn == int(firstCol) {
@@ -435,7 +660,44 @@ func (b *WorkBook) parseSheet(s *boundSheet, ss int) (*commonxl.Sheet, error) {
 			*/
 		}
 	}
-	return res, nil
+	return sheet, nil
+}
+
+// minCellRecordSize is the minimum size of the records parsed by parseSheet.
+var minCellRecordSize = map[recordType]int{
+	RecTypeBoolErr:    8,
+	RecTypeMulRk:      6,
+	RecTypeNumber:     14,
+	RecTypeRK:         10,
+	RecTypeFormula:    20,
+	RecTypeString:     3,
+	RecTypeLabelSst:   10,
+	RecTypeHLink:      8,
+	RecTypeMergeCells: 2,
+}
+
+// isCellRecord returns true for the records which start with the row and
+// column of a cell.
+// skipRow omits a row of the sheet which could not be parsed if the error
+// handler allows it. The handler is called once for each row, however many
+// of its cells are invalid.
+func (b *WorkBook) skipRow(res *commonxl.Sheet, row int, err error) bool {
+	if res.RowSkipped(row) {
+		return true
+	}
+	if !b.opts.SkipRowError(row, err) {
+		return false
+	}
+	res.SkipRow(row)
+	return true
+}
+
+func isCellRecord(rt recordType) bool {
+	switch rt {
+	case RecTypeBoolErr, RecTypeMulRk, RecTypeNumber, RecTypeRK, RecTypeFormula, RecTypeLabelSst:
+		return true
+	}
+	return false
 }
 
 var berrLookup = map[byte]string{
@@ -8,12 +8,23 @@ import (
 	"unicode/utf16"
 )
 
+var (
+	errTruncatedString = errors.New("xls: truncated string")
+	errTruncatedSST    = errors.New("xls: truncated shared string table")
+)
+
 // 2.5.240
 func decodeShortXLUnicodeString(raw []byte) (string, int, error) {
 	// identical to decodeXLUnicodeString except for cch=8bits instead of 16
+	if len(raw) < 2 {
+		return "", 0, errTruncatedString
+	}
 	cch := int(raw[0])
 	flags := raw[1]
 	raw = raw[2:]
+	if ((flags&0x1) == 0 && len(raw) < cch) || ((flags&0x1) != 0 && len(raw) < cch*2) {
+		return "", 0, errTruncatedString
+	}
 
 	content := make([]uint16, cch)
 	if (flags & 0x1) == 0 {
@@ -37,9 +48,15 @@ func decodeShortXLUnicodeString(raw []byte) (string, int, error) {
 // 2.5.294
 func decodeXLUnicodeString(raw []byte) (string, int, error) {
 	// identical to decodeShortXLUnicodeString except for cch=16bits instead of 8
+	if len(raw) < 3 {
+		return "", 0, errTruncatedString
+	}
 	cch := int(binary.LittleEndian.Uint16(raw[:2]))
 	flags := raw[2]
 	raw = raw[3:]
+	if ((flags&0x1) == 0 && len(raw) < cch) || ((flags&0x1) != 0 && len(raw) < cch*2) {
+		return "", 0, errTruncatedString
+	}
 
 	content := make([]uint16, cch)
 	if (flags & 0x1) == 0 {
@@ -139,9 +156,20 @@ func parseSST(recs []*rec) ([]string, error) {
 	// boundary, there's an intervening flags byte that MAY change the string
 	// from an 8-bit encoding to 16-bit or vice versa.
 
+	if len(recs[0].Data) < 8 {
+		return nil, errTruncatedSST
+	}
 	//totalRefs := binary.LittleEndian.Uint32(recs[0].Data[0:4])
 	numStrings := binary.LittleEndian.Uint32(recs[0].Data[4:8])
 
+	// each string takes at least 3 bytes, so don't trust a larger count
+	size := 0
+	for _, r := range recs {
+		size += len(r.Data)
+	}
+	if int64(numStrings) > int64(size/3) {
+		numStrings = uint32(size / 3)
+	}
 	all := make([]string, 0, numStrings)
 	current := make([]uint16, 32*1024)
 
@@ -152,6 +180,9 @@ func parseSST(recs []*rec) ([]string, error) {
 		var cbExtRs uint32
 
 		for len(buf) > 0 {
+			if len(buf) < 3 {
+				return nil, errTruncatedSST
+			}
 			slen := binary.LittleEndian.Uint16(buf)
 			buf = buf[2:]
 			flags = buf[0]
@@ -159,12 +190,18 @@ func parseSST(recs []*rec) ([]string, error) {
 
 			if (flags & 0x8) != 0 {
 				// rich formating data is present
+				if len(buf) < 2 {
+					return nil, errTruncatedSST
+				}
 				cRun := binary.LittleEndian.Uint16(buf)
 				cRunBytes = int(cRun) * 4
 				buf = buf[2:]
 			}
 			if (flags & 0x4) != 0 {
 				// phonetic string data is present
+				if len(buf) < 4 {
+					return nil, errTruncatedSST
+				}
 				cbExtRs = binary.LittleEndian.Uint32(buf)
 				buf = buf[4:]
 			}
@@ -189,6 +226,9 @@ func parseSST(recs []*rec) ([]string, error) {
 			for j := 0; j < int(slen); j++ {
 				if len(buf) == 0 {
 					i++
+					if i >= len(recs) || len(recs[i].Data) < 2 {
+						return nil, errTruncatedSST
+					}
 					if (recs[i].Data[0] & 1) == 0 {
 						flags &= 0xFE
 					} else {
@@ -201,6 +241,9 @@ func parseSST(recs []*rec) ([]string, error) {
 					current[j] = uint16(buf[0])
 					buf = buf[1:]
 				} else { //16-bit
+					if len(buf) < 2 {
+						return nil, errTruncatedSST
+					}
 					current[j] = uint16(binary.LittleEndian.Uint16(buf[:2]))
 					buf = buf[2:]
 					if len(buf) == 1 {
@@ -221,6 +264,9 @@ func parseSST(recs []*rec) ([]string, error) {
 				} else {
 					cRunBytes -= len(buf)
 					i++
+					if i >= len(recs) {
+						return nil, errTruncatedSST
+					}
 					buf = recs[i].Data
 				}
 			}
@@ -232,6 +278,9 @@ func parseSST(recs []*rec) ([]string, error) {
 				} else {
 					cbExtRs -= uint32(len(buf))
 					i++
+					if i >= len(recs) {
+						return nil, errTruncatedSST
+					}
 					buf = recs[i].Data
 				}
 			}
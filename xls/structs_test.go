@@ -1,13 +1,20 @@
 package xls
 
 import (
+	"os"
 	"testing"
 
 	"github.com/wubin1989/grate"
 )
 
 func TestDecimalNumberSavedAsIntegerMultipliedByHundred(t *testing.T) {
-	wb, _ := grate.Open("../testdata/decimal.xls")
+	if _, err := os.Stat("../testdata/decimal.xls"); os.IsNotExist(err) {
+		t.Skip("testdata/decimal.xls is not available")
+	}
+	wb, err := grate.Open("../testdata/decimal.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
 	sheets, _ := wb.List()
 	for _, s := range sheets {
 		sheet, _ := wb.Get(s)
@@ -0,0 +1,207 @@
+package xls
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/wubin1989/grate"
+)
+
+var _ grate.SourceMetadata = (*WorkBook)(nil)
+
+// summaryInfo holds the properties of the SummaryInformation stream.
+type summaryInfo struct {
+	author   string
+	appName  string
+	created  time.Time
+	modified time.Time
+}
+
+// Creator returns the author recorded in the document summary, or "".
+func (b *WorkBook) Creator() string {
+	return b.summaryInfo().author
+}
+
+// Created returns the creation time recorded in the document summary, or
+// the zero time.
+func (b *WorkBook) Created() time.Time {
+	return b.summaryInfo().created
+}
+
+// Modified returns the time the workbook was last saved, as recorded in
+// the document summary, or the zero time.
+func (b *WorkBook) Modified() time.Time {
+	return b.summaryInfo().modified
+}
+
+// Application returns the name of the application which wrote the
+// workbook (e.g. "Microsoft Excel"), or "".
+func (b *WorkBook) Application() string {
+	return b.summaryInfo().appName
+}
+
+// summaryInfo parses the SummaryInformation stream on first use. Missing or
+// malformed properties are left empty.
+func (b *WorkBook) summaryInfo() *summaryInfo {
+	if b.summary != nil {
+		return b.summary
+	}
+	b.summary = &summaryInfo{}
+	if b.doc == nil {
+		return b.summary
+	}
+	r, err := b.doc.Open("\x05SummaryInformation")
+	if err != nil {
+		b.opts.Logger().Debug("xls: no summary information", "error", err)
+		return b.summary
+	}
+	raw, err := io.ReadAll(r)
+	if err == nil {
+		err = parseSummaryInfo(raw, b.summary)
+	}
+	if err != nil {
+		b.opts.Logger().Debug("xls: invalid summary information", "error", err)
+	}
+	return b.summary
+}
+
+// property identifiers and types of the summary information property set
+// (MS-OLEPS section 2.18 and 2.15)
+const (
+	pidCodePage   = 1
+	pidAuthor     = 4
+	pidCreateTime = 12
+	pidSaveTime   = 13
+	pidAppName    = 18
+
+	vtI2       = 0x02
+	vtLPSTR    = 0x1E
+	vtFILETIME = 0x40
+)
+
+var errBadPropertySet = errors.New("xls: malformed property set")
+
+// parseSummaryInfo decodes the properties of the first section of a
+// property set stream into si.
+func parseSummaryInfo(raw []byte, si *summaryInfo) error {
+	if len(raw) < 48 || binary.LittleEndian.Uint16(raw) != 0xFFFE {
+		return errBadPropertySet
+	}
+	sec := int(binary.LittleEndian.Uint32(raw[44:]))
+	if sec < 0 || sec+8 > len(raw) {
+		return errBadPropertySet
+	}
+	section := raw[sec:]
+	n := int(binary.LittleEndian.Uint32(section[4:]))
+	if n < 0 || 8+n*8 > len(section) {
+		return errBadPropertySet
+	}
+
+	// property values by identifier
+	props := make(map[uint32][]byte, n)
+	for i := 0; i < n; i++ {
+		id := binary.LittleEndian.Uint32(section[8+i*8:])
+		off := int(binary.LittleEndian.Uint32(section[12+i*8:]))
+		if off < 0 || off+4 > len(section) {
+			return errBadPropertySet
+		}
+		props[id] = section[off:]
+	}
+
+	codepage := uint16(1252)
+	if v := props[pidCodePage]; len(v) >= 6 && binary.LittleEndian.Uint16(v) == vtI2 {
+		codepage = binary.LittleEndian.Uint16(v[4:])
+	}
+	si.author = propString(props[pidAuthor], codepage)
+	si.appName = propString(props[pidAppName], codepage)
+	si.created = propTime(props[pidCreateTime])
+	si.modified = propTime(props[pidSaveTime])
+	return nil
+}
+
+// propString decodes a VT_LPSTR property value in the codepage of the
+// property set.
+func propString(v []byte, codepage uint16) string {
+	if len(v) < 8 || binary.LittleEndian.Uint16(v) != vtLPSTR {
+		return ""
+	}
+	size := int(binary.LittleEndian.Uint32(v[4:]))
+	if size < 0 || 8+size > len(v) {
+		return ""
+	}
+	data := v[8 : 8+size]
+
+	var s string
+	switch codepage {
+	case 1200:
+		// UTF-16LE
+		u := make([]uint16, len(data)/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(data[i*2:])
+		}
+		s = string(utf16.Decode(u))
+	case 65001:
+		s = string(data)
+	default:
+		dec, err := codepageEncoding(codepage).NewDecoder().Bytes(data)
+		if err != nil {
+			return ""
+		}
+		s = string(dec)
+	}
+	// strings are null terminated, and may be padded
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// codepageEncoding returns the encoding of a Windows codepage, or
+// Windows-1252 for codepages which are not supported.
+func codepageEncoding(codepage uint16) encoding.Encoding {
+	switch codepage {
+	case 1250:
+		return charmap.Windows1250
+	case 1251:
+		return charmap.Windows1251
+	case 1253:
+		return charmap.Windows1253
+	case 1254:
+		return charmap.Windows1254
+	case 1255:
+		return charmap.Windows1255
+	case 1256:
+		return charmap.Windows1256
+	case 1257:
+		return charmap.Windows1257
+	case 1258:
+		return charmap.Windows1258
+	case 10000:
+		return charmap.Macintosh
+	}
+	return charmap.Windows1252
+}
+
+// filetimeOffset is the number of seconds from the FILETIME epoch (1601)
+// to the Unix epoch.
+const filetimeOffset = 11644473600
+
+// propTime decodes a VT_FILETIME property value, a count of 100ns
+// intervals since 1601 (in UTC).
+func propTime(v []byte) time.Time {
+	if len(v) < 12 || binary.LittleEndian.Uint16(v) != vtFILETIME {
+		return time.Time{}
+	}
+	ft := binary.LittleEndian.Uint64(v[4:])
+	if ft == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ft/10000000)-filetimeOffset, int64(ft%10000000)*100).UTC()
+}
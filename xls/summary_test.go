@@ -0,0 +1,91 @@
+package xls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+// buildPropertySet returns a property set stream with a single section
+// holding the given (already encoded) property values.
+func buildPropertySet(props map[uint32][]byte) []byte {
+	ids := []uint32{pidCodePage, pidAuthor, pidCreateTime, pidSaveTime, pidAppName}
+	var values bytes.Buffer
+	var entries []uint32
+	for _, id := range ids {
+		v, ok := props[id]
+		if !ok {
+			continue
+		}
+		entries = append(entries, id, uint32(values.Len()))
+		values.Write(v)
+	}
+	head := 8 + 4*len(entries)
+	for i := 1; i < len(entries); i += 2 {
+		entries[i] += uint32(head)
+	}
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&buf, le, []uint16{0xFFFE, 0})
+	buf.Write(make([]byte, 4+16)) // system identifier, CLSID
+	binary.Write(&buf, le, uint32(1))
+	buf.Write(make([]byte, 16)) // FMTID
+	binary.Write(&buf, le, uint32(48))
+	binary.Write(&buf, le, []uint32{uint32(head + values.Len()), uint32(len(entries) / 2)})
+	binary.Write(&buf, le, entries)
+	buf.Write(values.Bytes())
+	return buf.Bytes()
+}
+
+func lpstr(s []byte) []byte {
+	v := make([]byte, 8, 8+len(s)+1)
+	binary.LittleEndian.PutUint16(v, vtLPSTR)
+	binary.LittleEndian.PutUint32(v[4:], uint32(len(s)+1))
+	return append(append(v, s...), 0)
+}
+
+func filetime(t time.Time) []byte {
+	v := make([]byte, 12)
+	binary.LittleEndian.PutUint16(v, vtFILETIME)
+	binary.LittleEndian.PutUint64(v[4:], uint64(t.Unix()+filetimeOffset)*10000000)
+	return v
+}
+
+func TestSummaryInfo(t *testing.T) {
+	created := time.Date(2021, 2, 16, 22, 59, 30, 0, time.UTC)
+	modified := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	raw := buildPropertySet(map[uint32][]byte{
+		pidCodePage:   {vtI2, 0, 0, 0, 0xE4, 0x04, 0, 0}, // 1252
+		pidAuthor:     lpstr([]byte("Ren\xe9e")),
+		pidCreateTime: filetime(created),
+		pidSaveTime:   filetime(modified),
+		pidAppName:    lpstr([]byte("Microsoft Excel")),
+	})
+	var si summaryInfo
+	if err := parseSummaryInfo(raw, &si); err != nil {
+		t.Fatal(err)
+	}
+	want := summaryInfo{author: "Renée", appName: "Microsoft Excel", created: created, modified: modified}
+	if si != want {
+		t.Errorf("got %+v, expected %+v", si, want)
+	}
+
+	if err := parseSummaryInfo(raw[:40], &si); err == nil {
+		t.Error("expected an error for a truncated property set")
+	}
+
+	// the test files only record the codepage
+	wb, err := Open("../testdata/basic.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	m := wb.(grate.SourceMetadata)
+	if m.Creator() != "" || m.Application() != "" || !m.Created().IsZero() || !m.Modified().IsZero() {
+		t.Errorf("expected empty properties, got %+v", *wb.(*WorkBook).summary)
+	}
+}
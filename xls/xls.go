@@ -10,9 +10,11 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"image/color"
 	"io"
 	"io/fs"
-	"log"
+	"os"
 	"sync"
 
 	"github.com/wubin1989/grate"
@@ -24,6 +26,8 @@ import (
 var _ = grate.Register("xls", 1, Open)
 var _ = grate.RegisterFile("xls", 1, OpenFile)
 var _ = grate.RegisterReader("xls", 1, OpenReader)
+var _ = grate.RegisterOptions("xls", OpenWithOptions)
+var _ = grate.RegisterDetect("xls", cfb.HasSignature)
 
 // WorkBook represents an Excel workbook containing 1 or more sheets.
 type WorkBook struct {
@@ -46,13 +50,55 @@ type WorkBook struct {
 
 	nfmt commonxl.Formatter
 	xfs  []uint16
+	opts grate.Options
+
+	// raw Lbl record contents and ExternSheet entries, decoded on demand
+	names [][]byte
+	xtis  []xti
+
+	// font table, and the custom colours of the Palette record
+	fonts   []fontRec
+	palette []color.RGBA
+
+	// summary information properties, parsed on first use
+	summary *summaryInfo
 }
 
+// IsProtected returns true if the workbook structure is protected from
+// editing. Protection does not affect reading the workbook contents.
 func (b *WorkBook) IsProtected() bool {
 	return b.prot
 }
 
 func Open(filename string) (grate.Source, error) {
+	return OpenWithOptions(filename)
+}
+
+// OpenWithPassword opens a password-protected Excel workbook. Both RC4
+// encryption and XOR obfuscation are supported. If the password is
+// incorrect, an error wrapping grate.ErrWrongPassword is returned.
+func OpenWithPassword(filename, password string) (grate.Source, error) {
+	return openWorkBook(filename, password)
+}
+
+// OpenWithOptions opens an Excel workbook using the given options.
+// Supported: grate.MaxMemoryBytes, grate.DateTimezone, grate.WithErrorHandler,
+// grate.WithPassword, grate.WithDebugLogger, grate.WithMaxSheets,
+// grate.WithLocale.
+func OpenWithOptions(filename string, opts ...grate.Option) (grate.Source, error) {
+	return openWorkBook(filename, "", opts...)
+}
+
+func openWorkBook(filename, password string, opts ...grate.Option) (grate.Source, error) {
+	o := grate.ParseOptions(opts...)
+	if password == "" {
+		password = o.Password
+	}
+	if o.MaxMemoryBytes > 0 {
+		if err := checkMemory(filename, o); err != nil {
+			return nil, err
+		}
+	}
 	doc, err := cfb.Open(filename)
 	if err != nil {
 		return nil, err
@@ -61,24 +107,57 @@ func Open(filename string) (grate.Source, error) {
 	b := &WorkBook{
 		filename: filename,
 		doc:      doc,
+		password: password,
+		opts:     o,
 
 		pos2substream: make(map[int64]int, 16),
 		xfs:           make([]uint16, 0, 128),
 	}
+	b.nfmt = b.nfmt.WithLocale(o.Locale)
+	b.nfmt.SetLocation(o.DateTimezone)
 
-	rdr, err := doc.Open("Workbook")
-	if err != nil {
-		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
-	}
-	raw, err := io.ReadAll(rdr)
+	raw, err := readWorkbookStream(doc)
 	if err != nil {
 		return nil, err
 	}
 
 	err = b.loadFromStream(raw)
+	if err == nil && len(b.availableSheets()) < len(b.sheets) {
+		b.opts.Logger().Warn("xls: too many sheets, only the first are available",
+			"sheets", len(b.sheets), "limit", b.opts.MaxSheets)
+	}
 	return b, err
 }
 
+// checkMemory returns ErrTooLarge if filename is a compound file which
+// exceeds the configured memory limit. Other files return ErrNotInFormat
+// instead, so that the remaining formats are still tried.
+func checkMemory(filename string, o grate.Options) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(f, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return grate.ErrNotInFormat
+		}
+		return err
+	}
+	if !cfb.HasSignature(header) {
+		return grate.ErrNotInFormat
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if o.ExceedsMemory(info.Size()) {
+		return grate.ErrTooLarge
+	}
+	return nil
+}
+
 // OpenFile opens an Excel workbook from an fs.File.
 func OpenFile(file fs.File) (grate.Source, error) {
 	doc, err := cfb.OpenFile(file)
@@ -93,11 +172,7 @@ func OpenFile(file fs.File) (grate.Source, error) {
 		xfs:           make([]uint16, 0, 128),
 	}
 
-	rdr, err := doc.Open("Workbook")
-	if err != nil {
-		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
-	}
-	raw, err := io.ReadAll(rdr)
+	raw, err := readWorkbookStream(doc)
 	if err != nil {
 		return nil, err
 	}
@@ -119,11 +194,7 @@ func OpenReader(reader io.ReadCloser) (grate.Source, error) {
 		xfs:           make([]uint16, 0, 128),
 	}
 
-	rdr, err := doc.Open("Workbook")
-	if err != nil {
-		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
-	}
-	raw, err := io.ReadAll(rdr)
+	raw, err := readWorkbookStream(doc)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +203,60 @@ func OpenReader(reader io.ReadCloser) (grate.Source, error) {
 	return b, err
 }
 
+// ErrUnsupportedBIFFVersion is returned for workbooks saved in a BIFF
+// version other than BIFF8 (Excel 97 and later), such as the BIFF5/BIFF7
+// files written by Excel 5.0 and 95.
+type ErrUnsupportedBIFFVersion struct {
+	// Version is the BIFF version of the file, e.g. 5.
+	Version int
+}
+
+func (e ErrUnsupportedBIFFVersion) Error() string {
+	return fmt.Sprintf("xls: unsupported BIFF version %d (only BIFF8 is supported)", e.Version)
+}
+
+// readWorkbookStream returns the contents of the BIFF8 workbook stream of
+// doc (a *cfb.Document). Earlier BIFF versions store the workbook in a
+// "Book" stream instead.
+func readWorkbookStream(doc interface {
+	Open(name string) (io.ReadSeeker, error)
+}) ([]byte, error) {
+	rdr, err := doc.Open("Workbook")
+	if err != nil {
+		if old, err2 := doc.Open("Book"); err2 == nil {
+			raw, err2 := io.ReadAll(old)
+			verr := ErrUnsupportedBIFFVersion{Version: biffVersion(raw)}
+			if err2 != nil {
+				return nil, grate.WrapErr(err2, verr)
+			}
+			return nil, verr
+		}
+		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
+	}
+	return io.ReadAll(rdr)
+}
+
+// biffVersion determines the BIFF version from the BOF record at the start
+// of raw, defaulting to 5 (the version using the "Book" stream name).
+func biffVersion(raw []byte) int {
+	if len(raw) < 6 {
+		return 5
+	}
+	switch binary.LittleEndian.Uint16(raw) {
+	case 0x0009:
+		return 2
+	case 0x0209:
+		return 3
+	case 0x0409:
+		return 4
+	case 0x0809:
+		if binary.LittleEndian.Uint16(raw[4:]) == 0x0600 {
+			return 8
+		}
+	}
+	return 5
+}
+
 func (b *WorkBook) loadFromStream(raw []byte) error {
 	return b.loadFromStream2(raw, false)
 }
@@ -147,9 +272,7 @@ func (b *WorkBook) loadFromStreamWithDecryptor(raw []byte, dec crypto.Decryptor)
 	// a set of overlays applied to the final result which restore the
 	// "cleartext" contents in line with the decrypted content.
 
-	if grate.Debug {
-		log.Println("  Decrypting xls stream with standard RC4")
-	}
+	b.opts.Logger().Debug("xls: decrypting stream with standard RC4")
 
 	pos := 0
 	zeros := [8224]byte{}
@@ -176,15 +299,15 @@ func (b *WorkBook) loadFromStreamWithDecryptor(raw []byte, dec crypto.Decryptor)
 		binary.Write(dec, binary.LittleEndian, o.DataBytes)
 		tocopy := int(o.DataBytes)
 
-		switch o.RecType {
-		case RecTypeBOF, RecTypeFilePass, RecTypeUsrExcl, RecTypeFileLock, RecTypeInterfaceHdr, RecTypeRRDInfo, RecTypeRRDHead:
+		switch {
+		case isClearRecord(o.RecType):
 			// untouched data goes directly into output
 			o.Data = raw[pos : pos+int(o.DataBytes)]
 			pos += int(o.DataBytes)
 			dec.Write(zeros[:int(o.DataBytes)])
 			tocopy = 0
 
-		case RecTypeBoundSheet8:
+		case o.RecType == RecTypeBoundSheet8:
 			// copy 32-bit position to output
 			o.Data = raw[pos : pos+4]
 			pos += 4
@@ -215,6 +338,94 @@ func (b *WorkBook) loadFromStreamWithDecryptor(raw []byte, dec crypto.Decryptor)
 	return b.loadFromStream2(alldata, true)
 }
 
+// decryptStream sets up decryption using the FilePass record data and
+// the workbook password, and reloads the decrypted stream.
+func (b *WorkBook) decryptStream(raw []byte, filePass []byte) error {
+	if len(filePass) < 6 {
+		return grate.WrapErr(errors.New("xls: invalid FilePass record"), grate.ErrEncrypted)
+	}
+	password := b.password
+	if password == "" {
+		password = crypto.DefaultXLSPassword
+	}
+
+	etype := binary.LittleEndian.Uint16(filePass)
+	switch etype {
+	case 0:
+		dec, err := crypto.NewXOR(filePass[2:], password)
+		if err != nil {
+			return b.passwordErr(err)
+		}
+		return b.loadFromStreamWithXOR(raw, dec)
+	case 1:
+		major := binary.LittleEndian.Uint16(filePass[2:])
+		if major != 1 {
+			b.opts.Logger().Debug("xls: need Crypto API RC4 decryptor")
+			return grate.WrapErr(errors.New("xls: unsupported Crypto API encryption method"), grate.ErrEncrypted)
+		}
+		dec, err := crypto.NewBasicRC4WithPassword(filePass[2:], password)
+		if err != nil {
+			return b.passwordErr(err)
+		}
+		return b.loadFromStreamWithDecryptor(raw, dec)
+	default:
+		return grate.WrapErr(errors.New("xls: unsupported encryption method"), grate.ErrEncrypted)
+	}
+}
+
+func (b *WorkBook) passwordErr(err error) error {
+	if !errors.Is(err, crypto.ErrVerificationFailed) {
+		b.opts.Logger().Debug("xls: decryption failed to set up", "error", err)
+		return grate.WrapErr(err, grate.ErrEncrypted)
+	}
+	if b.password == "" {
+		return grate.ErrEncrypted
+	}
+	return grate.WrapErr(errors.New("xls: incorrect password"), grate.ErrWrongPassword)
+}
+
+// isClearRecord returns true for record types which are never encrypted.
+func isClearRecord(rt recordType) bool {
+	switch rt {
+	case RecTypeBOF, RecTypeFilePass, RecTypeUsrExcl, RecTypeFileLock, RecTypeInterfaceHdr, RecTypeRRDInfo, RecTypeRRDHead:
+		return true
+	}
+	return false
+}
+
+func (b *WorkBook) loadFromStreamWithXOR(raw []byte, dec *crypto.XORObfuscation) error {
+	b.opts.Logger().Debug("xls: decrypting stream with XOR obfuscation")
+
+	// record types and sizes are in the clear, and the XOR array index
+	// restarts for each record based on the stream position
+	alldata := make([]byte, len(raw))
+	copy(alldata, raw)
+	pos := 0
+	for len(alldata[pos:]) > 4 {
+		rt := recordType(binary.LittleEndian.Uint16(alldata[pos : pos+2]))
+		size := int(binary.LittleEndian.Uint16(alldata[pos+2 : pos+4]))
+		pos += 4
+		if pos+size > len(alldata) {
+			size = len(alldata) - pos
+		}
+		data := alldata[pos : pos+size]
+		index := pos + size
+		switch {
+		case isClearRecord(rt):
+			data = nil
+		case rt == RecTypeBoundSheet8 && len(data) >= 4:
+			// the 32-bit stream position is not obfuscated
+			data = data[4:]
+			index += 4
+		}
+		dec.Decrypt(data, index)
+		pos += size
+	}
+
+	// recurse into the stream parser now that things are decrypted
+	return b.loadFromStream2(alldata, true)
+}
+
 func (b *WorkBook) Close() error {
 	// return records to the pool for reuse
 	for i, sub := range b.substreams {
@@ -264,23 +475,12 @@ func (b *WorkBook) loadFromStream2(raw []byte, isDecrypted bool) error {
 
 		// if there's a FilePass record, the data is encrypted
 		if nr.RecType == RecTypeFilePass && !isDecrypted {
-			etype := binary.LittleEndian.Uint16(nr.Data)
-			switch etype {
-			case 1:
-				dec, err := crypto.NewBasicRC4(nr.Data[2:])
-				if err != nil {
-					log.Println("xls: rc4 encryption failed to set up", err)
-					return err
-				}
-				return b.loadFromStreamWithDecryptor(rawfull, dec)
-			case 2, 3, 4:
-				log.Println("need Crypto API RC4 decryptor")
-				return errors.New("xls: unsupported Crypto API encryption method")
-			default:
-				return errors.New("xls: unsupported encryption method")
-			}
+			return b.decryptStream(rawfull, nr.Data)
 		}
 
+		if substr < 0 {
+			return errors.New("xls: stream does not begin with a BOF record")
+		}
 		b.substreams[substr] = append(b.substreams[substr], nr)
 		nr, no, err = b.nextRecord(raw)
 	}
@@ -292,9 +492,7 @@ func (b *WorkBook) loadFromStream2(raw []byte, isDecrypted bool) error {
 	}
 
 	for ss, records := range b.substreams {
-		if grate.Debug {
-			log.Printf("  Processing substream %d/%d (%d records)", ss, len(b.substreams), len(records))
-		}
+		b.opts.Logger().Debug("xls: processing substream", "substream", ss, "substreams", len(b.substreams), "records", len(records))
 		for i, nr := range records {
 			if len(nr.Data) == 0 {
 				continue
@@ -324,6 +522,16 @@ func (b *WorkBook) loadFromStream2(raw []byte, isDecrypted bool) error {
 				// done
 
 			case RecTypeBOF:
+				// BIFF5/7 BOF records are shorter, so check the version first
+				if len(nr.Data) < 2 {
+					return errors.New("xls: invalid BOF record")
+				}
+				if v := binary.LittleEndian.Uint16(nr.Data); v == 0x0500 {
+					return ErrUnsupportedBIFFVersion{Version: 5}
+				}
+				if len(nr.Data) < 16 {
+					return errors.New("xls: invalid BOF record")
+				}
 				b.h = &header{
 					Version:  binary.LittleEndian.Uint16(nr.Data[0:2]),
 					DocType:  binary.LittleEndian.Uint16(nr.Data[2:4]),
@@ -348,17 +556,25 @@ func (b *WorkBook) loadFromStream2(raw []byte, isDecrypted bool) error {
 
 			case RecTypeCodePage:
 				// BIFF8 is entirely UTF-16LE so this is actually ignored
+				if len(nr.Data) < 2 {
+					continue
+				}
 				b.codepage = binary.LittleEndian.Uint16(nr.Data)
 
 			case RecTypeDate1904:
+				if len(nr.Data) < 2 {
+					continue
+				}
 				b.dateMode = binary.LittleEndian.Uint16(nr.Data)
 
 			case RecTypeFormat:
 				// Format maps a format ID to a code string
+				if len(nr.Data) < 2 {
+					return errors.New("xls: invalid Format record")
+				}
 				fmtNo := binary.LittleEndian.Uint16(nr.Data)
 				formatStr, _, err := decodeXLUnicodeString(nr.Data[2:])
 				if err != nil {
-					log.Println("fail2", err)
 					return err
 				}
 				b.nfmt.Add(fmtNo, formatStr)
@@ -366,12 +582,18 @@ func (b *WorkBook) loadFromStream2(raw []byte, isDecrypted bool) error {
 			case RecTypeXF:
 				// XF records merge multiple style and format directives to one ID
 				// ignore font id at nr.Data[0:2]
+				if len(nr.Data) < 4 {
+					return errors.New("xls: invalid XF record")
+				}
 				fmtNo := binary.LittleEndian.Uint16(nr.Data[2:])
 				b.xfs = append(b.xfs, fmtNo)
 
 			case RecTypeBoundSheet8:
 				// Identifies the postition within the stream, visibility state,
 				// and name of a worksheet
+				if len(nr.Data) < 6 {
+					return errors.New("xls: invalid BoundSheet8 record")
+				}
 				bs := &boundSheet{}
 				bs.Position = binary.LittleEndian.Uint32(nr.Data[:4])
 				bs.HiddenState = nr.Data[4]
@@ -382,9 +604,50 @@ func (b *WorkBook) loadFromStream2(raw []byte, isDecrypted bool) error {
 					return err
 				}
 				b.sheets = append(b.sheets, bs)
+
+			case RecTypeProtect:
+				if ss == 0 && len(nr.Data) >= 2 {
+					b.prot = binary.LittleEndian.Uint16(nr.Data) != 0
+				}
+
+			case RecTypeExternSheet:
+				if ss != 0 || len(nr.Data) < 2 {
+					continue
+				}
+				cxti := int(binary.LittleEndian.Uint16(nr.Data))
+				raw := nr.Data[2:]
+				for j := 0; j < cxti && len(raw) >= 6; j++ {
+					b.xtis = append(b.xtis, xti{
+						SupBook:   binary.LittleEndian.Uint16(raw),
+						FirstItab: int16(binary.LittleEndian.Uint16(raw[2:])),
+						LastItab:  int16(binary.LittleEndian.Uint16(raw[4:])),
+					})
+					raw = raw[6:]
+				}
+
+			case RecTypeLbl:
+				if ss == 0 {
+					b.names = append(b.names, append([]byte{}, nr.Data...))
+				}
+
+			case RecTypeFont:
+				if ss == 0 {
+					// a placeholder for malformed fonts keeps the indexes of
+					// the following fonts
+					f, ok := parseFont(nr.Data)
+					if !ok {
+						b.opts.Logger().Debug("xls: malformed font record", "index", i)
+					}
+					b.fonts = append(b.fonts, f)
+				}
+
+			case RecTypePalette:
+				if ss == 0 {
+					b.palette = parsePalette(nr.Data)
+				}
 			default:
-				if grate.Debug && ss == 0 {
-					log.Println("    Unhandled record type:", nr.RecType, i)
+				if ss == 0 {
+					b.opts.Logger().Debug("xls: unhandled record type", "type", nr.RecType, "index", i)
 				}
 			}
 		}
@@ -2,9 +2,15 @@ package xls
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"io"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/commonxl"
 )
 
 // 使用testdata中的所有Excel文件测试OpenReader
@@ -21,6 +27,9 @@ func TestOpenReaderWithTestData(t *testing.T) {
 		t.Run(filePath, func(t *testing.T) {
 			// 打开测试文件
 			file, err := os.Open(filePath)
+			if os.IsNotExist(err) {
+				t.Skipf("%s is not available", filePath)
+			}
 			if err != nil {
 				t.Fatalf("Failed to open test file %s: %v", filePath, err)
 			}
@@ -103,3 +112,132 @@ func TestOpenReaderWithTestData(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenWithOptionsMaxMemory(t *testing.T) {
+	_, err := OpenWithOptions("../testdata/basic.xls", grate.MaxMemoryBytes(1024))
+	if !errors.Is(err, grate.ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+
+	// other formats are not checked against the limit
+	_, err = OpenWithOptions("../testdata/basic.xlsx", grate.MaxMemoryBytes(1024))
+	if !errors.Is(err, grate.ErrNotInFormat) {
+		t.Fatalf("expected ErrNotInFormat, got %v", err)
+	}
+
+	wb, err := OpenWithOptions("../testdata/basic.xls", grate.MaxMemoryBytes(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+}
+
+func TestOpenWithOptionsLocale(t *testing.T) {
+	wb, err := OpenWithOptions("../testdata/basic.xls", grate.WithLocale(commonxl.LocaleDE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c.Next() && c.Row() < 2 {
+	}
+	if got := c.Strings()[2]; got != "99,1" {
+		t.Errorf("got %q, expected 99,1", got)
+	}
+}
+
+func TestErrorHandlerOncePerRow(t *testing.T) {
+	b, err := loadTestStream(t, readTestStream(t, "../testdata/basic.xls"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _ := b.List()
+	ss := b.pos2substream[int64(b.availableSheets()[0].Position)]
+
+	// truncate one string cell of the first row and break the sst index of
+	// the others
+	n := 0
+	for i, r := range b.substreams[ss] {
+		if r.RecType != RecTypeLabelSst || binary.LittleEndian.Uint16(r.Data) != 0 {
+			continue
+		}
+		if n == 0 {
+			b.substreams[ss][i].Data = r.Data[:4]
+		} else {
+			data := append([]byte(nil), r.Data...)
+			binary.LittleEndian.PutUint32(data[6:], 0xFFFFFF)
+			b.substreams[ss][i].Data = data
+		}
+		n++
+	}
+	if n < 2 {
+		t.Fatalf("expected several string cells in the first row, got %d", n)
+	}
+
+	if _, err = b.Get(names[0]); err == nil {
+		t.Fatal("expected a parse error without an error handler")
+	}
+
+	var errRows []int
+	b.opts = grate.Options{ErrorHandler: func(row int, err error) bool {
+		errRows = append(errRows, row)
+		return true
+	}}
+	c, err := b.Get(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() || c.Row() == 0 {
+		t.Errorf("expected the first row to be skipped, got row %d (%v)", c.Row(), c.Err())
+	}
+	if len(errRows) != 1 || errRows[0] != 0 {
+		t.Errorf("expected one error for row 0, got %v", errRows)
+	}
+}
+
+func TestDimensionsMemoryLimit(t *testing.T) {
+	raw := readTestStream(t, "../testdata/basic.xls")
+	plain, err := loadTestStream(t, raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, _ := plain.List()
+	want, err := plain.Sheet(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := loadTestStream(t, raw, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// claim 32768 rows of 64 columns, which would take 48MiB
+	ss := b.pos2substream[int64(b.availableSheets()[0].Position)]
+	for i, r := range b.substreams[ss] {
+		if r.RecType == RecTypeDimensions {
+			data := append([]byte(nil), r.Data...)
+			binary.LittleEndian.PutUint32(data[4:], 0x8000)
+			binary.LittleEndian.PutUint16(data[10:], 0x40)
+			b.substreams[ss][i].Data = data
+		}
+	}
+	b.opts = grate.Options{MaxMemoryBytes: 1 << 20}
+	s, err := b.Sheet(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NumRows >= 0x8000 || s.NumCols >= 0x40 {
+		t.Errorf("expected the declared dimensions to not be allocated, got %dx%d", s.NumRows, s.NumCols)
+	}
+	for want.Next() {
+		if !s.Next() {
+			t.Fatalf("missing row %d", want.Row())
+		}
+		if got, exp := s.Strings(), want.Strings(); strings.Join(got, "\t") != strings.Join(exp, "\t") {
+			t.Errorf("row %d: got %q, expected %q", want.Row(), got, exp)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package xlsx
+
+// AutoFilterRange returns the cell range covered by the auto-filter of the
+// sheet (e.g. "A1:F20"), and true if the sheet has one. The range is
+// returned even when no rows are hidden by the filter. Its first row is
+// usually the header row of the sheet.
+func (s *Sheet) AutoFilterRange() (string, bool) {
+	if s.loaded() == nil {
+		return "", false
+	}
+	return s.autoFilter, s.autoFilter != ""
+}
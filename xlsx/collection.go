@@ -0,0 +1,110 @@
+package xlsx
+
+import "github.com/wubin1989/grate/commonxl"
+
+// Preload parses the sheet contents if they have not been parsed yet, and
+// returns any error encountered. Sheets returned by Get are parsed on first
+// use, so Preload allows callers to control when the work is done. The
+// sheet metadata (e.g. PaneState) is only available after parsing.
+func (s *Sheet) Preload() error {
+	if s.err == errNotLoaded {
+		s.err = s.parseSheet()
+	}
+	return s.err
+}
+
+// loaded returns the parsed sheet contents, or nil if parsing failed.
+func (s *Sheet) loaded() *commonxl.Sheet {
+	if s.Preload() != nil {
+		return nil
+	}
+	return s.wrapped
+}
+
+// Next advances to the next record of content.
+// It MUST be called prior to any Scan().
+func (s *Sheet) Next() bool {
+	w := s.loaded()
+	return w != nil && w.Next()
+}
+
+// Row returns the zero-based index of the current row.
+func (s *Sheet) Row() int {
+	if s.wrapped == nil {
+		return -1
+	}
+	return s.wrapped.Row()
+}
+
+// Strings extracts values from the current record into a list of strings.
+func (s *Sheet) Strings() []string {
+	if s.wrapped == nil {
+		return nil
+	}
+	return s.wrapped.Strings()
+}
+
+// Types extracts the data types from the current record into a list.
+func (s *Sheet) Types() []string {
+	if s.wrapped == nil {
+		return nil
+	}
+	return s.wrapped.Types()
+}
+
+// Values extracts the native values of the current record into a list.
+func (s *Sheet) Values() []interface{} {
+	if s.wrapped == nil {
+		return nil
+	}
+	return s.wrapped.Values()
+}
+
+// Formats extracts the format codes for the current record into a list.
+func (s *Sheet) Formats() []string {
+	if s.wrapped == nil {
+		return nil
+	}
+	return s.wrapped.Formats()
+}
+
+// Scan extracts values from the current record into the provided arguments.
+func (s *Sheet) Scan(args ...interface{}) error {
+	w := s.loaded()
+	if w == nil {
+		return s.err
+	}
+	return w.Scan(args...)
+}
+
+// IsEmpty returns true if there are no data values.
+func (s *Sheet) IsEmpty() bool {
+	w := s.loaded()
+	return w == nil || w.IsEmpty()
+}
+
+// Width returns the number of columns of the sheet.
+func (s *Sheet) Width() int {
+	w := s.loaded()
+	if w == nil {
+		return 0
+	}
+	return w.Width()
+}
+
+// Err returns the last error that occured. Cells referencing external data
+// are read as "#REF!" errors, and once iteration reaches the row of the
+// first such cell, Err returns an error wrapping grate.ErrExternalReference
+// (in strict mode, parsing fails instead).
+func (s *Sheet) Err() error {
+	if s.err != nil && s.err != errNotLoaded {
+		return s.err
+	}
+	if s.refErr != nil && s.wrapped.Row() >= s.refRow {
+		return s.refErr
+	}
+	if s.wrapped == nil {
+		return nil
+	}
+	return s.wrapped.Err()
+}
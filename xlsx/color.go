@@ -0,0 +1,202 @@
+package xlsx
+
+import (
+	"encoding/xml"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// colorRef is a colour attribute set (CT_Color) as stored in the xml.
+type colorRef struct {
+	rgb     string
+	theme   int // -1 if not set
+	indexed int // -1 if not set
+	tint    float64
+}
+
+// parseColorRef extracts the colour attributes of an element.
+func parseColorRef(attrs []xml.Attr) colorRef {
+	ax := getAttrs(attrs, "rgb", "theme", "indexed", "tint")
+	c := colorRef{rgb: ax[0], theme: -1, indexed: -1}
+	if n, err := strconv.Atoi(ax[1]); err == nil {
+		c.theme = n
+	}
+	if n, err := strconv.Atoi(ax[2]); err == nil {
+		c.indexed = n
+	}
+	c.tint, _ = strconv.ParseFloat(ax[3], 64)
+	return c
+}
+
+// resolveColor converts a colour reference to RGB, returning false if
+// it is unset or cannot be resolved.
+func (d *Document) resolveColor(c colorRef) (color.RGBA, bool) {
+	var res color.RGBA
+	switch {
+	case len(c.rgb) == 8 || len(c.rgb) == 6:
+		v, err := strconv.ParseUint(c.rgb, 16, 32)
+		if err != nil {
+			return res, false
+		}
+		// the alpha channel is ignored by Excel
+		res = rgb(uint32(v))
+	case c.theme >= 0 && c.theme < len(defaultThemeColors):
+		res = d.ThemeColor(c.theme)
+	case c.indexed >= 0 && c.indexed < len(indexedColors):
+		res = indexedColors[c.indexed]
+	default:
+		return res, false
+	}
+	if c.tint != 0 {
+		res = applyTint(res, c.tint)
+	}
+	return res, true
+}
+
+func rgb(v uint32) color.RGBA {
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xFF}
+}
+
+// defaultThemeColors are the colours of the default Office theme, in the
+// order referenced by theme attributes (lt1, dk1, lt2, dk2, accents 1-6,
+// hyperlink, followed hyperlink).
+var defaultThemeColors = []color.RGBA{
+	rgb(0xFFFFFF), rgb(0x000000), rgb(0xEEECE1), rgb(0x1F497D),
+	rgb(0x4F81BD), rgb(0xC0504D), rgb(0x9BBB59), rgb(0x8064A2),
+	rgb(0x4BACC6), rgb(0xF79646), rgb(0x0000FF), rgb(0x800080),
+}
+
+// indexedColors is the default legacy colour palette (section 18.8.27),
+// including the system foreground and background colours (64 and 65).
+var indexedColors = []color.RGBA{
+	rgb(0x000000), rgb(0xFFFFFF), rgb(0xFF0000), rgb(0x00FF00), rgb(0x0000FF), rgb(0xFFFF00), rgb(0xFF00FF), rgb(0x00FFFF),
+	rgb(0x000000), rgb(0xFFFFFF), rgb(0xFF0000), rgb(0x00FF00), rgb(0x0000FF), rgb(0xFFFF00), rgb(0xFF00FF), rgb(0x00FFFF),
+	rgb(0x800000), rgb(0x008000), rgb(0x000080), rgb(0x808000), rgb(0x800080), rgb(0x008080), rgb(0xC0C0C0), rgb(0x808080),
+	rgb(0x9999FF), rgb(0x993366), rgb(0xFFFFCC), rgb(0xCCFFFF), rgb(0x660066), rgb(0xFF8080), rgb(0x0066CC), rgb(0xCCCCFF),
+	rgb(0x000080), rgb(0xFF00FF), rgb(0xFFFF00), rgb(0x00FFFF), rgb(0x800080), rgb(0x800000), rgb(0x008080), rgb(0x0000FF),
+	rgb(0x00CCFF), rgb(0xCCFFFF), rgb(0xCCFFCC), rgb(0xFFFF99), rgb(0x99CCFF), rgb(0xFF99CC), rgb(0xCC99FF), rgb(0xFFCC99),
+	rgb(0x3366FF), rgb(0x33CCCC), rgb(0x99CC00), rgb(0xFFCC00), rgb(0xFF9900), rgb(0xFF6600), rgb(0x666699), rgb(0x969696),
+	rgb(0x003366), rgb(0x339966), rgb(0x003300), rgb(0x333300), rgb(0x993300), rgb(0x993366), rgb(0x333399), rgb(0x333333),
+	rgb(0x000000), rgb(0xFFFFFF),
+}
+
+// applyTint lightens (tint > 0) or darkens (tint < 0) a colour by
+// adjusting its luminance, as described in section 18.8.19.
+func applyTint(c color.RGBA, tint float64) color.RGBA {
+	h, s, l := rgbToHSL(c)
+	if tint < 0 {
+		l = l * (1 + tint)
+	} else {
+		l = l*(1-tint) + tint
+	}
+	return hslToRGB(h, s, l)
+}
+
+func rgbToHSL(c color.RGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, l
+}
+
+func hslToRGB(h, s, l float64) color.RGBA {
+	if s == 0 {
+		v := uint8(math.Round(l * 255))
+		return color.RGBA{R: v, G: v, B: v, A: 0xFF}
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	hue := func(t float64) uint8 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 1.0/2:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(math.Round(v * 255))
+	}
+	return color.RGBA{R: hue(h + 1.0/3), G: hue(h), B: hue(h - 1.0/3), A: 0xFF}
+}
+
+// SheetTabColor returns the tab colour of the named sheet, and false if
+// the sheet uses the default colour (or is not found). Only the start of
+// the sheet is read if it has not been parsed yet.
+func (d *Document) SheetTabColor(name string) (color.RGBA, bool) {
+	s := d.findSheet(name)
+	if s == nil {
+		return color.RGBA{}, false
+	}
+	c := s.tabColor
+	if s.wrapped == nil {
+		c = s.declaredTabColor()
+	}
+	if c == nil {
+		return color.RGBA{}, false
+	}
+	return d.resolveColor(*c)
+}
+
+// declaredTabColor reads the tabColor element of the sheet properties at
+// the start of the sheet, returning nil if it is missing.
+func (s *Sheet) declaredTabColor() *colorRef {
+	dec, clo, err := s.d.openXML(s.docname)
+	if err != nil {
+		return nil
+	}
+	defer clo.Close()
+
+	tok, err := dec.RawToken()
+	for ; err == nil; tok, err = dec.RawToken() {
+		v, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch v.Name.Local {
+		case "tabColor":
+			c := parseColorRef(v.Attr)
+			return &c
+		case "sheetData":
+			// the sheet properties must come before the cells
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package xlsx
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestSheetTabColor(t *testing.T) {
+	tests := []struct {
+		sheetPr string
+		want    color.RGBA
+		ok      bool
+	}{
+		{`<sheetPr><tabColor rgb="FFFF0000"/></sheetPr>`, color.RGBA{0xFF, 0, 0, 0xFF}, true},
+		{`<sheetPr><tabColor indexed="17"/></sheetPr>`, color.RGBA{0, 0x80, 0, 0xFF}, true},
+		{`<sheetPr><tabColor theme="4"/></sheetPr>`, color.RGBA{0x4F, 0x81, 0xBD, 0xFF}, true},
+		{`<sheetPr><tabColor theme="1" tint="0.5"/></sheetPr>`, color.RGBA{0x80, 0x80, 0x80, 0xFF}, true},
+		{``, color.RGBA{}, false},
+	}
+	for _, tc := range tests {
+		sheet := worksheetXML(tc.sheetPr + `<dimension ref="A1"/><sheetData/>`)
+		wb, err := Open(buildFixture(t, map[string]string{"xl/worksheets/sheet1.xml": sheet}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := wb.(*Document)
+		c, ok := d.SheetTabColor("Sheet1")
+		if ok != tc.ok || c != tc.want {
+			t.Errorf("%s: got %v %v, expected %v %v", tc.sheetPr, c, ok, tc.want, tc.ok)
+		}
+		if d.findSheet("Sheet1").wrapped != nil {
+			t.Errorf("%s: expected the sheet to not be parsed", tc.sheetPr)
+		}
+
+		// the colour recorded when parsing is the same
+		if _, err = d.Sheet("Sheet1"); err != nil {
+			t.Fatal(err)
+		}
+		if c, ok = d.SheetTabColor("Sheet1"); ok != tc.ok || c != tc.want {
+			t.Errorf("%s: got %v %v after parsing, expected %v %v", tc.sheetPr, c, ok, tc.want, tc.ok)
+		}
+		wb.Close()
+	}
+}
+
+func TestThemeColor(t *testing.T) {
+	theme := `<?xml version="1.0" encoding="UTF-8"?>
+<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="Custom"><a:themeElements><a:clrScheme name="Custom">` +
+		`<a:dk1><a:sysClr val="windowText" lastClr="101010"/></a:dk1><a:lt1><a:sysClr val="window" lastClr="FAFAFA"/></a:lt1>` +
+		`<a:dk2><a:srgbClr val="202020"/></a:dk2><a:lt2><a:srgbClr val="E0E0E0"/></a:lt2>` +
+		`<a:accent1><a:srgbClr val="FF8000"/></a:accent1>` +
+		`</a:clrScheme><a:fontScheme name="Custom"><a:majorFont><a:latin typeface="Arial"/></a:majorFont></a:fontScheme></a:themeElements></a:theme>`
+	rels := strings.Replace(fixtureWorkbookRels, "</Relationships>",
+		`<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme" Target="theme/theme1.xml"/></Relationships>`, 1)
+	sheet := worksheetXML(`<sheetPr><tabColor theme="4"/></sheetPr><dimension ref="A1"/><sheetData/>`)
+	wb, err := Open(buildFixture(t, map[string]string{
+		"xl/_rels/workbook.xml.rels": rels,
+		"xl/theme/theme1.xml":        theme,
+		"xl/worksheets/sheet1.xml":   sheet,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	d := wb.(*Document)
+
+	want := map[int]color.RGBA{
+		0:  {0xFA, 0xFA, 0xFA, 0xFF},
+		1:  {0x10, 0x10, 0x10, 0xFF},
+		2:  {0xE0, 0xE0, 0xE0, 0xFF},
+		3:  {0x20, 0x20, 0x20, 0xFF},
+		4:  {0xFF, 0x80, 0x00, 0xFF},
+		5:  {0xC0, 0x50, 0x4D, 0xFF}, // not in the theme, uses the default
+		12: {},
+	}
+	for idx, c := range want {
+		if got := d.ThemeColor(idx); got != c {
+			t.Errorf("theme colour %d: got %v, expected %v", idx, got, c)
+		}
+	}
+	if c, ok := d.SheetTabColor("Sheet1"); !ok || c != want[4] {
+		t.Errorf("got tab colour %v %v, expected %v", c, ok, want[4])
+	}
+}
+
+func TestMalformedTheme(t *testing.T) {
+	rels := strings.Replace(fixtureWorkbookRels, "</Relationships>",
+		`<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme" Target="theme/theme1.xml"/></Relationships>`, 1)
+	wb, err := Open(buildFixture(t, map[string]string{
+		"xl/_rels/workbook.xml.rels": rels,
+		"xl/theme/theme1.xml":        `<a:theme><a:clrScheme><a:dk1><a:srgbClr val="FF0000/></a:dk1>`,
+	}))
+	if err != nil {
+		t.Fatalf("expected a malformed theme to be ignored, got %v", err)
+	}
+	defer wb.Close()
+	if got := wb.(*Document).ThemeColor(4); got != defaultThemeColors[4] {
+		t.Errorf("got theme colour %v, expected the default %v", got, defaultThemeColors[4])
+	}
+	c, err := wb.Get("Sheet1")
+	if err != nil || !c.Next() {
+		t.Errorf("expected the sheet to be readable, got %v", err)
+	}
+}
+
+func TestCellFill(t *testing.T) {
+	styles := stylesXML(
+		`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+			`<fill><patternFill patternType="solid"><fgColor rgb="FFFF0000"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+			`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="0" fillId="2" borderId="0" xfId="0" applyFill="1"/></cellXfs>` +
+			`<dxfs count="1"><dxf><fill><patternFill><bgColor rgb="FF00FF00"/></patternFill></fill></dxf></dxfs>`)
+	sheet := fixtureSheetXML("", "")
+	sheet = strings.Replace(sheet, `<c r="B2">`, `<c r="B2" s="1">`, 1)
+	s := getFixtureSheet(t, map[string]string{
+		"xl/styles.xml":            styles,
+		"xl/worksheets/sheet1.xml": sheet,
+	})
+
+	fg, bg, pattern, ok := s.CellFill(1, 1)
+	if !ok || pattern != "solid" || fg != (color.RGBA{0xFF, 0, 0, 0xFF}) || bg != (color.RGBA{0, 0, 0, 0xFF}) {
+		t.Errorf("got %v %v %q %v for B2", fg, bg, pattern, ok)
+	}
+	if _, _, _, ok = s.CellFill(0, 0); ok {
+		t.Error("expected no fill for A1")
+	}
+}
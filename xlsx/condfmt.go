@@ -0,0 +1,26 @@
+package xlsx
+
+// ConditionalFormat describes a single conditional formatting rule
+// applied to a range of cells.
+type ConditionalFormat struct {
+	// Range is the space-separated list of cell ranges covered by the rule, e.g. "A1:A10 C1:C10".
+	Range string
+
+	// Type of the rule, e.g. "cellIs", "expression", "colorScale", "dataBar".
+	Type string
+
+	// Operator for "cellIs" rules, e.g. "greaterThan", "between".
+	Operator string
+
+	// Formula is the first formula of the rule (if any).
+	Formula string
+}
+
+// ConditionalFormats returns the conditional formatting rules defined
+// on the sheet. The conditions are not evaluated.
+func (s *Sheet) ConditionalFormats() []ConditionalFormat {
+	if s.loaded() == nil {
+		return nil
+	}
+	return s.condFormats
+}
@@ -0,0 +1,125 @@
+package xlsx
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+)
+
+// checkContentTypes cross-references the zip members against the
+// [Content_Types].xml manifest, logging a warning for each mismatch.
+// Mismatches are not fatal, but help to diagnose broken files.
+func (d *Document) checkContentTypes() {
+	for _, p := range d.contentTypeProblems() {
+		d.opts.Logger().Warn("xlsx: content types mismatch", "filename", d.filename, "part", p.part, "problem", p.problem)
+	}
+}
+
+type contentTypeProblem struct {
+	part    string
+	problem string
+}
+
+func (d *Document) contentTypeProblems() []contentTypeProblem {
+	dec, c, err := d.openXML("[Content_Types].xml")
+	if err != nil {
+		return []contentTypeProblem{{"/[Content_Types].xml", "missing content types part"}}
+	}
+	defer c.Close()
+
+	defaults := make(map[string]bool)
+	overrides := make(map[string]bool)
+	var overrideOrder []string
+	tok, err := dec.RawToken()
+	for ; err == nil; tok, err = dec.RawToken() {
+		v, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch v.Name.Local {
+		case "Default":
+			ax := getAttrs(v.Attr, "Extension")
+			defaults[strings.ToLower(ax[0])] = true
+		case "Override":
+			ax := getAttrs(v.Attr, "PartName")
+			name := strings.ToLower(ax[0])
+			if !overrides[name] {
+				overrideOrder = append(overrideOrder, ax[0])
+			}
+			overrides[name] = true
+		}
+	}
+	if err != io.EOF {
+		return []contentTypeProblem{{"/[Content_Types].xml", "invalid content types part: " + err.Error()}}
+	}
+
+	var res []contentTypeProblem
+	present := make(map[string]bool, len(d.r.File))
+	for _, zf := range d.r.File {
+		zname := zipPath(zf.Name)
+		if strings.HasSuffix(zname, "/") || zname == "[Content_Types].xml" {
+			continue
+		}
+		name := "/" + strings.TrimPrefix(zname, "/")
+		present[strings.ToLower(name)] = true
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+		if !overrides[strings.ToLower(name)] && !defaults[ext] {
+			res = append(res, contentTypeProblem{name, "part is not listed in the content types"})
+		}
+	}
+	for _, name := range overrideOrder {
+		if !present[strings.ToLower(name)] {
+			res = append(res, contentTypeProblem{name, "listed part is missing from the package"})
+		}
+	}
+	return res
+}
+
+// mainContentTypes are the content types of workbook parts which can be
+// read. Macro-enabled workbooks are read like any other, their macros are
+// never executed.
+var mainContentTypes = map[string]string{
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml":    "workbook",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.template.main+xml": "template",
+	"application/vnd.ms-excel.sheet.macroEnabled.main+xml":                          "macro-enabled workbook",
+	"application/vnd.ms-excel.template.macroEnabled.main+xml":                       "macro-enabled template",
+}
+
+// mainContentType returns the content type listed for the primary document
+// in the [Content_Types].xml manifest, or "" if it is not listed.
+func (d *Document) mainContentType() string {
+	dec, c, err := d.openXML("[Content_Types].xml")
+	if err != nil {
+		return ""
+	}
+	defer c.Close()
+
+	part := "/" + strings.TrimPrefix(zipPath(d.primaryDoc), "/")
+	tok, err := dec.RawToken()
+	for ; err == nil; tok, err = dec.RawToken() {
+		v, ok := tok.(xml.StartElement)
+		if !ok || v.Name.Local != "Override" {
+			continue
+		}
+		ax := getAttrs(v.Attr, "PartName", "ContentType")
+		if strings.EqualFold(ax[0], part) {
+			return ax[1]
+		}
+	}
+	return ""
+}
+
+// checkMainContentType logs the kind of workbook being read, or a warning
+// if the primary document has an unexpected content type.
+func (d *Document) checkMainContentType() {
+	ct := d.mainContentType()
+	if ct == "" {
+		return
+	}
+	if kind, ok := mainContentTypes[ct]; ok {
+		d.opts.Logger().Debug("xlsx: reading "+kind, "filename", d.filename)
+		return
+	}
+	d.opts.Logger().Warn("xlsx: unexpected workbook content type", "filename", d.filename, "type", ct)
+}
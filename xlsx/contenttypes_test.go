@@ -0,0 +1,28 @@
+package xlsx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContentTypeProblems(t *testing.T) {
+	wb, err := Open(buildFixture(t, map[string]string{
+		"[Content_Types].xml": strings.Replace(fixtureContentTypes, "</Types>",
+			`<Override PartName="/xl/calcChain.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.calcChain+xml"/></Types>`, 1),
+		"docProps/thumb.jpeg": "not really a jpeg",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+
+	got := wb.(*Document).contentTypeProblems()
+	want := []contentTypeProblem{
+		{"/docProps/thumb.jpeg", "part is not listed in the content types"},
+		{"/xl/calcChain.xml", "listed part is missing from the package"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, expected %v", got, want)
+	}
+}
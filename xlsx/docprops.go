@@ -0,0 +1,115 @@
+package xlsx
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+var _ grate.SourceMetadata = (*Document)(nil)
+
+// docProps are the core and extended document properties of the package.
+type docProps struct {
+	creator     string
+	application string
+	created     time.Time
+	modified    time.Time
+}
+
+// Creator returns the author of the workbook (dc:creator), or "".
+func (d *Document) Creator() string {
+	return d.docProps().creator
+}
+
+// Created returns the creation time of the workbook (dcterms:created), or
+// the zero time.
+func (d *Document) Created() time.Time {
+	return d.docProps().created
+}
+
+// Modified returns the time the workbook was last saved
+// (dcterms:modified), or the zero time.
+func (d *Document) Modified() time.Time {
+	return d.docProps().modified
+}
+
+// Application returns the name of the application which wrote the
+// workbook (e.g. "Microsoft Excel"), or "".
+func (d *Document) Application() string {
+	return d.docProps().application
+}
+
+// docProps parses the document properties on first use. Missing or
+// malformed properties are left empty.
+func (d *Document) docProps() *docProps {
+	if d.props != nil {
+		return d.props
+	}
+	d.props = &docProps{}
+	for _, name := range d.rels["http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"] {
+		d.parseDocProps(name, map[string]func(string){
+			"creator":  func(s string) { d.props.creator = s },
+			"created":  func(s string) { d.props.created = parseW3CDTF(s) },
+			"modified": func(s string) { d.props.modified = parseW3CDTF(s) },
+		})
+	}
+	for _, name := range d.rels["http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"] {
+		d.parseDocProps(name, map[string]func(string){
+			"Application": func(s string) { d.props.application = s },
+		})
+	}
+	return d.props
+}
+
+// parseDocProps calls the setter of each top-level property element of the
+// named part with its text.
+func (d *Document) parseDocProps(name string, setters map[string]func(string)) {
+	dec, c, err := d.openXML(name)
+	if err != nil {
+		d.opts.Logger().Debug("xlsx: document properties are missing", "name", name, "error", err)
+		return
+	}
+	defer c.Close()
+
+	depth := 0
+	var set func(string)
+	var text strings.Builder
+	tok, err := dec.RawToken()
+	for ; err == nil; tok, err = dec.RawToken() {
+		switch v := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				set = setters[v.Name.Local]
+				text.Reset()
+			}
+		case xml.CharData:
+			if set != nil {
+				text.Write(v)
+			}
+		case xml.EndElement:
+			if depth == 2 && set != nil {
+				set(strings.TrimSpace(text.String()))
+				set = nil
+			}
+			depth--
+		}
+	}
+	if err != io.EOF {
+		d.opts.Logger().Debug("xlsx: malformed document properties", "name", name, "error", err)
+	}
+}
+
+// parseW3CDTF parses the date and time formats used by the core
+// properties, returning the zero time if the value is not valid.
+func parseW3CDTF(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,38 @@
+package xlsx
+
+import (
+	"errors"
+	"io"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/xls/cfb"
+)
+
+// notZipError is called when the contents of ra cannot be read as a zip
+// archive. Workbooks encrypted by Excel are stored as a Compound File Binary
+// document holding the encrypted package, which is reported as
+// grate.ErrEncrypted. Otherwise zerr is wrapped as grate.ErrNotInFormat.
+func notZipError(ra io.ReaderAt, size int64, zerr error) error {
+	sig := make([]byte, 8)
+	if _, err := ra.ReadAt(sig, 0); err != nil || !cfb.HasSignature(sig) {
+		return grate.WrapErr(zerr, grate.ErrNotInFormat)
+	}
+	doc, err := cfb.OpenReader(io.NopCloser(io.NewSectionReader(ra, 0, size)))
+	if err != nil {
+		return grate.WrapErr(zerr, grate.ErrNotInFormat)
+	}
+	names, _ := doc.List()
+	var info, pkg bool
+	for _, name := range names {
+		switch name {
+		case "EncryptionInfo":
+			info = true
+		case "EncryptedPackage":
+			pkg = true
+		}
+	}
+	if !info || !pkg {
+		return grate.WrapErr(zerr, grate.ErrNotInFormat)
+	}
+	return grate.WrapErr(errors.New("xlsx: workbook is encrypted"), grate.ErrEncrypted)
+}
@@ -0,0 +1,48 @@
+package xlsx
+
+import "image/color"
+
+// cellFill is a pattern fill (section 18.8.32) from the styles.
+type cellFill struct {
+	pattern string
+	fg, bg  *colorRef
+}
+
+// styleFill returns the index of the fill used by the cell style, or -1 if
+// the style has no visible fill.
+func (d *Document) styleFill(xf int) int {
+	if xf < 0 || xf >= len(d.xfFills) {
+		return -1
+	}
+	fill := d.xfFills[xf]
+	if fill < 0 || fill >= len(d.fills) {
+		return -1
+	}
+	if p := d.fills[fill].pattern; p == "" || p == "none" {
+		return -1
+	}
+	return fill
+}
+
+// CellFill returns the fill of the cell at the zero-based row and column:
+// the foreground and background colours, and the pattern type (e.g.
+// "solid" or "gray125"). Solid fills use the foreground colour. Colours
+// which are not set are returned as the zero colour. ok is false if the
+// cell has no fill or the sheet cannot be parsed.
+func (s *Sheet) CellFill(row, col int) (fg, bg color.RGBA, pattern string, ok bool) {
+	if s.loaded() == nil {
+		return fg, bg, "", false
+	}
+	fill, found := s.fills[[2]int{row, col}]
+	if !found {
+		return fg, bg, "", false
+	}
+	f := s.d.fills[fill]
+	if f.fg != nil {
+		fg, _ = s.d.resolveColor(*f.fg)
+	}
+	if f.bg != nil {
+		bg, _ = s.d.resolveColor(*f.bg)
+	}
+	return fg, bg, f.pattern, true
+}
@@ -0,0 +1,138 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+const (
+	fixtureContentTypes = `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/sharedStrings.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+	fixtureRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+	fixtureWorkbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	fixtureWorkbookRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/><Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+	fixtureSharedStrings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst count="2" uniqueCount="2" xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>a</t></si><si><t>b</t></si></sst>`
+
+	fixtureStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs></styleSheet>`
+
+	fixtureSheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><dimension ref="A1:B2"/><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row><row r="2"><c r="A2"><v>1</v></c><c r="B2"><v>2</v></c></row></sheetData></worksheet>`
+)
+
+// buildFixture writes a minimal single-sheet xlsx file to a temporary
+// directory and returns its path. Any of the default zip members can be
+// replaced (or removed, using an empty string) via parts.
+func buildFixture(t *testing.T, parts map[string]string) string {
+	t.Helper()
+	files := map[string]string{
+		"[Content_Types].xml":        fixtureContentTypes,
+		"_rels/.rels":                fixtureRels,
+		"xl/workbook.xml":            fixtureWorkbook,
+		"xl/_rels/workbook.xml.rels": fixtureWorkbookRels,
+		"xl/sharedStrings.xml":       fixtureSharedStrings,
+		"xl/styles.xml":              fixtureStyles,
+		"xl/worksheets/sheet1.xml":   fixtureSheet,
+	}
+	for k, v := range parts {
+		if v == "" {
+			delete(files, k)
+		} else {
+			files[k] = v
+		}
+	}
+	names := make([]string, 0, len(files))
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	fn := filepath.Join(t.TempDir(), "fixture.xlsx")
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(f)
+	for _, name := range names {
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+// worksheetXML wraps the elements of a worksheet into a worksheet document.
+func worksheetXML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + body + `</worksheet>`
+}
+
+// stylesXML wraps the elements of a style sheet into a styles document.
+func stylesXML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + body + `</styleSheet>`
+}
+
+// fixtureSheetXML wraps sheet-level elements into a worksheet document
+// around the default sheetData.
+func fixtureSheetXML(before, after string) string {
+	return worksheetXML(`<dimension ref="A1:B2"/>` + before +
+		`<sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row><row r="2"><c r="A2"><v>1</v></c><c r="B2"><v>2</v></c></row></sheetData>` +
+		after)
+}
+
+// openFixtureSheet opens the fixture and returns its first sheet.
+func openFixtureSheet(t *testing.T, parts map[string]string) *Sheet {
+	t.Helper()
+	wb, err := Open(buildFixture(t, parts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wb.Close() })
+	s, err := wb.(*Document).Sheet("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// getFixtureSheet returns Sheet1 of a fixture workbook from Get, so unlike
+// openFixtureSheet it has not been parsed yet.
+func getFixtureSheet(t *testing.T, parts map[string]string) *Sheet {
+	t.Helper()
+	wb, err := Open(buildFixture(t, parts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wb.Close() })
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.(*Sheet)
+	if s.wrapped != nil {
+		t.Fatal("expected Get to not parse the sheet")
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package xlsx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CellFormula returns the formula of the cell at the zero-based row and
+// column, without the leading "=" (e.g. "SUM(A1:A10)"), and true if the
+// cell contains a formula. Cells of a shared formula return the formula
+// of the master cell with its relative references adjusted. Strings and
+// Values return the result last calculated by Excel, and Types the type
+// of that result. The sheet is parsed on first use, and ok is false if it
+// cannot be.
+func (s *Sheet) CellFormula(row, col int) (string, bool) {
+	if s.loaded() == nil {
+		return "", false
+	}
+	f, ok := s.formulas[[2]int{row, col}]
+	return f, ok
+}
+
+// sharedFormula is the formula of the master cell of a shared formula.
+type sharedFormula struct {
+	text     string
+	row, col int
+}
+
+// a1Ref matches A1 style cell references, and string literals and quoted
+// sheet names so that they can be skipped.
+var a1Ref = regexp.MustCompile(`"(?:[^"]|"")*"|'(?:[^']|'')*'|(\$?)([A-Z]{1,3})(\$?)([0-9]+)`)
+
+// shiftFormula moves the relative references of formula by the given
+// number of rows and columns, as Excel does when filling a shared formula.
+// Absolute ($) parts of references, string literals, function names and
+// references to whole rows or columns are left unchanged.
+func shiftFormula(formula string, rows, cols int) string {
+	if rows == 0 && cols == 0 {
+		return formula
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range a1Ref.FindAllStringSubmatchIndex(formula, -1) {
+		if m[4] < 0 {
+			// string literal or sheet name
+			continue
+		}
+		start, end := m[0], m[1]
+		if start > 0 && isNameChar(formula[start-1]) {
+			continue
+		}
+		if end < len(formula) && (isNameChar(formula[end]) || formula[end] == '(') {
+			continue
+		}
+		colRef, rowRef := formula[m[4]:m[5]], formula[m[8]:m[9]]
+		if m[3] == m[2] { // relative column
+			c := col2int(colRef) + cols
+			if c < 0 {
+				continue
+			}
+			colRef = columnName(c)
+		}
+		if m[7] == m[6] { // relative row
+			r, _ := strconv.Atoi(rowRef)
+			if r+rows < 1 {
+				continue
+			}
+			rowRef = strconv.Itoa(r + rows)
+		}
+		sb.WriteString(formula[last:start])
+		sb.WriteString(formula[m[2]:m[3]] + colRef + formula[m[6]:m[7]] + rowRef)
+		last = end
+	}
+	sb.WriteString(formula[last:])
+	return sb.String()
+}
+
+// isNameChar returns true for the characters of names and references.
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+// columnName returns the name of the zero-based column index.
+func columnName(idx int) string {
+	name := ""
+	for idx >= 0 {
+		name = string(rune('A'+idx%26)) + name
+		idx = idx/26 - 1
+	}
+	return name
+}
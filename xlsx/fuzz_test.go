@@ -0,0 +1,33 @@
+package xlsx
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wubin1989/grate/internal/testutil"
+)
+
+// FuzzOpenXLSX checks that malformed files return errors rather than
+// panicking.
+func FuzzOpenXLSX(f *testing.F) {
+	fns, _ := filepath.Glob("../testdata/*.xlsx")
+	for _, fn := range fns {
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		src, err := OpenReader(io.NopCloser(bytes.NewReader(data)))
+		if err != nil {
+			return
+		}
+		defer src.Close()
+		// large (but valid) sheets would slow down fuzzing
+		testutil.DrainSource(src, 10000)
+	})
+}
@@ -0,0 +1,47 @@
+package xlsx
+
+// defaultColumnWidth is Excel's default column width, in characters.
+const defaultColumnWidth = 8.43
+
+type colWidth struct {
+	first, last int // zero-based, inclusive
+	width       float64
+}
+
+// ColumnWidths returns the width of each column of the sheet (zero-based),
+// in characters of the default font. Columns without an explicit width
+// use the sheet's default width.
+func (s *Sheet) ColumnWidths() []float64 {
+	if s.loaded() == nil {
+		return nil
+	}
+	def := s.defaultColWidth
+	if def == 0 {
+		def = defaultColumnWidth
+	}
+	res := make([]float64, s.wrapped.NumCols)
+	for i := range res {
+		res[i] = def
+	}
+	for _, cw := range s.colWidths {
+		for c := cw.first; c <= cw.last && c < len(res); c++ {
+			if c >= 0 {
+				res[c] = cw.width
+			}
+		}
+	}
+	return res
+}
+
+// RowHeights returns the height in points of each row (zero-based) which
+// has an explicit height.
+func (s *Sheet) RowHeights() map[int]float64 {
+	if s.loaded() == nil {
+		return nil
+	}
+	res := make(map[int]float64, len(s.rowHeights))
+	for r, ht := range s.rowHeights {
+		res[r] = ht
+	}
+	return res
+}
@@ -0,0 +1,350 @@
+package xlsx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wubin1989/grate"
+)
+
+func TestConditionalFormats(t *testing.T) {
+	s := getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("",
+			`<conditionalFormatting sqref="A1:B2"><cfRule type="cellIs" dxfId="0" priority="1" operator="between"><formula>1</formula><formula>5</formula></cfRule><cfRule type="colorScale" priority="2"><colorScale/></cfRule></conditionalFormatting>`),
+	})
+	cfs := s.ConditionalFormats()
+	if len(cfs) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(cfs))
+	}
+	want := ConditionalFormat{Range: "A1:B2", Type: "cellIs", Operator: "between", Formula: "1"}
+	if cfs[0] != want {
+		t.Errorf("got %+v, expected %+v", cfs[0], want)
+	}
+	if cfs[1].Type != "colorScale" || cfs[1].Range != "A1:B2" {
+		t.Errorf("unexpected second rule %+v", cfs[1])
+	}
+}
+
+func TestDataValidations(t *testing.T) {
+	s := getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("",
+			`<dataValidations count="3"><dataValidation type="list" allowBlank="1" showInputMessage="1" sqref="A1:A5"><formula1>"Yes,No,Maybe"</formula1></dataValidation><dataValidation type="whole" operator="between" sqref="B1"><formula1>1</formula1><formula2>10</formula2></dataValidation><dataValidation type="list" sqref="C1"><formula1>$D$1:$D$3</formula1></dataValidation></dataValidations>`),
+	})
+	dvs := s.DataValidations()
+	if len(dvs) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(dvs))
+	}
+	if dvs[0].CellRange != "A1:A5" || !dvs[0].AllowBlank || dvs[0].ShowDropDown {
+		t.Errorf("unexpected list rule %+v", dvs[0])
+	}
+	if !reflect.DeepEqual(dvs[0].Values, []string{"Yes", "No", "Maybe"}) {
+		t.Errorf("unexpected list values %v", dvs[0].Values)
+	}
+	if dvs[1].Type != "whole" || dvs[1].Operator != "between" || dvs[1].Formula1 != "1" || dvs[1].Formula2 != "10" {
+		t.Errorf("unexpected whole rule %+v", dvs[1])
+	}
+	if dvs[2].Values != nil {
+		t.Errorf("range-based list should not have literal values: %v", dvs[2].Values)
+	}
+}
+
+func TestSparklines(t *testing.T) {
+	s := getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("",
+			`<extLst><ext uri="{05C60535-1F16-4fd2-B633-F4F36F0B64E0}" xmlns:x14="http://schemas.microsoft.com/office/spreadsheetml/2009/9/main"><x14:sparklineGroups xmlns:xm="http://schemas.microsoft.com/office/excel/2006/main"><x14:sparklineGroup type="column"><x14:colorSeries rgb="FF376092"/><x14:sparklines><x14:sparkline><xm:f>Sheet1!A2:B2</xm:f><xm:sqref>C2</xm:sqref></x14:sparkline></x14:sparklines></x14:sparklineGroup><x14:sparklineGroup><x14:sparklines><x14:sparkline><xm:f>Sheet1!A1:B1</xm:f><xm:sqref>C1</xm:sqref></x14:sparkline></x14:sparklines></x14:sparklineGroup></x14:sparklineGroups></ext></extLst>`),
+	})
+	sl := s.Sparklines()
+	if len(sl) != 2 {
+		t.Fatalf("expected 2 sparklines, got %d", len(sl))
+	}
+	want := SparklineGroup{HostCell: "C2", DataRange: "Sheet1!A2:B2", Type: "column"}
+	if sl[0] != want {
+		t.Errorf("got %+v, expected %+v", sl[0], want)
+	}
+	if sl[1].Type != "line" || sl[1].HostCell != "C1" {
+		t.Errorf("unexpected sparkline %+v", sl[1])
+	}
+}
+
+func TestPaneState(t *testing.T) {
+	s := getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML(
+			`<sheetViews><sheetView workbookViewId="0"><pane xSplit="1" ySplit="2" topLeftCell="B3" activePane="bottomRight" state="frozen"/></sheetView></sheetViews>`, ""),
+	})
+	want := PaneState{FrozenRows: 2, FrozenCols: 1, ActivePane: "bottomRight"}
+	if p := s.PaneState(); p == nil || *p != want {
+		t.Fatalf("got %+v, expected %+v", p, want)
+	}
+
+	s = openFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML(
+			`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1800.5" topLeftCell="A5"/></sheetView></sheetViews>`, ""),
+	})
+	want = PaneState{SplitY: 1800.5, ActivePane: "topLeft"}
+	if p := s.PaneState(); p == nil || *p != want {
+		t.Fatalf("got %+v, expected %+v", p, want)
+	}
+
+	s = openFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("", ""),
+	})
+	if p := s.PaneState(); p != nil {
+		t.Fatalf("expected no pane, got %+v", p)
+	}
+}
+
+func TestErrorCells(t *testing.T) {
+	s := openFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": worksheetXML(`<dimension ref="A1:C1"/><sheetData><row r="1"><c r="A1" t="e"><f>1/0</f><v>#DIV/0!</v></c><c r="B1" t="e"><v>23</v></c><c r="C1"><v>5</v></c></row></sheetData>`),
+	})
+	c := s.wrapped
+	if !c.Next() {
+		t.Fatal("expected a row")
+	}
+	if got, want := c.Strings(), []string{"#DIV/0!", "#REF!", "5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+	if got, want := c.Types(), []string{"error", "error", "float"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got types %v, expected %v", got, want)
+	}
+}
+
+func TestColumnWidthsRowHeights(t *testing.T) {
+	s := getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": worksheetXML(`<dimension ref="A1:C2"/><sheetFormatPr defaultColWidth="10" defaultRowHeight="15"/><cols><col min="2" max="2" width="20.5" customWidth="1"/><col min="4" max="16384" width="3"/></cols><sheetData><row r="1" ht="30" customHeight="1"><c r="A1"><v>1</v></c></row><row r="2"><c r="C2"><v>2</v></c></row></sheetData>`),
+	})
+	if got, want := s.RowHeights(), map[int]float64{0: 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got heights %v, expected %v", got, want)
+	}
+	if got, want := s.ColumnWidths(), []float64{10, 20.5, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got widths %v, expected %v", got, want)
+	}
+}
+
+func TestSheetProtection(t *testing.T) {
+	s := openFixtureSheet(t, nil)
+	if p := s.Protection(); p != nil {
+		t.Errorf("expected no protection, got %+v", p)
+	}
+
+	s = getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("",
+			`<sheetProtection password="CC1A" sheet="1" objects="1" selectLockedCells="1" sort="0" insertRows="false"/>`),
+	})
+	p := s.Protection()
+	if p == nil {
+		t.Fatal("expected the sheet to be protected")
+	}
+	want := &SheetProtection{
+		PasswordHash:      "CC1A",
+		AllowedOperations: []string{"selectUnlockedCells", "scenarios", "insertRows", "sort"},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, expected %+v", p, want)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	wb, err := Open(buildFixture(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.(*Sheet)
+	if s.wrapped != nil {
+		t.Fatal("expected Get to not parse the sheet")
+	}
+	if err = s.Preload(); err != nil || s.wrapped == nil {
+		t.Fatalf("expected a parsed sheet, got %v", err)
+	}
+	if !c.Next() || !reflect.DeepEqual(c.Strings(), []string{"a", "b"}) {
+		t.Errorf("unexpected first row %q (%v)", c.Strings(), c.Err())
+	}
+
+	// parse errors are returned by Preload and Err
+	wb, err = Open(buildFixture(t, map[string]string{"xl/worksheets/sheet1.xml": "<worksheet><sheetData><row"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, _ = wb.Get("Sheet1")
+	if c.Next() || c.Err() == nil || c.(*Sheet).Preload() == nil {
+		t.Errorf("expected a parse error, got %v", c.Err())
+	}
+}
+
+func TestSheetStats(t *testing.T) {
+	wb, err := Open(buildFixture(t, map[string]string{
+		"xl/worksheets/sheet1.xml": strings.Replace(fixtureSheet, `ref="A1:B2"`, `ref="A1:C50"`, 1),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	st, err := wb.(*Document).SheetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []grate.SheetStats{{Name: "Sheet1", EstimatedRows: 50, MaxCols: 3}}
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("got %+v, expected %+v", st, want)
+	}
+	if wb.(*Document).sheets[0].wrapped != nil {
+		t.Error("expected the sheet to not be parsed")
+	}
+}
+
+func TestPrintArea(t *testing.T) {
+	s := openFixtureSheet(t, nil)
+	if area, ok := s.PrintArea(); ok {
+		t.Errorf("expected no print area, got %q", area)
+	}
+
+	s = openFixtureSheet(t, map[string]string{
+		"xl/workbook.xml": strings.Replace(fixtureWorkbook, `</sheets>`,
+			`</sheets><definedNames><definedName name="_xlnm.Print_Area" localSheetId="0">'Sheet1'!$A$1:$G$50,Sheet1!$J$1:$K$2</definedName><definedName name="Other">Sheet1!$B$2</definedName></definedNames>`, 1),
+	})
+	if area, ok := s.PrintArea(); !ok || area != "A1:G50,J1:K2" {
+		t.Errorf("got %q, %v; expected A1:G50,J1:K2", area, ok)
+	}
+}
+
+func TestPrintTitles(t *testing.T) {
+	wb, err := Open(buildFixture(t, map[string]string{
+		"xl/workbook.xml": strings.Replace(fixtureWorkbook, `</sheets>`,
+			`</sheets><definedNames><definedName name="_xlnm.Print_Area" localSheetId="0">Sheet1!$A$1:$B$2</definedName><definedName name="_xlnm.Print_Titles" localSheetId="0">'Sheet1'!$A:$A,'Sheet1'!$1:$2</definedName></definedNames>`, 1),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	d := wb.(*Document)
+	if rows, cols, ok := d.PrintTitles("Sheet1"); !ok || rows != "$1:$2" || cols != "$A:$A" {
+		t.Errorf("got %q, %q, %v; expected $1:$2, $A:$A", rows, cols, ok)
+	}
+	if _, _, ok := d.PrintTitles("Missing"); ok {
+		t.Error("expected no print titles for a missing sheet")
+	}
+
+	// commas in a quoted sheet name do not separate references
+	wb, err = Open(buildFixture(t, map[string]string{
+		"xl/workbook.xml": strings.Replace(strings.Replace(fixtureWorkbook, `name="Sheet1"`, `name="Q1, Q2"`, 1), `</sheets>`,
+			`</sheets><definedNames><definedName name="_xlnm.Print_Area" localSheetId="0">'Q1, Q2'!$A$1:$B$2,'Q1, Q2'!$D$1:$E$2</definedName><definedName name="_xlnm.Print_Titles" localSheetId="0">'Q1, Q2'!$1:$1</definedName></definedNames>`, 1),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	d = wb.(*Document)
+	if rows, cols, ok := d.PrintTitles("Q1, Q2"); !ok || rows != "$1:$1" || cols != "" {
+		t.Errorf("got %q, %q, %v; expected $1:$1 and no columns", rows, cols, ok)
+	}
+	if area, ok := d.sheets[0].PrintArea(); !ok || area != "A1:B2,D1:E2" {
+		t.Errorf("got %q, %v; expected A1:B2,D1:E2", area, ok)
+	}
+
+	s := openFixtureSheet(t, nil)
+	if rows, cols, ok := s.d.PrintTitles("Sheet1"); ok {
+		t.Errorf("expected no print titles, got %q, %q", rows, cols)
+	}
+}
+
+func TestAutoFilterRange(t *testing.T) {
+	s := openFixtureSheet(t, nil)
+	if ref, ok := s.AutoFilterRange(); ok {
+		t.Errorf("expected no auto-filter, got %q", ref)
+	}
+
+	s = getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("",
+			`<autoFilter ref="A1:B2"><filterColumn colId="1"><filters><filter val="2"/></filters></filterColumn></autoFilter>`),
+	})
+	if ref, ok := s.AutoFilterRange(); !ok || ref != "A1:B2" {
+		t.Errorf("got %q, %v; expected A1:B2", ref, ok)
+	}
+
+	// a filter which shows all rows
+	s = openFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": fixtureSheetXML("", `<autoFilter ref="A1:B1"/>`),
+	})
+	if ref, ok := s.AutoFilterRange(); !ok || ref != "A1:B1" {
+		t.Errorf("got %q, %v; expected A1:B1", ref, ok)
+	}
+}
+
+func TestCellFormula(t *testing.T) {
+	s := getFixtureSheet(t, map[string]string{
+		"xl/worksheets/sheet1.xml": worksheetXML(`<dimension ref="A1:C3"/><sheetData>
+<row r="1"><c r="A1"><v>1</v></c><c r="B1"><v>2</v></c><c r="C1"><f>SUM(A1:B1)&amp;"A1"</f><v>3</v></c></row>
+<row r="2"><c r="A2"><v>4</v></c><c r="B2"><v>5</v></c><c r="C2"><f t="shared" ref="C2:C3" si="0">A2*$B$1+LOG10(B2)</f><v>9</v></c></row>
+<row r="3"><c r="A3"><v>6</v></c><c r="B3"><v>7</v></c><c r="C3"><f t="shared" si="0"/><v>13</v></c></row>
+</sheetData>`),
+	})
+	for _, tc := range []struct {
+		row, col int
+		formula  string
+	}{
+		{0, 2, `SUM(A1:B1)&"A1"`},
+		{1, 2, "A2*$B$1+LOG10(B2)"},
+		{2, 2, "A3*$B$1+LOG10(B3)"},
+	} {
+		if f, ok := s.CellFormula(tc.row, tc.col); !ok || f != tc.formula {
+			t.Errorf("CellFormula(%d, %d) = %q, %v; expected %q", tc.row, tc.col, f, ok, tc.formula)
+		}
+	}
+	if f, ok := s.CellFormula(0, 0); ok {
+		t.Errorf("expected no formula in A1, got %q", f)
+	}
+
+	// values are still the cached results
+	var got []string
+	for s.Next() && s.Row() < 3 {
+		got = append(got, s.Strings()[2])
+	}
+	if want := []string{"3", "9", "13"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got values %q, expected %q", got, want)
+	}
+}
+
+func TestDocProps(t *testing.T) {
+	wb, err := Open(buildFixture(t, map[string]string{
+		"_rels/.rels": strings.Replace(fixtureRels, `</Relationships>`,
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/><Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/></Relationships>`, 1),
+		"docProps/core.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><dc:creator>Jane Doe</dc:creator><cp:lastModifiedBy>John Doe</cp:lastModifiedBy><dcterms:created xsi:type="dcterms:W3CDTF">2021-02-16T22:59:30Z</dcterms:created><dcterms:modified xsi:type="dcterms:W3CDTF">2021-03-01T08:00:00+01:00</dcterms:modified></cp:coreProperties>`,
+		"docProps/app.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"><Application>Microsoft Excel</Application><HeadingPairs><vt:vector size="1" baseType="variant"><vt:variant><vt:lpstr>Worksheets</vt:lpstr></vt:variant></vt:vector></HeadingPairs></Properties>`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	m, ok := wb.(grate.SourceMetadata)
+	if !ok {
+		t.Fatal("expected a SourceMetadata")
+	}
+	if got := m.Creator(); got != "Jane Doe" {
+		t.Errorf("got creator %q, expected Jane Doe", got)
+	}
+	if got := m.Application(); got != "Microsoft Excel" {
+		t.Errorf("got application %q, expected Microsoft Excel", got)
+	}
+	if got, want := m.Created(), time.Date(2021, 2, 16, 22, 59, 30, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got created %v, expected %v", got, want)
+	}
+	if got, want := m.Modified(), time.Date(2021, 3, 1, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got modified %v, expected %v", got, want)
+	}
+
+	// without document properties
+	d := openFixtureSheet(t, nil).d
+	if d.Creator() != "" || d.Application() != "" || !d.Created().IsZero() || !d.Modified().IsZero() {
+		t.Errorf("expected empty properties, got %+v", *d.docProps())
+	}
+}
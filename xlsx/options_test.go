@@ -0,0 +1,213 @@
+package xlsx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/commonxl"
+)
+
+func TestOpenWithOptionsMaxMemory(t *testing.T) {
+	_, err := OpenWithOptions("../testdata/basic.xlsx", grate.MaxMemoryBytes(1024))
+	if !errors.Is(err, grate.ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+
+	wb, err := OpenWithOptions("../testdata/basic.xlsx", grate.MaxMemoryBytes(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+}
+
+func TestWithLocale(t *testing.T) {
+	fn := buildFixture(t, map[string]string{
+		"xl/styles.xml":            stylesXML(`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>`),
+		"xl/worksheets/sheet1.xml": worksheetXML(`<sheetData><row r="1"><c r="A1" s="1"><v>1234.5</v></c></row></sheetData>`),
+	})
+	for _, tc := range []struct {
+		loc    *grate.Locale
+		expect string
+	}{
+		{nil, "1,234.50"},
+		{commonxl.LocaleDE, "1.234,50"},
+	} {
+		wb, err := OpenWithOptions(fn, grate.WithLocale(tc.loc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, _ := wb.Get("Sheet1")
+		if !c.Next() {
+			t.Fatal("expected a row")
+		}
+		if got := c.Strings()[0]; got != tc.expect {
+			t.Errorf("got %q, expected %q", got, tc.expect)
+		}
+		wb.Close()
+	}
+}
+
+func TestSheetNameLookup(t *testing.T) {
+	fn := buildFixture(t, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets><sheet name="Café" sheetId="1" r:id="rId1"/><sheet name="Data" sheetId="2" r:id="rId1"/><sheet name="data " sheetId="3" r:id="rId1"/></sheets></workbook>`,
+	})
+	wb, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+
+	// decomposed "e" + combining acute accent, with surrounding whitespace
+	for _, name := range []string{"Café", " Cafe\u0301\t", "CAFÉ", "Data", "data "} {
+		if _, err := wb.Get(name); err != nil {
+			t.Errorf("Get(%q): %v", name, err)
+		}
+	}
+	if _, err := wb.Get("DATA"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected an ambiguous name to not be found, got %v", err)
+	}
+
+	strict, err := OpenWithOptions(fn, WithStrictSheetNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+	if _, err := strict.Get("Café"); err != nil {
+		t.Error(err)
+	}
+	if _, err := strict.Get("Café "); err == nil {
+		t.Error("expected strict lookup to fail")
+	}
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	// the shared string indexes of A1 and B1 are out of range
+	sheet := strings.Replace(fixtureSheet, `<c r="A1" t="s"><v>0</v>`, `<c r="A1" t="s"><v>98</v>`, 1)
+	fn := buildFixture(t, map[string]string{
+		"xl/worksheets/sheet1.xml": strings.Replace(sheet, `<c r="B1" t="s"><v>1</v>`, `<c r="B1" t="s"><v>99</v>`, 1),
+	})
+
+	wb, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, _ := wb.Get("Sheet1")
+	if c.Next() || c.Err() == nil {
+		t.Fatal("expected a parse error without an error handler")
+	}
+
+	var errRows []int
+	wb, err = OpenWithOptions(fn, grate.WithErrorHandler(func(row int, err error) bool {
+		errRows = append(errRows, row)
+		return true
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, _ = wb.Get("Sheet1")
+	if !c.Next() || c.Row() != 1 || c.Strings()[0] != "1" {
+		t.Fatalf("expected the first row to be skipped, got row %d %q (%v)", c.Row(), c.Strings(), c.Err())
+	}
+	if len(errRows) != 1 || errRows[0] != 0 {
+		t.Errorf("unexpected error rows %v", errRows)
+	}
+}
+
+func TestExternalSharedStrings(t *testing.T) {
+	rels := strings.Replace(fixtureWorkbookRels, `Target="sharedStrings.xml"`,
+		`Target="file:///C:/strings.xml" TargetMode="External"`, 1)
+	fn := buildFixture(t, map[string]string{
+		"xl/_rels/workbook.xml.rels": rels,
+		"xl/sharedStrings.xml":       "",
+	})
+
+	wb, err := OpenWithOptions(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for c.Next() {
+		rows = append(rows, c.Strings())
+	}
+	if !errors.Is(c.Err(), grate.ErrExternalReference) {
+		t.Fatalf("expected ErrExternalReference, got %v", c.Err())
+	}
+	if len(rows) < 2 || rows[0][0] != "#REF!" || rows[1][0] != "1" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+
+	wb2, err := OpenWithOptions(fn, grate.StrictMode(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb2.Close()
+	c, _ = wb2.Get("Sheet1")
+	if c.Next() || !errors.Is(c.Err(), grate.ErrExternalReference) {
+		t.Fatalf("expected strict mode to fail with ErrExternalReference, got %v", c.Err())
+	}
+}
+
+func TestExternalSharedStringsRow(t *testing.T) {
+	rels := strings.Replace(fixtureWorkbookRels, `Target="sharedStrings.xml"`,
+		`Target="file:///C:/strings.xml" TargetMode="External"`, 1)
+	fn := buildFixture(t, map[string]string{
+		"xl/_rels/workbook.xml.rels": rels,
+		"xl/sharedStrings.xml":       "",
+		"xl/worksheets/sheet1.xml":   worksheetXML(`<dimension ref="A1:A2"/><sheetData><row r="1"><c r="A1"><v>1</v></c></row><row r="2"><c r="A2" t="s"><v>0</v></c></row></sheetData>`),
+	})
+
+	wb, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, _ := wb.Get("Sheet1")
+	if !c.Next() || c.Err() != nil {
+		t.Fatalf("expected no error before the external reference, got %v", c.Err())
+	}
+	if !c.Next() || !errors.Is(c.Err(), grate.ErrExternalReference) {
+		t.Fatalf("expected ErrExternalReference, got %v", c.Err())
+	}
+}
+
+func TestMissingSharedStrings(t *testing.T) {
+	// the relationship is kept, but the part is missing
+	fn := buildFixture(t, map[string]string{"xl/sharedStrings.xml": ""})
+
+	wb, err := OpenWithOptions(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, _ := wb.Get("Sheet1")
+	var rows [][]string
+	for c.Next() {
+		rows = append(rows, c.Strings())
+	}
+	if c.Err() != nil {
+		t.Fatal(c.Err())
+	}
+	if len(rows) < 2 || rows[0][0] != "" || rows[0][1] != "" || rows[1][0] != "1" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+
+	wb2, err := OpenWithOptions(fn, grate.StrictMode(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb2.Close()
+	c, _ = wb2.Get("Sheet1")
+	if c.Next() || c.Err() == nil {
+		t.Error("expected strict mode to fail on shared string cells")
+	}
+}
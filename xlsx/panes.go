@@ -0,0 +1,24 @@
+package xlsx
+
+// PaneState describes the frozen or split panes of a sheet's view.
+type PaneState struct {
+	// FrozenRows and FrozenCols are the number of rows and columns
+	// frozen at the top and left of the sheet.
+	FrozenRows, FrozenCols int
+
+	// SplitX and SplitY are the positions of unfrozen split bars,
+	// in twentieths of a point.
+	SplitX, SplitY float64
+
+	// ActivePane is the pane with the cursor, e.g. "bottomRight".
+	ActivePane string
+}
+
+// PaneState returns the pane configuration of the sheet, or nil if no
+// pane is configured.
+func (s *Sheet) PaneState() *PaneState {
+	if s.loaded() == nil {
+		return nil
+	}
+	return s.pane
+}
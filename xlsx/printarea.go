@@ -0,0 +1,54 @@
+package xlsx
+
+import "strings"
+
+// PrintArea returns the cell range printed for the sheet (e.g. "A1:G50"),
+// and true if a print area is defined. Multiple ranges are separated by
+// commas.
+func (s *Sheet) PrintArea() (string, bool) {
+	return s.printArea, s.printArea != ""
+}
+
+// PrintTitles returns the rows (e.g. "$1:$1") and columns (e.g. "$A:$A")
+// repeated on each printed page of the named sheet, which often identify
+// its header. Either may be empty, and ok is false if the sheet does not
+// exist or has no print titles.
+func (d *Document) PrintTitles(sheetName string) (rows, cols string, ok bool) {
+	s := d.findSheet(sheetName)
+	if s == nil || s.printTitles == "" {
+		return "", "", false
+	}
+	for _, p := range splitRefs(s.printTitles) {
+		if i := strings.LastIndexByte(p, '!'); i >= 0 {
+			p = p[i+1:]
+		}
+		p = strings.TrimSpace(p)
+		if strings.IndexAny(p, "0123456789") >= 0 {
+			rows = p
+		} else {
+			cols = p
+		}
+	}
+	return rows, cols, true
+}
+
+// splitRefs splits a comma separated list of references, such as
+// 'Q1, Q2'!$A:$A,'Q1, Q2'!$1:$1, ignoring commas in quoted sheet names.
+func splitRefs(formula string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(formula); i++ {
+		switch formula[i] {
+		case '\'':
+			// an escaped quote ('') toggles twice
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				parts = append(parts, formula[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, formula[start:])
+}
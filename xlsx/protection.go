@@ -0,0 +1,77 @@
+package xlsx
+
+import "encoding/xml"
+
+// SheetProtection describes the protection settings of a sheet.
+type SheetProtection struct {
+	// PasswordHash is the legacy 16-bit password hash as hex digits, or
+	// the base64 hash value for files using a modern hashing algorithm.
+	// It is empty if the sheet is protected without a password.
+	PasswordHash string
+
+	// AllowedOperations lists the operations users may still perform on
+	// the protected sheet, using the attribute names of the file format
+	// (e.g. "selectLockedCells", "sort", "insertRows").
+	AllowedOperations []string
+}
+
+// Protection returns the protection settings of the sheet, or nil if the
+// sheet is not protected.
+func (s *Sheet) Protection() *SheetProtection {
+	if s.loaded() == nil {
+		return nil
+	}
+	return s.protection
+}
+
+// operations which are locked unless their attribute is false
+var lockedByDefault = []string{
+	"formatCells", "formatColumns", "formatRows",
+	"insertColumns", "insertRows", "insertHyperlinks",
+	"deleteColumns", "deleteRows",
+	"sort", "autoFilter", "pivotTables",
+}
+
+// operations which are allowed unless their attribute is true
+var allowedByDefault = []string{
+	"selectLockedCells", "selectUnlockedCells", "objects", "scenarios",
+}
+
+// parseSheetProtection converts the attributes of a sheetProtection element,
+// returning nil if it does not protect the sheet.
+func parseSheetProtection(xattrs []xml.Attr) *SheetProtection {
+	attrs := make(map[string]string, len(xattrs))
+	for _, a := range xattrs {
+		attrs[a.Name.Local] = a.Value
+	}
+	if !xmlBool(attrs["sheet"], false) {
+		return nil
+	}
+	p := &SheetProtection{PasswordHash: attrs["password"]}
+	if p.PasswordHash == "" {
+		p.PasswordHash = attrs["hashValue"]
+	}
+	for _, op := range allowedByDefault {
+		if !xmlBool(attrs[op], false) {
+			p.AllowedOperations = append(p.AllowedOperations, op)
+		}
+	}
+	for _, op := range lockedByDefault {
+		if !xmlBool(attrs[op], true) {
+			p.AllowedOperations = append(p.AllowedOperations, op)
+		}
+	}
+	return p
+}
+
+// xmlBool parses an xsd:boolean attribute value, returning def if it is
+// missing or invalid.
+func xmlBool(v string, def bool) bool {
+	switch v {
+	case "1", "true":
+		return true
+	case "0", "false":
+		return false
+	}
+	return def
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -19,17 +18,49 @@ type Sheet struct {
 	relID   string
 	name    string
 	docname string
+	typ     SheetType
 
 	err error
+	// refErr is the first external reference found in lenient mode, in
+	// row refRow
+	refErr error
+	refRow int
 
 	wrapped *commonxl.Sheet
+
+	condFormats     []ConditionalFormat
+	dataValidations []DataValidation
+	sparklines      []SparklineGroup
+	pane            *PaneState
+	protection      *SheetProtection
+
+	defaultColWidth float64
+	colWidths       []colWidth
+	rowHeights      map[int]float64
+	tabColor        *colorRef
+	fills           map[[2]int]int // (row, col) => fill index
+	printArea       string
+	printTitles     string
+	autoFilter      string
+	formulas        map[[2]int]string // (row, col) => formula
 }
 
 var errNotLoaded = errors.New("xlsx: sheet not loaded")
 
+// sharedValue is the cached value of a shared formula master cell.
+type sharedValue struct {
+	value interface{}
+	fno   uint16
+}
+
 func (s *Sheet) parseSheet() error {
 	s.wrapped = &commonxl.Sheet{
 		Formatter: &s.d.fmt,
+		Logger:    s.d.opts.Logger(),
+	}
+	if s.typ != SheetTypeWorksheet {
+		s.d.opts.Logger().Debug("xlsx: sheet has no cells", "sheet", s.name, "type", s.typ)
+		return nil
 	}
 	linkmap := make(map[string]string)
 	base := filepath.Base(s.docname)
@@ -61,11 +92,45 @@ func (s *Sheet) parseSheet() error {
 	var fno uint16
 	var maxCol, maxRow int
 
+	// conditional formatting state
+	cfRange := ""
+	cfFormulas := 0
+
+	// sparkline state
+	sparklineType := ""
+	inSparkline := false
+
+	// when non-nil, character data is collected into this metadata field
+	var textDst *string
+
+	// inline string state: the text of the <t> elements of an <is> element,
+	// excluding phonetic runs
+	inInline, inPhonetic := false, false
+	inlineText := ""
+
+	// shared formula state: the value of each master cell by shared index,
+	// and the shared index and value of the current cell
+	inFormula := false
+	sharedValues := make(map[string]sharedValue)
+	cellShared, cellMaster := "", false
+	var cellValue interface{}
+
+	// formula text of the current cell, and of each shared formula by
+	// shared index (with the position of its master cell)
+	hasFormula, formulaText := false, ""
+	sharedFormulas := make(map[string]sharedFormula)
+
 	tok, err := dec.RawToken()
 	for ; err == nil; tok, err = dec.RawToken() {
 		switch v := tok.(type) {
 		case xml.CharData:
-			if currentCell == "" {
+			if textDst != nil {
+				*textDst += string(v)
+				continue
+			}
+			if currentCell == "" || inFormula || currentCellType == InlineStringCellType {
+				// formula text is not a cell value, and inline strings are
+				// collected from their <is> element
 				continue
 			}
 			c, r := refToIndexes(currentCell)
@@ -80,7 +145,7 @@ func (s *Sheet) parseSheet() error {
 						val = false
 					}
 				case DateCellType:
-					log.Println("CELL DATE", val, fno)
+					s.d.opts.Logger().Debug("xlsx: date cell", "cell", currentCell, "value", val, "format", fno)
 				case NumberCellType:
 					fval, err := strconv.ParseFloat(string(v), 64)
 					if err == nil {
@@ -89,18 +154,50 @@ func (s *Sheet) parseSheet() error {
 					//log.Println("CELL NUMBER", val, numFormat)
 				case SharedStringCellType:
 					//log.Println("CELL SHSTR", val, currentCellType, numFormat)
-					si, _ := strconv.ParseInt(string(v), 10, 64)
+					if s.d.externalStrings {
+						err := grate.WrapErr(fmt.Errorf("xlsx: cell %s references an external shared string table", currentCell), grate.ErrExternalReference)
+						if s.d.opts.Strict {
+							return err
+						}
+						if s.refErr == nil {
+							s.refErr, s.refRow = err, r
+						}
+						s.wrapped.PutError(r, c, "#REF!", fno)
+						continue
+					}
+					if s.d.strings == nil && !s.d.opts.Strict {
+						// the workbook has no shared string table
+						s.d.opts.Logger().Debug("xlsx: shared string cell without a shared string table", "cell", currentCell)
+						continue
+					}
+					si, err := strconv.ParseInt(string(v), 10, 64)
+					if err != nil || si < 0 || si >= int64(len(s.d.strings)) {
+						if s.wrapped.RowSkipped(r) {
+							// the handler was already called for the row
+							continue
+						}
+						err = fmt.Errorf("xlsx: invalid shared string index '%s' in cell %s", v, currentCell)
+						if !s.d.opts.SkipRowError(r, err) {
+							return err
+						}
+						s.wrapped.SkipRow(r)
+						continue
+					}
 					val = s.d.strings[si]
 				case BlankCellType:
 					//log.Println("CELL BLANK")
 					// don't place any values
 					continue
-				case ErrorCellType, FormulaStringCellType, InlineStringCellType:
+				case ErrorCellType:
+					s.wrapped.PutError(r, c, errorText(string(v)), fno)
+					continue
+				case FormulaStringCellType:
 					//log.Println("CELL ERR/FORM/INLINE", val, currentCellType)
 				default:
-					log.Println("CELL UNKNOWN", val, currentCellType, fno)
+					s.d.opts.Logger().Debug("xlsx: unknown cell type", "cell", currentCell, "type", currentCellType, "value", val, "format", fno)
 				}
 				s.wrapped.Put(r, c, val, fno)
+				cellValue = val
 			} else {
 				//log.Println("FAIL row/col: ", currentCell)
 			}
@@ -126,13 +223,45 @@ func (s *Sheet) parseSheet() error {
 			case "row":
 				//currentRow = ax["r"] // unsigned int row index
 				//log.Println("ROW", currentRow)
+				ax := getAttrs(v.Attr, "r", "ht")
+				if ax[1] != "" {
+					r, err := strconv.Atoi(ax[0])
+					ht, err2 := strconv.ParseFloat(ax[1], 64)
+					if err == nil && err2 == nil && r > 0 {
+						if s.rowHeights == nil {
+							s.rowHeights = make(map[int]float64)
+						}
+						s.rowHeights[r-1] = ht
+					}
+				}
+			case "tabColor":
+				c := parseColorRef(v.Attr)
+				s.tabColor = &c
+			case "sheetFormatPr":
+				ax := getAttrs(v.Attr, "defaultColWidth")
+				if w, err := strconv.ParseFloat(ax[0], 64); err == nil {
+					s.defaultColWidth = w
+				}
+			case "col":
+				ax := getAttrs(v.Attr, "min", "max", "width")
+				min, err1 := strconv.Atoi(ax[0])
+				max, err2 := strconv.Atoi(ax[1])
+				w, err3 := strconv.ParseFloat(ax[2], 64)
+				if err1 == nil && err2 == nil && err3 == nil {
+					s.colWidths = append(s.colWidths, colWidth{min - 1, max - 1, w})
+				}
 			case "c":
 				ax := getAttrs(v.Attr, "t", "r", "s")
 				currentCellType = CellType(ax[0])
 				if currentCellType == BlankCellType {
 					currentCellType = NumberCellType
+				} else if currentCellType == "is" {
+					// written by some producers instead of inlineStr
+					currentCellType = InlineStringCellType
 				}
 				currentCell = ax[1] // always an A1 style reference
+				cellShared, cellMaster, cellValue = "", false, nil
+				hasFormula, formulaText = false, ""
 				style := ax[2]
 				sid, _ := strconv.ParseInt(style, 10, 64)
 				if len(s.d.xfs) > int(sid) {
@@ -140,9 +269,26 @@ func (s *Sheet) parseSheet() error {
 				} else {
 					fno = 0
 				}
+				if fill := s.d.styleFill(int(sid)); fill >= 0 {
+					c, r := refToIndexes(currentCell)
+					if s.fills == nil {
+						s.fills = make(map[[2]int]int)
+					}
+					s.fills[[2]int{r, c}] = fill
+				}
 				//log.Println("CELL", currentCell, sid, numFormat, currentCellType)
 			case "v":
 				//log.Println("CELL VALUE", ax)
+			case "is":
+				if currentCell != "" {
+					inInline, inlineText = true, ""
+				}
+			case "rPh":
+				inPhonetic = true
+			case "t":
+				if inInline && !inPhonetic {
+					textDst = &inlineText
+				}
 
 			case "mergeCell":
 				ax := getAttrs(v.Attr, "ref")
@@ -185,27 +331,174 @@ func (s *Sheet) parseSheet() error {
 				s.wrapped.Put(row, col, link, 0)
 				s.wrapped.SetURL(row, col, link)
 
-			case "worksheet", "mergeCells", "hyperlinks":
+			case "conditionalFormatting":
+				ax := getAttrs(v.Attr, "sqref")
+				cfRange = ax[0]
+			case "cfRule":
+				ax := getAttrs(v.Attr, "type", "operator")
+				s.condFormats = append(s.condFormats, ConditionalFormat{
+					Range:    cfRange,
+					Type:     ax[0],
+					Operator: ax[1],
+				})
+				cfFormulas = 0
+			case "formula":
+				// only the first formula of a rule is kept
+				cfFormulas++
+				if cfRange != "" && len(s.condFormats) > 0 && cfFormulas == 1 {
+					textDst = &s.condFormats[len(s.condFormats)-1].Formula
+				}
+
+			case "dataValidation":
+				ax := getAttrs(v.Attr, "sqref", "type", "operator", "allowBlank", "showDropDown")
+				s.dataValidations = append(s.dataValidations, DataValidation{
+					CellRange:    ax[0],
+					Type:         ax[1],
+					Operator:     ax[2],
+					AllowBlank:   ax[3] == "1" || ax[3] == "true",
+					ShowDropDown: ax[4] == "1" || ax[4] == "true",
+				})
+			case "formula1", "formula2":
+				if len(s.dataValidations) > 0 {
+					dv := &s.dataValidations[len(s.dataValidations)-1]
+					if v.Name.Local == "formula1" {
+						textDst = &dv.Formula1
+					} else {
+						textDst = &dv.Formula2
+					}
+				}
+
+			case "pane":
+				if s.pane != nil {
+					// only the first sheet view is used
+					continue
+				}
+				ax := getAttrs(v.Attr, "xSplit", "ySplit", "activePane", "state")
+				xs, _ := strconv.ParseFloat(ax[0], 64)
+				ys, _ := strconv.ParseFloat(ax[1], 64)
+				s.pane = &PaneState{ActivePane: ax[2]}
+				if s.pane.ActivePane == "" {
+					s.pane.ActivePane = "topLeft"
+				}
+				if ax[3] == "frozen" || ax[3] == "frozenSplit" {
+					s.pane.FrozenCols, s.pane.FrozenRows = int(xs), int(ys)
+				} else {
+					s.pane.SplitX, s.pane.SplitY = xs, ys
+				}
+
+			case "sheetProtection":
+				s.protection = parseSheetProtection(v.Attr)
+
+			case "autoFilter":
+				ax := getAttrs(v.Attr, "ref")
+				s.autoFilter = ax[0]
+			case "filterColumn", "filters", "filter", "customFilters", "customFilter", "dynamicFilter",
+				"top10", "colorFilter", "iconFilter", "dateGroupItem", "sortState", "sortCondition":
+				// the filter criteria and sort order are not used
+
+			case "worksheet", "mergeCells", "hyperlinks", "dataValidations", "sheetViews", "sheetView", "cols", "sheetPr":
 				// containers
+			case "sparklineGroup":
+				ax := getAttrs(v.Attr, "type")
+				sparklineType = ax[0]
+				if sparklineType == "" {
+					sparklineType = "line"
+				}
+			case "sparkline":
+				s.sparklines = append(s.sparklines, SparklineGroup{Type: sparklineType})
+				inSparkline = true
+			case "sqref":
+				if inSparkline {
+					textDst = &s.sparklines[len(s.sparklines)-1].HostCell
+				}
+			case "extLst", "ext", "sparklineGroups", "sparklines", "colorSeries", "colorNegative",
+				"colorAxis", "colorMarkers", "colorFirst", "colorLast", "colorHigh", "colorLow":
+				// containers and sparkline styles
 			case "f":
+				if inSparkline {
+					textDst = &s.sparklines[len(s.sparklines)-1].DataRange
+					break
+				}
+				if currentCell != "" {
+					inFormula = true
+					hasFormula, formulaText = true, ""
+					textDst = &formulaText
+					ax := getAttrs(v.Attr, "t", "si", "ref")
+					if ax[0] == "shared" {
+						cellShared, cellMaster = ax[1], ax[2] != ""
+					}
+				}
 				//log.Println("start: ", v.Name.Local, v.Attr)
 			default:
-				if grate.Debug {
-					log.Println("      Unhandled sheet xml tag", v.Name.Local, v.Attr)
+				if inInline {
+					// rich text runs and their properties
+					continue
 				}
+				s.d.opts.Logger().Debug("xlsx: unhandled sheet xml tag", "tag", v.Name.Local, "attrs", v.Attr)
 			}
 		case xml.EndElement:
 
 			switch v.Name.Local {
 			case "c":
+				if hasFormula {
+					c, r := refToIndexes(currentCell)
+					if cellShared != "" {
+						if formulaText != "" {
+							sharedFormulas[cellShared] = sharedFormula{formulaText, r, c}
+						} else if sf, ok := sharedFormulas[cellShared]; ok {
+							formulaText = shiftFormula(sf.text, r-sf.row, c-sf.col)
+						}
+					}
+					if formulaText != "" && c >= 0 && r >= 0 {
+						if s.formulas == nil {
+							s.formulas = make(map[[2]int]string)
+						}
+						s.formulas[[2]int{r, c}] = formulaText
+					}
+				}
+				if cellShared != "" {
+					c, r := refToIndexes(currentCell)
+					if cellMaster && cellValue != nil {
+						sharedValues[cellShared] = sharedValue{cellValue, fno}
+					} else if cellValue == nil && c >= 0 && r >= 0 {
+						// no cached value, use the value of the master cell
+						if sv, ok := sharedValues[cellShared]; ok {
+							s.wrapped.Put(r, c, sv.value, sv.fno)
+						} else {
+							s.d.opts.Logger().Debug("xlsx: shared formula cell without a value",
+								"cell", currentCell, "si", cellShared)
+						}
+					}
+				}
 				currentCell = ""
+			case "is":
+				if inInline {
+					c, r := refToIndexes(currentCell)
+					if c >= 0 && r >= 0 {
+						s.wrapped.Put(r, c, inlineText, fno)
+						cellValue = inlineText
+					}
+				}
+				inInline = false
+			case "rPh":
+				inPhonetic = false
+			case "formula", "formula1", "formula2", "f", "sqref", "t":
+				textDst = nil
+				inFormula = false
+			case "sparkline":
+				inSparkline = false
+			case "dataValidation":
+				if len(s.dataValidations) > 0 {
+					dv := &s.dataValidations[len(s.dataValidations)-1]
+					dv.Values = parseListValues(dv.Type, dv.Formula1)
+				}
+			case "conditionalFormatting":
+				cfRange = ""
 			case "row":
 				//currentRow = ""
 			}
 		default:
-			if grate.Debug {
-				log.Printf("      Unhandled sheet xml tokens %T %+v", tok, tok)
-			}
+			s.d.opts.Logger().Debug("xlsx: unhandled sheet xml token", "token", tok)
 		}
 	}
 	if err == io.EOF {
@@ -0,0 +1,50 @@
+package xlsx
+
+// SheetType is the kind of a sheet in the workbook.
+type SheetType int
+
+const (
+	// SheetTypeUnknown is returned for sheet names which do not exist.
+	SheetTypeUnknown SheetType = iota
+	// SheetTypeWorksheet is a grid of cells (including macro sheets).
+	SheetTypeWorksheet
+	// SheetTypeChart is a sheet which only contains a chart.
+	SheetTypeChart
+	// SheetTypeDialog is an Excel 5 dialog sheet.
+	SheetTypeDialog
+)
+
+func (t SheetType) String() string {
+	switch t {
+	case SheetTypeWorksheet:
+		return "worksheet"
+	case SheetTypeChart:
+		return "chart"
+	case SheetTypeDialog:
+		return "dialog"
+	}
+	return "unknown"
+}
+
+// sheetRelTypes lists the relationship types of sheets with their SheetType,
+// in the order they are looked up.
+var sheetRelTypes = []struct {
+	relType string
+	typ     SheetType
+}{
+	{"http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet", SheetTypeWorksheet},
+	{"http://schemas.microsoft.com/office/2006/relationships/xlMacrosheet", SheetTypeWorksheet},
+	{"http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartsheet", SheetTypeChart},
+	{"http://schemas.openxmlformats.org/officeDocument/2006/relationships/dialogsheet", SheetTypeDialog},
+}
+
+// SheetType returns the kind of the named sheet. Chart and dialog sheets
+// have no cells, so Get returns them as empty collections; use SheetType
+// to skip them.
+func (d *Document) SheetType(name string) SheetType {
+	s := d.findSheet(name)
+	if s == nil {
+		return SheetTypeUnknown
+	}
+	return s.typ
+}
@@ -61,7 +61,10 @@ func TestBasic(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			xsheet := sheet.(*commonxl.Sheet)
+			if err = sheet.(*Sheet).Preload(); err != nil {
+				t.Fatal(err)
+			}
+			xsheet := sheet.(*Sheet).wrapped
 			if firstLoad {
 				trueData, err = loadTestData(fnames[1], xsheet.Formatter)
 				if err != nil {
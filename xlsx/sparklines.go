@@ -0,0 +1,22 @@
+package xlsx
+
+// SparklineGroup describes a sparkline (a small in-cell chart).
+type SparklineGroup struct {
+	// HostCell is the cell the sparkline is drawn in, e.g. "F1".
+	HostCell string
+
+	// DataRange is the range of values the sparkline visualizes, e.g. "Sheet1!A1:E1".
+	DataRange string
+
+	// Type of the sparkline group: "line", "column" or "stacked".
+	Type string
+}
+
+// Sparklines returns the sparklines defined on the sheet. There is one
+// entry per sparkline, carrying the type of the group it belongs to.
+func (s *Sheet) Sparklines() []SparklineGroup {
+	if s.loaded() == nil {
+		return nil
+	}
+	return s.sparklines
+}
@@ -0,0 +1,61 @@
+package xlsx
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/wubin1989/grate"
+)
+
+// SheetStats returns the size of each sheet as declared by its dimension
+// element, without parsing the cells. Sheets without a declared dimension
+// are parsed to find their size.
+func (d *Document) SheetStats() ([]grate.SheetStats, error) {
+	res := make([]grate.SheetStats, 0, len(d.sheets))
+	for _, s := range d.sheets {
+		st := grate.SheetStats{Name: s.name}
+		if s.wrapped == nil {
+			st.EstimatedRows, st.MaxCols = s.declaredSize()
+		}
+		if st.EstimatedRows == 0 {
+			if err := s.Preload(); err != nil {
+				return nil, err
+			}
+			st.EstimatedRows, st.MaxCols = s.wrapped.NumRows, s.wrapped.NumCols
+		}
+		res = append(res, st)
+	}
+	return res, nil
+}
+
+// declaredSize reads the dimension element at the start of the sheet,
+// returning zeros if it is missing.
+func (s *Sheet) declaredSize() (rows, cols int) {
+	dec, clo, err := s.d.openXML(s.docname)
+	if err != nil {
+		return 0, 0
+	}
+	defer clo.Close()
+
+	tok, err := dec.RawToken()
+	for ; err == nil; tok, err = dec.RawToken() {
+		v, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch v.Name.Local {
+		case "dimension":
+			ax := getAttrs(v.Attr, "ref")
+			dims := strings.Split(ax[0], ":")
+			maxCol, maxRow := refToIndexes(dims[len(dims)-1])
+			if maxCol < 0 || maxRow < 0 {
+				return 0, 0
+			}
+			return maxRow + 1, maxCol + 1
+		case "sheetData":
+			// the dimension element must come before the cells
+			return 0, 0
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,80 @@
+package xlsx
+
+import (
+	"encoding/xml"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// themeColorIndex maps the colour scheme elements of a theme to the index
+// used by theme attributes. Note that the light and dark colours are
+// swapped compared to their order in the theme file.
+var themeColorIndex = map[string]int{
+	"lt1": 0, "dk1": 1, "lt2": 2, "dk2": 3,
+	"accent1": 4, "accent2": 5, "accent3": 6,
+	"accent4": 7, "accent5": 8, "accent6": 9,
+	"hlink": 10, "folHlink": 11,
+}
+
+// parseTheme reads the colour scheme of a theme part (DrawingML
+// section 20.1.6.2), replacing the default theme colours.
+func (d *Document) parseTheme(dec *xml.Decoder) error {
+	colors := append([]color.RGBA(nil), defaultThemeColors...)
+	current := -1
+	inScheme := false
+
+	tok, err := dec.RawToken()
+	for ; err == nil; tok, err = dec.RawToken() {
+		switch v := tok.(type) {
+		case xml.StartElement:
+			switch v.Name.Local {
+			case "clrScheme":
+				inScheme = true
+			case "srgbClr", "sysClr":
+				if !inScheme || current < 0 {
+					break
+				}
+				// system colours store the last computed value
+				ax := getAttrs(v.Attr, "val", "lastClr")
+				val := ax[0]
+				if v.Name.Local == "sysClr" {
+					val = ax[1]
+				}
+				if n, err := strconv.ParseUint(val, 16, 32); err == nil && len(val) == 6 {
+					colors[current] = rgb(uint32(n))
+				}
+			default:
+				if idx, ok := themeColorIndex[v.Name.Local]; ok && inScheme {
+					current = idx
+				}
+			}
+		case xml.EndElement:
+			if v.Name.Local == "clrScheme" {
+				inScheme = false
+			} else if idx, ok := themeColorIndex[v.Name.Local]; ok && idx == current {
+				current = -1
+			}
+		}
+	}
+	if err != io.EOF {
+		return err
+	}
+	d.themeColors = colors
+	return nil
+}
+
+// ThemeColor returns the RGB value of the theme colour with the given index,
+// as referenced by theme attributes of colours in the workbook. The colours
+// of the default Office theme are used if the workbook has no theme.
+// Invalid indexes return the zero colour.
+func (d *Document) ThemeColor(idx int) color.RGBA {
+	colors := d.themeColors
+	if colors == nil {
+		colors = defaultThemeColors
+	}
+	if idx < 0 || idx >= len(colors) {
+		return color.RGBA{}
+	}
+	return colors[idx]
+}
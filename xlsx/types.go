@@ -90,3 +90,27 @@ func getAttrs(attrs []xml.Attr, keys ...string) []string {
 	}
 	return res
 }
+
+// errorCodes maps the numeric error codes (as used by BIFF) to their text.
+var errorCodes = map[int]string{
+	0x00: "#NULL!",
+	0x07: "#DIV/0!",
+	0x0F: "#VALUE!",
+	0x17: "#REF!",
+	0x1D: "#NAME?",
+	0x24: "#NUM!",
+	0x2A: "#N/A",
+	0x2B: "#GETTING_DATA",
+}
+
+// errorText returns the text of an error cell value. Excel stores the
+// text itself, but some writers store the numeric error code instead.
+func errorText(v string) string {
+	v = strings.TrimSpace(v)
+	if n, err := strconv.Atoi(v); err == nil {
+		if s, ok := errorCodes[n]; ok {
+			return s
+		}
+	}
+	return v
+}
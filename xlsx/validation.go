@@ -0,0 +1,51 @@
+package xlsx
+
+import (
+	"strings"
+)
+
+// DataValidation describes a data validation rule applied to a range of cells.
+type DataValidation struct {
+	// CellRange is the space-separated list of cell ranges covered by the rule.
+	CellRange string
+
+	// Type of the rule: "list", "whole", "decimal", "date", "time", "textLength", "custom",
+	// or empty when any value is allowed.
+	Type string
+
+	// Operator for comparison rules, e.g. "between", "greaterThan".
+	Operator string
+
+	// Formula1 and Formula2 are the (raw) rule operands.
+	Formula1, Formula2 string
+
+	// AllowBlank is true if blank cells are considered valid.
+	AllowBlank bool
+
+	// ShowDropDown reflects the showDropDown attribute as stored. NB per the
+	// spec a true value actually hides the in-cell dropdown of list rules.
+	ShowDropDown bool
+
+	// Values contains the allowed values of a "list" rule when they are
+	// given literally (i.e. not as a reference to a range of cells).
+	Values []string
+}
+
+// DataValidations returns the data validation rules defined on the sheet.
+func (s *Sheet) DataValidations() []DataValidation {
+	if s.loaded() == nil {
+		return nil
+	}
+	return s.dataValidations
+}
+
+// parseListValues extracts the literal values from a list formula, e.g.
+//
+//	"Yes,No,Maybe" => [Yes No Maybe]
+func parseListValues(typ, formula string) []string {
+	if typ != "list" || len(formula) < 2 || formula[0] != '"' || formula[len(formula)-1] != '"' {
+		return nil
+	}
+	formula = strings.ReplaceAll(formula[1:len(formula)-1], `""`, `"`)
+	return strings.Split(formula, ",")
+}
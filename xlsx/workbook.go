@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"strconv"
 	"strings"
 
@@ -25,29 +24,35 @@ func (d *Document) parseRels(dec *xml.Decoder, basedir string) error {
 				for _, a := range v.Attr {
 					vals[a.Name.Local] = a.Value
 				}
+				if vals["TargetMode"] == "External" {
+					// external parts are not stored in the package
+					if vals["Type"] == "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" {
+						d.externalStrings = true
+					}
+					d.opts.Logger().Debug("xlsx: external relationship", "type", vals["Type"], "target", vals["Target"])
+					continue
+				}
 				if _, ok := d.rels[vals["Type"]]; !ok {
 					d.rels[vals["Type"]] = make(map[string]string)
 				}
-				if strings.HasPrefix(vals["Target"], "/") {
-					// handle malformed "absolute" paths cleanly
-					d.rels[vals["Type"]][vals["Id"]] = vals["Target"][1:]
+				target := vals["Target"]
+				if strings.HasPrefix(target, "/") {
+					// targets relative to the package root
+					target = partName(target)
 				} else {
-					d.rels[vals["Type"]][vals["Id"]] = fmt.Sprintf("%s%s", basedir, vals["Target"])
+					target = partName(basedir + target)
 				}
+				d.rels[vals["Type"]][vals["Id"]] = target
 				if vals["Type"] == "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" {
-					d.primaryDoc = vals["Target"]
+					d.primaryDoc = target
 				}
 			default:
-				if grate.Debug {
-					log.Println("      Unhandled relationship xml tag", v.Name.Local, v.Attr)
-				}
+				d.opts.Logger().Debug("xlsx: unhandled relationship xml tag", "tag", v.Name.Local, "attrs", v.Attr)
 			}
 		case xml.EndElement:
 			// not needed
 		default:
-			if grate.Debug {
-				log.Printf("      Unhandled relationship xml tokens %T %+v", tok, tok)
-			}
+			d.opts.Logger().Debug("xlsx: unhandled relationship xml token", "token", tok)
 		}
 	}
 	if err == io.EOF {
@@ -57,11 +62,28 @@ func (d *Document) parseRels(dec *xml.Decoder, basedir string) error {
 }
 
 func (d *Document) parseWorkbook(dec *xml.Decoder) error {
+	// print areas and titles by local sheet index
+	printAreas := make(map[int]string)
+	printTitles := make(map[int]string)
+	nameSheet, nameKind := -1, ""
+	var nameText []byte
+
 	tok, err := dec.RawToken()
 	for ; err == nil; tok, err = dec.RawToken() {
 		switch v := tok.(type) {
 		case xml.StartElement:
 			switch v.Name.Local {
+			case "definedName":
+				ax := getAttrs(v.Attr, "name", "localSheetId")
+				nameSheet = -1
+				if ax[0] == "_xlnm.Print_Area" || ax[0] == "_xlnm.Print_Titles" {
+					if idx, err := strconv.Atoi(ax[1]); err == nil {
+						nameSheet, nameKind = idx, ax[0]
+						nameText = nameText[:0]
+					}
+				}
+			case "definedNames":
+				// container
 			case "sheet":
 				vals := make(map[string]string, 5)
 				for _, a := range v.Attr {
@@ -73,39 +95,83 @@ func (d *Document) parseWorkbook(dec *xml.Decoder) error {
 					return errors.New("xlsx: invalid sheet definition")
 				}
 				s := &Sheet{
-					d:       d,
-					relID:   sheetID,
-					name:    sheetName,
-					docname: d.rels["http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet"][sheetID],
-					err:     errNotLoaded,
+					d:     d,
+					relID: sheetID,
+					name:  sheetName,
+					typ:   SheetTypeWorksheet,
+					err:   errNotLoaded,
+				}
+				for _, rt := range sheetRelTypes {
+					if fn, ok := d.rels[rt.relType][sheetID]; ok {
+						s.docname, s.typ = fn, rt.typ
+						break
+					}
 				}
 				d.sheets = append(d.sheets, s)
+			case "workbookPr":
+				switch getAttrs(v.Attr, "date1904")[0] {
+				case "1", "true":
+					d.date1904 = true
+					d.fmt.Mode1904(true)
+				}
 			case "workbook", "sheets":
 				// containers
 			default:
-				if grate.Debug {
-					log.Println("      Unhandled workbook xml tag", v.Name.Local, v.Attr)
-				}
+				d.opts.Logger().Debug("xlsx: unhandled workbook xml tag", "tag", v.Name.Local, "attrs", v.Attr)
+			}
+		case xml.CharData:
+			if nameSheet >= 0 {
+				nameText = append(nameText, v...)
 			}
 		case xml.EndElement:
-			// not needed
-		default:
-			if grate.Debug {
-				log.Printf("      Unhandled workbook xml tokens %T %+v", tok, tok)
+			if v.Name.Local == "definedName" && nameSheet >= 0 {
+				if nameKind == "_xlnm.Print_Area" {
+					printAreas[nameSheet] = printAreaRange(string(nameText))
+				} else {
+					printTitles[nameSheet] = string(nameText)
+				}
+				nameSheet = -1
 			}
+		default:
+			d.opts.Logger().Debug("xlsx: unhandled workbook xml token", "token", tok)
 		}
 	}
 	if err == io.EOF {
 		err = nil
 	}
+	for idx, area := range printAreas {
+		if idx < len(d.sheets) {
+			d.sheets[idx].printArea = area
+		}
+	}
+	for idx, titles := range printTitles {
+		if idx < len(d.sheets) {
+			d.sheets[idx].printTitles = titles
+		}
+	}
 	return err
 }
 
+// printAreaRange converts a print area formula such as 'Sheet 1'!$A$1:$G$50
+// into a plain cell range. Multiple areas are separated by commas.
+func printAreaRange(formula string) string {
+	parts := splitRefs(formula)
+	for i, p := range parts {
+		if j := strings.LastIndexByte(p, '!'); j >= 0 {
+			p = p[j+1:]
+		}
+		parts[i] = strings.ReplaceAll(strings.TrimSpace(p), "$", "")
+	}
+	return strings.Join(parts, ",")
+}
+
 func (d *Document) parseStyles(dec *xml.Decoder) error {
 	baseNumFormats := []string{}
 	d.xfs = d.xfs[:0]
 
 	section := 0
+	inFills := false
+	d.fills = d.fills[:0]
 	tok, err := dec.RawToken()
 	for ; err == nil; tok, err = dec.RawToken() {
 		switch v := tok.(type) {
@@ -118,6 +184,26 @@ func (d *Document) parseStyles(dec *xml.Decoder) error {
 				fmtNo, _ := strconv.ParseInt(ax[0], 10, 16)
 				d.fmt.Add(uint16(fmtNo), ax[1])
 
+			case "fills":
+				inFills = true
+			case "fill":
+				if inFills {
+					d.fills = append(d.fills, cellFill{})
+				}
+			case "patternFill":
+				if inFills && len(d.fills) > 0 {
+					d.fills[len(d.fills)-1].pattern = getAttrs(v.Attr, "patternType")[0]
+				}
+			case "fgColor", "bgColor":
+				if inFills && len(d.fills) > 0 {
+					c := parseColorRef(v.Attr)
+					if v.Name.Local == "fgColor" {
+						d.fills[len(d.fills)-1].fg = &c
+					} else {
+						d.fills[len(d.fills)-1].bg = &c
+					}
+				}
+
 			case "cellStyleXfs":
 				section = 1
 			case "cellXfs":
@@ -125,9 +211,10 @@ func (d *Document) parseStyles(dec *xml.Decoder) error {
 				ax := getAttrs(v.Attr, "count")
 				n, _ := strconv.ParseInt(ax[0], 10, 64)
 				d.xfs = make([]uint16, 0, n)
+				d.xfFills = make([]int, 0, n)
 
 			case "xf":
-				ax := getAttrs(v.Attr, "numFmtId", "applyNumberFormat", "xfId")
+				ax := getAttrs(v.Attr, "numFmtId", "applyNumberFormat", "xfId", "fillId")
 				if section == 1 {
 					// load base styles, but only save number format
 					if ax[1] == "0" {
@@ -154,25 +241,25 @@ func (d *Document) parseStyles(dec *xml.Decoder) error {
 
 					nfid, _ := strconv.ParseInt(numFmtID, 10, 16)
 					d.xfs = append(d.xfs, uint16(nfid))
+					fillID, _ := strconv.Atoi(ax[3])
+					d.xfFills = append(d.xfFills, fillID)
 				} else {
-					panic("wheres is this xf??")
+					d.opts.Logger().Debug("xlsx: xf outside of a style section")
 				}
 			default:
-				if grate.Debug {
-					log.Println("  Unhandled style xml tag", v.Name.Local, v.Attr)
-				}
+				d.opts.Logger().Debug("xlsx: unhandled style xml tag", "tag", v.Name.Local, "attrs", v.Attr)
 			}
 		case xml.EndElement:
 			switch v.Name.Local {
+			case "fills":
+				inFills = false
 			case "cellStyleXfs":
 				section = 0
 			case "cellXfs":
 				section = 0
 			}
 		default:
-			if grate.Debug {
-				log.Printf("      Unhandled style xml tokens %T %+v", tok, tok)
-			}
+			d.opts.Logger().Debug("xlsx: unhandled style xml token", "token", tok)
 		}
 	}
 	if err == io.EOF {
@@ -181,39 +268,69 @@ func (d *Document) parseStyles(dec *xml.Decoder) error {
 	return err
 }
 
+// ErrTruncatedSharedStrings is returned when opening a file whose shared
+// string table has fewer entries than it declares, e.g. because the file
+// was only partially written. Cells referring to the missing strings would
+// have the wrong values.
+var ErrTruncatedSharedStrings = errors.New("xlsx: shared string table is truncated")
+
+// parseSharedStrings reads the shared string table. The text of a string
+// item is either a single <t> element, or the <t> elements of its rich text
+// runs <r>. Phonetic hints (<rPh>) and run properties are not part of the
+// text.
 func (d *Document) parseSharedStrings(dec *xml.Decoder) error {
 	val := ""
+	first, expect := len(d.strings), -1
+	inText, inPhonetic, inRunProps := false, false, false
 	tok, err := dec.RawToken()
 	for ; err == nil; tok, err = dec.RawToken() {
 		switch v := tok.(type) {
 		case xml.CharData:
-			val += string(v)
+			if inText && !inPhonetic {
+				val += string(v)
+			}
 		case xml.StartElement:
 			switch v.Name.Local {
 			case "si":
 				val = ""
 			case "t":
-				// no attributes to parse, we only want the CharData ...
+				inText = true
+			case "rPh":
+				inPhonetic = true
+			case "rPr":
+				inRunProps = true
 			case "sst":
-				// main container
+				if n, err := strconv.Atoi(getAttrs(v.Attr, "uniqueCount")[0]); err == nil {
+					expect = n
+				}
+			case "r", "phoneticPr":
+				// containers and hints
 			default:
-				if grate.Debug {
-					log.Println("  Unhandled SST xml tag", v.Name.Local, v.Attr)
+				if !inRunProps {
+					d.opts.Logger().Debug("xlsx: unhandled SST xml tag", "tag", v.Name.Local, "attrs", v.Attr)
 				}
 			}
 		case xml.EndElement:
-			if v.Name.Local == "si" {
+			switch v.Name.Local {
+			case "si":
 				d.strings = append(d.strings, val)
-				continue
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic = false
+			case "rPr":
+				inRunProps = false
 			}
 		default:
-			if grate.Debug {
-				log.Printf("    Unhandled SST xml token %T %+v", tok, tok)
-			}
+			d.opts.Logger().Debug("xlsx: unhandled SST xml token", "token", tok)
 		}
 	}
 	if err == io.EOF {
 		err = nil
 	}
+	if n := len(d.strings) - first; err == nil && expect >= 0 && n < expect {
+		d.opts.Logger().Warn("xlsx: shared string table is truncated", "strings", n, "expected", expect)
+		err = grate.WrapErr(fmt.Errorf("xlsx: shared string table is truncated: found %d of %d strings", n, expect), ErrTruncatedSharedStrings)
+	}
 	return err
 }
@@ -6,20 +6,27 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"image/color"
 	"io"
 	"io/fs"
-	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/wubin1989/grate"
 	"github.com/wubin1989/grate/commonxl"
+	"golang.org/x/text/unicode/norm"
 )
 
 var _ = grate.Register("xlsx", 5, Open)
 var _ = grate.RegisterFile("xlsx", 5, OpenFile)
 var _ = grate.RegisterReader("xlsx", 5, OpenReader)
+var _ = grate.RegisterOptions("xlsx", OpenWithOptions)
+var _ = grate.RegisterDetect("xlsx", func(header []byte) bool {
+	// zip local file header
+	return bytes.HasPrefix(header, []byte("PK\x03\x04"))
+})
 
 // Document contains an Office Open XML document.
 type Document struct {
@@ -34,6 +41,51 @@ type Document struct {
 	strings []string
 	xfs     []uint16
 	fmt     commonxl.Formatter
+
+	// fills from the styles, and the fill index of each cell style
+	fills   []cellFill
+	xfFills []int
+
+	// date1904 is set for workbooks using the 1904 date system
+	date1904 bool
+
+	// externalStrings is set when the shared string table is stored
+	// outside of the package
+	externalStrings bool
+
+	// themeColors are parsed from the theme, nil to use the defaults
+	themeColors []color.RGBA
+
+	// props are the document properties, parsed on first use
+	props *docProps
+
+	opts grate.Options
+
+	// strictNames disables normalised sheet name matching
+	strictNames bool
+}
+
+// Is1904DateSystem returns true if the dates of the workbook are counted
+// from January 1, 1904 (as used by early versions of Excel for Mac) rather
+// than from 1900.
+func (d *Document) Is1904DateSystem() bool {
+	return d.date1904
+}
+
+// HasMacros returns true if the workbook contains a VBA project (as in
+// macro-enabled .xlsm files). Macros are never executed.
+func (d *Document) HasMacros() bool {
+	for _, name := range d.rels["http://schemas.microsoft.com/office/2006/relationships/vbaProject"] {
+		if d.findFile(name) != nil {
+			return true
+		}
+	}
+	for _, zf := range d.r.File {
+		if strings.EqualFold(path.Base(zipPath(zf.Name)), "vbaProject.bin") {
+			return true
+		}
+	}
+	return false
 }
 
 func (d *Document) Close() error {
@@ -50,6 +102,25 @@ func (d *Document) Close() error {
 }
 
 func Open(filename string) (grate.Source, error) {
+	return OpenWithOptions(filename)
+}
+
+// strictSheetNamesOption disables normalised sheet name lookups.
+type strictSheetNamesOption struct{}
+
+// OptionName implements the grate.Option interface.
+func (strictSheetNamesOption) OptionName() string { return "StrictSheetNames" }
+
+// WithStrictSheetNames requires sheet names passed to Get and Sheet to match
+// the stored names exactly, instead of ignoring case, surrounding whitespace
+// and Unicode normalisation differences.
+func WithStrictSheetNames() grate.Option { return strictSheetNamesOption{} }
+
+// OpenWithOptions opens an Excel workbook using the given options.
+// Supported: grate.MaxMemoryBytes, grate.StrictMode, grate.DateTimezone,
+// grate.WithErrorHandler, grate.WithDebugLogger, grate.WithMaxSheets,
+// grate.WithLocale, WithStrictSheetNames.
+func OpenWithOptions(filename string, opts ...grate.Option) (grate.Source, error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -60,13 +131,27 @@ func Open(filename string) (grate.Source, error) {
 	}
 	z, err := zip.NewReader(f, info.Size())
 	if err != nil {
-		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
+		err = notZipError(f, info.Size(), err)
+		f.Close()
+		return nil, err
 	}
 	d := &Document{
 		filename: filename,
 		f:        f,
 		r:        z,
+		opts:     grate.ParseOptions(opts...),
+	}
+	for _, o := range opts {
+		if _, ok := o.(strictSheetNamesOption); ok {
+			d.strictNames = true
+		}
 	}
+	if d.opts.ExceedsMemory(d.uncompressedSize()) {
+		f.Close()
+		return nil, grate.ErrTooLarge
+	}
+	d.fmt = d.fmt.WithLocale(d.opts.Locale)
+	d.fmt.SetLocation(d.opts.DateTimezone)
 
 	err = d.init()
 	if err != nil {
@@ -77,49 +162,32 @@ func Open(filename string) (grate.Source, error) {
 	return d, nil
 }
 
-// OpenFile opens an Excel workbook from an fs.File.
+// OpenFile opens an Excel workbook from an fs.File. Files which do not
+// implement io.ReaderAt, or whose size is not available from Stat, are
+// read into memory and closed.
 func OpenFile(file fs.File) (grate.Source, error) {
-	// We need to check if the file implements ReaderAt for zip.NewReader
-	ra, ok := file.(io.ReaderAt)
-	if !ok {
-		// If not a ReaderAt, we need to read all bytes to use a bytes.Reader
+	var ra io.ReaderAt
+	var size int64
+	var closer io.Closer = file
+
+	if fra, ok := file.(io.ReaderAt); ok {
+		if stat, err := file.Stat(); err == nil {
+			ra, size = fra, stat.Size()
+		}
+	}
+	if ra == nil {
 		data, err := io.ReadAll(file)
 		if err != nil {
 			return nil, err
 		}
-		if closer, ok := file.(io.Closer); ok {
-			closer.Close()
-		}
-
-		// Create a dummy readerat that's backed by the data
-		type readAtCloser struct {
-			io.ReaderAt
-			io.Closer
-		}
-		ra = &readAtCloser{
-			ReaderAt: bytes.NewReader(data),
-			Closer:   io.NopCloser(nil),
-		}
-		file = nil // we've already closed it if possible
+		file.Close()
+		ra, size = bytes.NewReader(data), int64(len(data))
+		closer = nil
 	}
 
-	// Get file size
-	stat, err := file.Stat()
+	z, err := zip.NewReader(ra, size)
 	if err != nil {
-		return nil, err
-	}
-
-	z, err := zip.NewReader(ra, stat.Size())
-	if err != nil {
-		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
-	}
-
-	// Only set f to file if it's a closer, otherwise leave it nil
-	var closer io.Closer
-	if file != nil {
-		if c, ok := file.(io.Closer); ok {
-			closer = c
-		}
+		return nil, notZipError(ra, size, err)
 	}
 
 	d := &Document{
@@ -155,7 +223,7 @@ func OpenReader(reader io.ReadCloser) (grate.Source, error) {
 	// Create a zip reader
 	z, err := zip.NewReader(br, int64(len(data)))
 	if err != nil {
-		return nil, grate.WrapErr(err, grate.ErrNotInFormat)
+		return nil, notZipError(br, int64(len(data)), err)
 	}
 
 	// Create and initialize the document
@@ -176,6 +244,7 @@ func OpenReader(reader io.ReadCloser) (grate.Source, error) {
 // init initializes the document by parsing relationships and workbook structure
 func (d *Document) init() error {
 	d.rels = make(map[string]map[string]string, 4)
+	d.checkContentTypes()
 
 	// parse the primary relationships
 	dec, c, err := d.openXML("_rels/.rels")
@@ -190,6 +259,7 @@ func (d *Document) init() error {
 	if d.primaryDoc == "" {
 		return errors.New("xlsx: invalid document")
 	}
+	d.checkMainContentType()
 
 	// parse the secondary relationships to primary doc
 	base := filepath.Base(d.primaryDoc)
@@ -215,6 +285,11 @@ func (d *Document) init() error {
 	if err != nil {
 		return err
 	}
+	if d.opts.ExceedsSheets(len(d.sheets)) {
+		d.opts.Logger().Warn("xlsx: too many sheets, only the first are available",
+			"sheets", len(d.sheets), "limit", d.opts.MaxSheets)
+		d.sheets = d.sheets[:d.opts.MaxSheets]
+	}
 
 	styn := d.rels["http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"]
 	for _, sst := range styn {
@@ -230,10 +305,29 @@ func (d *Document) init() error {
 		}
 	}
 
+	thn := d.rels["http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"]
+	for _, th := range thn {
+		// parse the theme colours, which only affect cell colours, so the
+		// default theme is kept if the part is missing or malformed
+		dec, c, err = d.openXML(th)
+		if err == nil {
+			err = d.parseTheme(dec)
+			c.Close()
+		}
+		if err != nil {
+			d.opts.Logger().Debug("xlsx: invalid theme, using the default colours", "part", th, "error", err)
+		}
+	}
+
 	ssn := d.rels["http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings"]
 	for _, sst := range ssn {
 		// parse the shared string table
 		dec, c, err = d.openXML(sst)
+		if err == io.EOF {
+			// a dangling relationship, string cells will be blank
+			d.opts.Logger().Debug("xlsx: shared string table is missing", "name", sst)
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -247,21 +341,68 @@ func (d *Document) init() error {
 	return nil
 }
 
+// uncompressedSize returns the total uncompressed size of all zip members.
+func (d *Document) uncompressedSize() int64 {
+	var n int64
+	for _, zf := range d.r.File {
+		n += int64(zf.UncompressedSize64)
+	}
+	return n
+}
+
+// openXML opens the named part of the package. Backslash separators in
+// the zip entry names (written by some producers) match forward slashes.
 func (d *Document) openXML(name string) (*xml.Decoder, io.Closer, error) {
-	if grate.Debug {
-		log.Println("    openXML", name)
+	d.opts.Logger().Debug("xlsx: openXML", "name", name)
+	zf := d.findFile(name)
+	if zf == nil {
+		return nil, nil, io.EOF
+	}
+	zfr, err := zf.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	dec := xml.NewDecoder(zfr)
+	return dec, zfr, nil
+}
+
+// findFile returns the zip entry matching name after normalising both
+// with partName, and falls back to comparing the names as-is. As some
+// producers write part names in a different case than they reference them
+// (e.g. "xl/sharedstrings.xml"), and part names are case-insensitive, a
+// case-insensitive match is used if there is no exact one.
+func (d *Document) findFile(name string) *zip.File {
+	norm := partName(name)
+	for _, zf := range d.r.File {
+		if partName(zf.Name) == norm {
+			return zf
+		}
 	}
 	for _, zf := range d.r.File {
 		if zf.Name == name {
-			zfr, err := zf.Open()
-			if err != nil {
-				return nil, nil, err
-			}
-			dec := xml.NewDecoder(zfr)
-			return dec, zfr, nil
+			return zf
 		}
 	}
-	return nil, nil, io.EOF
+	lower := strings.ToLower(norm)
+	for _, zf := range d.r.File {
+		if strings.ToLower(partName(zf.Name)) == lower {
+			return zf
+		}
+	}
+	return nil
+}
+
+// zipPath normalises the path separators of a zip entry name.
+func zipPath(name string) string {
+	return strings.ReplaceAll(name, "\\", "/")
+}
+
+// partName canonicalises the name of a package part for comparison:
+// separators are normalised, a leading "/" is removed and "." and ".."
+// elements are resolved, so that "/xl/workbook.xml", "./xl/workbook.xml"
+// and "xl/workbook.xml" all match.
+func partName(name string) string {
+	return path.Clean(strings.TrimPrefix(zipPath(name), "/"))
 }
 
 func (d *Document) List() ([]string, error) {
@@ -272,14 +413,62 @@ func (d *Document) List() ([]string, error) {
 	return res, nil
 }
 
+// SheetCount returns the number of sheets in the workbook.
+func (d *Document) SheetCount() (int, error) {
+	return len(d.sheets), nil
+}
+
+// Get returns the named sheet as a grate.Collection (a *Sheet). The sheet
+// contents are parsed on first use, or when calling Sheet.Preload.
 func (d *Document) Get(sheetName string) (grate.Collection, error) {
+	s := d.findSheet(sheetName)
+	if s == nil {
+		return nil, grate.WrapErr(errors.New("xlsx: sheet not found"), grate.ErrSheetNotFound)
+	}
+	return s, nil
+}
+
+// Sheet returns the named worksheet, parsing it if necessary. It provides
+// access to xlsx-specific sheet metadata not exposed by grate.Collection.
+//
+// An exact match of the name is preferred. Otherwise names are compared
+// ignoring case, leading and trailing whitespace and Unicode normalisation
+// form, as long as only one sheet matches (see WithStrictSheetNames).
+func (d *Document) Sheet(sheetName string) (*Sheet, error) {
+	c, err := d.Get(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	s := c.(*Sheet)
+	return s, s.Preload()
+}
+
+func (d *Document) findSheet(sheetName string) *Sheet {
 	for _, s := range d.sheets {
 		if s.name == sheetName {
-			if s.err == errNotLoaded {
-				s.err = s.parseSheet()
+			return s
+		}
+	}
+	if d.strictNames {
+		return nil
+	}
+
+	var found *Sheet
+	want := normalizeSheetName(sheetName)
+	for _, s := range d.sheets {
+		if strings.EqualFold(normalizeSheetName(s.name), want) {
+			if found != nil {
+				d.opts.Logger().Debug("xlsx: ambiguous sheet name", "name", sheetName)
+				return nil
 			}
-			return s.wrapped, s.err
+			found = s
 		}
 	}
-	return nil, errors.New("xlsx: sheet not found")
+	return found
+}
+
+// normalizeSheetName trims surrounding whitespace and converts the name
+// to Unicode normalisation form C.
+func normalizeSheetName(name string) string {
+	return norm.NFC.String(strings.TrimSpace(name))
 }
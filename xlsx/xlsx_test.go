@@ -2,9 +2,19 @@ package xlsx
 
 import (
 	"bytes"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/wubin1989/grate"
+	"github.com/wubin1989/grate/xls/cfb"
 )
 
 // 使用testdata中的所有Excel文件测试OpenReader
@@ -49,6 +59,9 @@ func TestOpenReaderWithTestData(t *testing.T) {
 			if len(sheets) == 0 {
 				t.Fatalf("Expected at least one sheet in %s", filePath)
 			}
+			if n, err := source.SheetCount(); err != nil || n != len(sheets) {
+				t.Fatalf("SheetCount for %s = %d, %v, expected %d", filePath, n, err, len(sheets))
+			}
 
 			// 验证能否获取每个工作表
 			for _, sheetName := range sheets {
@@ -101,3 +114,446 @@ func TestOpenReaderWithTestData(t *testing.T) {
 		})
 	}
 }
+
+// noStatFile is an io.ReaderAt fs.File whose size is not available from Stat.
+type noStatFile struct {
+	fs.File
+	io.ReaderAt
+}
+
+func (noStatFile) Stat() (fs.FileInfo, error) {
+	return nil, errors.New("stat not supported")
+}
+
+// readOnlyFile is an fs.File which does not implement io.ReaderAt.
+type readOnlyFile struct {
+	fs.File
+}
+
+func TestOpenFile(t *testing.T) {
+	data, err := os.ReadFile("../testdata/basic.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"basic.xlsx": {Data: data}}
+
+	for name, wrap := range map[string]func(fs.File) fs.File{
+		"ReaderAt":    func(f fs.File) fs.File { return f },
+		"no Stat":     func(f fs.File) fs.File { return noStatFile{f, f.(io.ReaderAt)} },
+		"no ReaderAt": func(f fs.File) fs.File { return readOnlyFile{f} },
+	} {
+		f, err := fsys.Open("basic.xlsx")
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := OpenFile(wrap(f))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if sheets, _ := src.List(); len(sheets) == 0 {
+			t.Errorf("%s: expected sheets", name)
+		}
+		src.Close()
+	}
+}
+
+func TestValues(t *testing.T) {
+	wb, err := Open(buildFixture(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := c.Width(); w != 2 {
+		t.Errorf("expected the declared width 2, got %d", w)
+	}
+	var rows [][]interface{}
+	for c.Next() {
+		rows = append(rows, c.Values())
+	}
+	if len(rows) < 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	expect := [][]interface{}{{"a", "b"}, {1.0, 2.0}}
+	if !reflect.DeepEqual(rows[:2], expect) {
+		t.Errorf("got %#v, expected %#v", rows[:2], expect)
+	}
+}
+
+func TestBackslashZipPaths(t *testing.T) {
+	wb, err := Open(buildFixture(t, map[string]string{
+		"xl/worksheets/sheet1.xml": "",
+		`xl\worksheets\sheet1.xml`: fixtureSheet,
+		"xl/sharedStrings.xml":     "",
+		`xl\sharedStrings.xml`:     fixtureSharedStrings,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() {
+		t.Fatalf("expected rows, got error %v", c.Err())
+	}
+	if row := c.Strings(); !reflect.DeepEqual(row, []string{"a", "b"}) {
+		t.Errorf("got %q, expected [a b]", row)
+	}
+	if problems := wb.(*Document).contentTypeProblems(); len(problems) != 0 {
+		t.Errorf("unexpected content type problems %v", problems)
+	}
+}
+
+func TestSharedFormulaValues(t *testing.T) {
+	sheet := worksheetXML(`<dimension ref="A1:B3"/><sheetData>` +
+		`<row r="1"><c r="A1"><f t="shared" ref="A1:A3" si="0">B1*2</f><v>4</v></c><c r="B1"><v>2</v></c></row>` +
+		`<row r="2"><c r="A2"><f t="shared" si="0"/></c><c r="B2"><v>2</v></c></row>` +
+		`<row r="3"><c r="A3"><f t="shared" si="1"/></c><c r="B3"><f>B2+1</f><v>3</v></c></row>` +
+		`</sheetData>`)
+	wb, err := Open(buildFixture(t, map[string]string{"xl/worksheets/sheet1.xml": sheet}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for c.Next() {
+		rows = append(rows, c.Strings())
+	}
+	expect := [][]string{{"4", "2"}, {"4", "2"}, {"", "3"}}
+	if len(rows) < 3 || !reflect.DeepEqual(rows[:3], expect) {
+		t.Errorf("got %q, expected %q", rows, expect)
+	}
+}
+
+func TestDate1904(t *testing.T) {
+	styles := stylesXML(`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>`)
+	sheet := worksheetXML(`<dimension ref="A1:B1"/><sheetData><row r="1"><c r="A1" s="1"><v>0</v></c><c r="B1" s="1"><v>1462</v></c></row></sheetData>`)
+	for _, date1904 := range []bool{false, true} {
+		workbook := fixtureWorkbook
+		expect := time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+		if date1904 {
+			workbook = strings.Replace(workbook, "<sheets>", `<workbookPr date1904="1"/><sheets>`, 1)
+			expect = time.Date(1908, 1, 2, 0, 0, 0, 0, time.UTC)
+		}
+		wb, err := Open(buildFixture(t, map[string]string{
+			"xl/workbook.xml":          workbook,
+			"xl/styles.xml":            styles,
+			"xl/worksheets/sheet1.xml": sheet,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := wb.(*Document).Is1904DateSystem(); got != date1904 {
+			t.Errorf("Is1904DateSystem() = %v, expected %v", got, date1904)
+		}
+		c, _ := wb.Get("Sheet1")
+		if !c.Next() {
+			t.Fatal("expected a row")
+		}
+		vals := c.Values()
+		if got, ok := vals[1].(time.Time); !ok || !got.Equal(expect) {
+			t.Errorf("1904=%v: got %v, expected %v", date1904, vals[1], expect)
+		}
+		wb.Close()
+	}
+}
+
+func TestMacroEnabled(t *testing.T) {
+	fn := buildFixture(t, nil)
+	wb, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wb.(*Document).HasMacros() {
+		t.Error("expected no macros in a plain workbook")
+	}
+	wb.Close()
+
+	fn = buildFixture(t, map[string]string{
+		"[Content_Types].xml": strings.Replace(fixtureContentTypes,
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml",
+			"application/vnd.ms-excel.sheet.macroEnabled.main+xml", 1),
+		"xl/_rels/workbook.xml.rels": strings.Replace(fixtureWorkbookRels, "</Relationships>",
+			`<Relationship Id="rId9" Type="http://schemas.microsoft.com/office/2006/relationships/vbaProject" Target="vbaProject.bin"/></Relationships>`, 1),
+		"xl/vbaProject.bin": "\xd0\xcf\x11\xe0",
+	})
+	wb, err = Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	d := wb.(*Document)
+	if got := d.mainContentType(); got != "application/vnd.ms-excel.sheet.macroEnabled.main+xml" {
+		t.Errorf("unexpected main content type %q", got)
+	}
+	if !d.HasMacros() {
+		t.Error("expected HasMacros to be true")
+	}
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() || c.Strings()[0] != "a" {
+		t.Errorf("unexpected first row %v", c.Strings())
+	}
+}
+
+func TestSheetType(t *testing.T) {
+	fn := buildFixture(t, map[string]string{
+		"xl/workbook.xml": strings.Replace(fixtureWorkbook, "</sheets>",
+			`<sheet name="Chart1" sheetId="2" r:id="rId4"/></sheets>`, 1),
+		"xl/_rels/workbook.xml.rels": strings.Replace(fixtureWorkbookRels, "</Relationships>",
+			`<Relationship Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartsheet" Target="chartsheets/sheet1.xml"/></Relationships>`, 1),
+		"xl/chartsheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<chartsheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetViews><sheetView workbookViewId="0"/></sheetViews><drawing r:id="rId1" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"/></chartsheet>`,
+	})
+	wb, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	d := wb.(*Document)
+
+	for name, expect := range map[string]SheetType{
+		"Sheet1":  SheetTypeWorksheet,
+		"Chart1":  SheetTypeChart,
+		"Missing": SheetTypeUnknown,
+	} {
+		if got := d.SheetType(name); got != expect {
+			t.Errorf("SheetType(%q) = %v, expected %v", name, got, expect)
+		}
+	}
+
+	c, err := wb.Get("Chart1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Next() {
+		t.Error("expected no records in a chart sheet")
+	}
+	if err := c.Err(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRichTextSharedStrings(t *testing.T) {
+	sst := `<?xml version="1.0" encoding="UTF-8"?>
+<sst count="2" uniqueCount="2" xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+  <si>
+    <r><rPr><b/><sz val="11"/><rFont val="Calibri"/></rPr><t>Hello</t></r>
+    <r><t xml:space="preserve"> world</t></r>
+  </si>
+  <si>
+    <t>東京</t>
+    <rPh sb="0" eb="2"><t>トウキョウ</t></rPh>
+    <phoneticPr fontId="1"/>
+  </si>
+</sst>`
+	wb, err := Open(buildFixture(t, map[string]string{"xl/sharedStrings.xml": sst}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, _ := wb.Get("Sheet1")
+	if !c.Next() {
+		t.Fatal("expected a row")
+	}
+	if got := c.Strings(); !reflect.DeepEqual(got, []string{"Hello world", "東京"}) {
+		t.Errorf("unexpected strings %q", got)
+	}
+}
+
+func TestInlineStrings(t *testing.T) {
+	sheet := worksheetXML(`<dimension ref="A1:D1"/><sheetData><row r="1">
+  <c r="A1" t="inlineStr"><is><t>plain</t></is></c>
+  <c r="B1" t="is"><is><t>short</t></is></c>
+  <c r="C1" t="inlineStr">
+    <is>
+      <r><rPr><b/></rPr><t>rich</t></r>
+      <r><t xml:space="preserve"> text</t></r>
+    </is>
+  </c>
+  <c r="D1" t="inlineStr"><is><t>東京</t><rPh sb="0" eb="2"><t>トウキョウ</t></rPh></is></c>
+</row></sheetData>`)
+	wb, err := Open(buildFixture(t, map[string]string{"xl/worksheets/sheet1.xml": sheet}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := wb.Get("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() {
+		t.Fatal("expected a row")
+	}
+	if got, want := c.Strings(), []string{"plain", "short", "rich text", "東京"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got strings %q, expected %q", got, want)
+	}
+	if got, want := c.Types(), []string{"string", "string", "string", "string"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got types %q, expected %q", got, want)
+	}
+}
+
+func TestEncryptedPackage(t *testing.T) {
+	build := func(streams ...string) []byte {
+		doc := cfb.NewDocument()
+		for _, name := range streams {
+			w, err := doc.CreateStream(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("encrypted"))
+			w.Close()
+		}
+		var buf bytes.Buffer
+		if err := doc.Save(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	encrypted := build("EncryptionInfo", "EncryptedPackage")
+	fn := filepath.Join(t.TempDir(), "encrypted.xlsx")
+	if err := os.WriteFile(fn, encrypted, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(fn); !errors.Is(err, grate.ErrEncrypted) {
+		t.Errorf("Open: expected ErrEncrypted, got %v", err)
+	}
+	if _, err := OpenReader(io.NopCloser(bytes.NewReader(encrypted))); !errors.Is(err, grate.ErrEncrypted) {
+		t.Errorf("OpenReader: expected ErrEncrypted, got %v", err)
+	}
+
+	other := build("Workbook")
+	if _, err := OpenReader(io.NopCloser(bytes.NewReader(other))); !errors.Is(err, grate.ErrNotInFormat) {
+		t.Errorf("expected ErrNotInFormat for other compound files, got %v", err)
+	}
+}
+
+func TestMaxSheets(t *testing.T) {
+	fn := buildFixture(t, map[string]string{
+		"xl/workbook.xml": strings.Replace(fixtureWorkbook, "</sheets>",
+			`<sheet name="Sheet2" sheetId="2" r:id="rId1"/></sheets>`, 1),
+	})
+	wb, err := OpenWithOptions(fn, grate.WithMaxSheets(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	if names, _ := wb.List(); !reflect.DeepEqual(names, []string{"Sheet1"}) {
+		t.Errorf("unexpected sheets %v", names)
+	}
+	if n, _ := wb.SheetCount(); n != 1 {
+		t.Errorf("expected 1 sheet, got %d", n)
+	}
+	if _, err := wb.Get("Sheet2"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+
+	// hidden sheets count towards the limit
+	fn = buildFixture(t, map[string]string{
+		"xl/workbook.xml": strings.Replace(fixtureWorkbook, "<sheet ",
+			`<sheet name="Hidden" sheetId="2" state="hidden" r:id="rId1"/><sheet `, 1),
+	})
+	wb2, err := OpenWithOptions(fn, grate.WithMaxSheets(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb2.Close()
+	if names, _ := wb2.List(); !reflect.DeepEqual(names, []string{"Hidden"}) {
+		t.Errorf("unexpected sheets %v", names)
+	}
+	if _, err := wb2.Get("Sheet1"); !errors.Is(err, grate.ErrSheetNotFound) {
+		t.Errorf("expected ErrSheetNotFound, got %v", err)
+	}
+}
+
+func TestRelationshipTargets(t *testing.T) {
+	for _, target := range []string{"/xl/workbook.xml", "./xl/workbook.xml", "xl/./workbook.xml"} {
+		fn := buildFixture(t, map[string]string{
+			"_rels/.rels": strings.Replace(fixtureRels, `Target="xl/workbook.xml"`, `Target="`+target+`"`, 1),
+			"xl/_rels/workbook.xml.rels": strings.Replace(fixtureWorkbookRels,
+				`Target="worksheets/sheet1.xml"`, `Target="../xl/worksheets/sheet1.xml"`, 1),
+		})
+		wb, err := Open(fn)
+		if err != nil {
+			t.Errorf("%s: %v", target, err)
+			continue
+		}
+		c, err := wb.Get("Sheet1")
+		if err != nil {
+			t.Errorf("%s: %v", target, err)
+		} else if !c.Next() || c.Strings()[0] != "a" {
+			t.Errorf("%s: unexpected first row %v", target, c.Strings())
+		}
+		wb.Close()
+	}
+}
+
+func TestPartNameCase(t *testing.T) {
+	for _, name := range []string{"xl/sharedstrings.xml", "xl/SharedStrings.xml", "XL/SHAREDSTRINGS.XML"} {
+		fn := buildFixture(t, map[string]string{
+			"xl/sharedStrings.xml": "",
+			name:                   fixtureSharedStrings,
+		})
+		wb, err := Open(fn)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		c, err := wb.Get("Sheet1")
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !c.Next() || c.Strings()[0] != "a" {
+			t.Errorf("%s: unexpected first row %v", name, c.Strings())
+		}
+		wb.Close()
+	}
+}
+
+func TestTruncatedSharedStrings(t *testing.T) {
+	_, err := Open(buildFixture(t, map[string]string{
+		"xl/sharedStrings.xml": strings.Replace(fixtureSharedStrings, `uniqueCount="2"`, `uniqueCount="3"`, 1),
+	}))
+	if !errors.Is(err, ErrTruncatedSharedStrings) {
+		t.Errorf("expected ErrTruncatedSharedStrings, got %v", err)
+	}
+
+	// count is the number of references to the strings, not their number
+	wb, err := Open(buildFixture(t, map[string]string{
+		"xl/sharedStrings.xml": strings.Replace(fixtureSharedStrings, `count="2"`, `count="5"`, 1),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+}
+
+func TestSingleCellNotEmpty(t *testing.T) {
+	fn := buildFixture(t, map[string]string{
+		"xl/worksheets/sheet1.xml": worksheetXML(`<dimension ref="A1"/><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c></row></sheetData>`),
+	})
+	wb, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	c, err := grate.ConvenienceSource{Source: wb}.GetNonEmpty("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Next() || c.Strings()[0] != "a" {
+		t.Errorf("expected the single value, got %q", c.Strings())
+	}
+}